@@ -0,0 +1,40 @@
+// Package cache provides the shared cache behind the API's computed responses, either kept in
+// process memory or in a Redis-protocol server shared by every replica.
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Backend names accepted by New.
+const (
+	BackendMemory = "memory"
+	BackendRedis  = "redis"
+)
+
+// Cache stores byte values that expire after a TTL.
+type Cache interface {
+	// Get returns the value stored under key and whether it was found.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value under key for ttl.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Close releases the backend's resources.
+	Close() error
+}
+
+// New returns the cache backend with the given name. redisURL is only used by the redis backend.
+func New(backend string, redisURL string) (Cache, error) {
+	switch backend {
+		case "", BackendMemory:
+			return NewMemory(DefaultMemoryEntries), nil
+		case BackendRedis:
+			if redisURL == "" {
+				return nil, fmt.Errorf("the redis cache backend needs a Redis URL")
+			}
+			return NewRedis(redisURL)
+		default:
+			return nil, fmt.Errorf("invalid cache backend: %s; supported: 'memory', 'redis'", backend)
+	}
+}
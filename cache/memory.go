@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultMemoryEntries bounds the memory cache created by New.
+const DefaultMemoryEntries = 10000
+
+type memoryEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+// Memory is a Cache held in process memory. Past its maximum size expired entries are swept and,
+// failing that, the cache is reset.
+type Memory struct {
+	mu         sync.Mutex
+	entries    map[string]memoryEntry
+	maxEntries int
+}
+
+// NewMemory returns an empty memory cache holding up to maxEntries entries.
+func NewMemory(maxEntries int) *Memory {
+	return &Memory{entries: map[string]memoryEntry{}, maxEntries: maxEntries}
+}
+
+func (m *Memory) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if !time.Now().Before(entry.expires) {
+		delete(m.entries, key)
+		return nil, false, nil
+	}
+	return entry.value, true, nil
+}
+
+func (m *Memory) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.entries[key]; !ok && len(m.entries) >= m.maxEntries {
+		now := time.Now()
+		for k, e := range m.entries {
+			if !now.Before(e.expires) {
+				delete(m.entries, k)
+			}
+		}
+		if len(m.entries) >= m.maxEntries {
+			m.entries = map[string]memoryEntry{}
+		}
+	}
+	m.entries[key] = memoryEntry{value: value, expires: time.Now().Add(ttl)}
+	return nil
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
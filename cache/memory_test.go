@@ -0,0 +1,93 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestMemoryGetSet(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(10)
+	if _, ok, err := m.Get(ctx, "missing"); ok || err != nil {
+		t.Fatalf("Get(missing) = %v, %v, want a miss", ok, err)
+	}
+	if err := m.Set(ctx, "key", []byte("value"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	value, ok, err := m.Get(ctx, "key")
+	if err != nil || !ok || string(value) != "value" {
+		t.Fatalf("Get(key) = %q, %v, %v, want value", value, ok, err)
+	}
+	// Setting again replaces the value
+	m.Set(ctx, "key", []byte("other"), time.Minute)
+	if value, _, _ := m.Get(ctx, "key"); string(value) != "other" {
+		t.Errorf("Get(key) = %q after overwrite, want other", value)
+	}
+}
+
+func TestMemoryExpiry(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(10)
+	m.Set(ctx, "key", []byte("value"), 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	if _, ok, _ := m.Get(ctx, "key"); ok {
+		t.Error("expired entry was returned")
+	}
+	if len(m.entries) != 0 {
+		t.Errorf("expired entry was not dropped on read, %d entries left", len(m.entries))
+	}
+}
+
+func TestMemoryEviction(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(3)
+	m.Set(ctx, "short", []byte("1"), 10*time.Millisecond)
+	m.Set(ctx, "a", []byte("2"), time.Minute)
+	m.Set(ctx, "b", []byte("3"), time.Minute)
+	time.Sleep(20 * time.Millisecond)
+
+	// Full: the expired entry is swept to make room
+	m.Set(ctx, "c", []byte("4"), time.Minute)
+	if len(m.entries) != 3 {
+		t.Fatalf("%d entries after sweeping, want 3", len(m.entries))
+	}
+	for _, key := range []string{"a", "b", "c"} {
+		if _, ok, _ := m.Get(ctx, key); !ok {
+			t.Errorf("live entry %s was evicted", key)
+		}
+	}
+
+	// Full of live entries: the cache starts over
+	m.Set(ctx, "d", []byte("5"), time.Minute)
+	if len(m.entries) != 1 {
+		t.Fatalf("%d entries after reset, want 1", len(m.entries))
+	}
+	if _, ok, _ := m.Get(ctx, "d"); !ok {
+		t.Error("new entry missing after reset")
+	}
+
+	// Overwriting an existing key never evicts
+	for i := 0; i < 2; i++ {
+		m.Set(ctx, fmt.Sprint(i), []byte("x"), time.Minute)
+	}
+	m.Set(ctx, "d", []byte("6"), time.Minute)
+	if len(m.entries) != 3 {
+		t.Errorf("%d entries after overwriting, want 3", len(m.entries))
+	}
+}
+
+func TestNew(t *testing.T) {
+	if c, err := New("", ""); err != nil {
+		t.Error(err)
+	} else if _, ok := c.(*Memory); !ok {
+		t.Errorf("New(\"\") = %T, want *Memory", c)
+	}
+	if _, err := New(BackendRedis, ""); err == nil {
+		t.Error("expected an error for the redis backend without a URL")
+	}
+	if _, err := New("memcached", ""); err == nil {
+		t.Error("expected an error for an unknown backend")
+	}
+}
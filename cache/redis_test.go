@@ -0,0 +1,86 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, *Redis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	c, err := New(BackendRedis, "redis://"+mr.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return mr, c.(*Redis)
+}
+
+func TestRedisGetSet(t *testing.T) {
+	ctx := context.Background()
+	mr, r := newTestRedis(t)
+	if _, ok, err := r.Get(ctx, "missing"); ok || err != nil {
+		t.Fatalf("Get(missing) = %v, %v, want a miss", ok, err)
+	}
+	if err := r.Set(ctx, "key", []byte("value"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	value, ok, err := r.Get(ctx, "key")
+	if err != nil || !ok || string(value) != "value" {
+		t.Fatalf("Get(key) = %q, %v, %v, want value", value, ok, err)
+	}
+	// Keys are namespaced and carry the TTL
+	if got, err := mr.Get(keyPrefix + "key"); err != nil || got != "value" {
+		t.Errorf("server holds %q, %v under the prefixed key", got, err)
+	}
+	if ttl := mr.TTL(keyPrefix + "key"); ttl != time.Minute {
+		t.Errorf("TTL = %v, want 1m", ttl)
+	}
+}
+
+func TestRedisExpiry(t *testing.T) {
+	ctx := context.Background()
+	mr, r := newTestRedis(t)
+	r.Set(ctx, "key", []byte("value"), time.Minute)
+	mr.FastForward(2 * time.Minute)
+	if _, ok, err := r.Get(ctx, "key"); ok || err != nil {
+		t.Errorf("Get(key) = %v, %v after expiry, want a miss", ok, err)
+	}
+}
+
+func TestRedisShared(t *testing.T) {
+	ctx := context.Background()
+	mr, r := newTestRedis(t)
+	// A second replica sees what the first stored
+	other, err := NewRedis("redis://" + mr.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	r.Set(ctx, "key", []byte("value"), time.Minute)
+	if value, ok, _ := other.Get(ctx, "key"); !ok || string(value) != "value" {
+		t.Errorf("other replica got %q, %v", value, ok)
+	}
+}
+
+func TestRedisErrors(t *testing.T) {
+	ctx := context.Background()
+	mr, r := newTestRedis(t)
+	addr := mr.Addr()
+	mr.Close()
+	if _, _, err := r.Get(ctx, "key"); err == nil {
+		t.Error("expected an error from Get with the server gone")
+	}
+	if err := r.Set(ctx, "key", []byte("value"), time.Minute); err == nil {
+		t.Error("expected an error from Set with the server gone")
+	}
+	if _, err := NewRedis("redis://" + addr); err == nil {
+		t.Error("expected an error connecting to a stopped server")
+	}
+	if _, err := NewRedis("http://localhost"); err == nil {
+		t.Error("expected an error for an invalid URL")
+	}
+}
@@ -1,13 +1,28 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
+	"github.com/astrocartics-xyz/Astrocartics-API/cache"
+	"github.com/astrocartics-xyz/Astrocartics-API/config"
 	"github.com/astrocartics-xyz/Astrocartics-API/controller"
 	"github.com/astrocartics-xyz/Astrocartics-API/dba"
-	_ "github.com/astrocartics-xyz/Astrocartics-API/docs" // Import the generated docs
+	"github.com/astrocartics-xyz/Astrocartics-API/docs" // The generated docs
+	"github.com/astrocartics-xyz/Astrocartics-API/logging"
+	"github.com/astrocartics-xyz/Astrocartics-API/metrics"
+	"github.com/astrocartics-xyz/Astrocartics-API/service"
+	"github.com/astrocartics-xyz/Astrocartics-API/tracing"
 	"github.com/go-chi/chi/v5"
 	"github.com/joho/godotenv"
 )
@@ -17,22 +32,186 @@ import (
 // @description The application programmer interface for Astrocartics for statistics about Eve Online.
 // @host api.astrocartics.xyz
 // @BasePath /v1
+// @securityDefinitions.apikey AdminToken
+// @in header
+// @name Authorization
+// @securityDefinitions.apikey APIKey
+// @in header
+// @name X-API-Key
 func main() {
 	err := godotenv.Load()
 	if err != nil {
 		log.Println("Error loading .env file, using environment variables")
 	}
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := logging.Setup(os.Stderr, cfg.Log.Format, cfg.Log.Level); err != nil {
+		log.Fatalf("Failed to set up logging: %v", err)
+	}
+
+	// Export request traces when an exporter is configured
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing.Exporter)
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %v", err)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			slog.Error("Error flushing traces", "err", err)
+		}
+	}()
+
+	// Stop serving and the background workers on SIGINT or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	dba.InitDB(dba.ConnConfig{
+		URL:               cfg.Database.URL,
+		MaxOpenConns:      cfg.Database.MaxOpenConns,
+		MaxIdleConns:      cfg.Database.MaxIdleConns,
+		ConnMaxLifetime:   cfg.Database.ConnMaxLifetime,
+		ConnMaxIdleTime:   cfg.Database.ConnMaxIdleTime,
+		ConnectRetries:    cfg.Database.ConnectRetries,
+		ConnectRetryDelay: cfg.Database.ConnectRetryDelay,
+	})
+	defer dba.CloseDB()
+	metrics.RegisterDBStats(dba.GetDB())
+	metrics.RegisterIngestionLag(dba.GetLatestKillmailTime)
+
+	// Bring the schema up to date before serving when asked to
+	if cfg.Database.MigrateOnStart {
+		if _, err := dba.MigrateUp(); err != nil {
+			log.Fatalf("Failed to apply migrations: %v", err)
+		}
+	}
+
+	// Share computed kill aggregates between replicas when a Redis backend is configured
+	aggregates, err := cache.New(cfg.Cache.Backend, cfg.Cache.RedisURL)
+	if err != nil {
+		log.Fatalf("Failed to set up the cache: %v", err)
+	}
+	defer aggregates.Close()
+	service.SetAggregateCache(aggregates)
+	for mode, ttl := range cfg.Cache.AggregateTTLs {
+		service.SetAggregateTTL(mode, ttl)
+	}
+	controller.StaticMaxAge = cfg.Cache.StaticMaxAge
+
+	// Keep the kill rollups fresh and serve aggregates from them
+	if cfg.Rollups.Enabled {
+		service.StartKillRollupWorker(ctx, cfg.Rollups.Interval)
+	}
+
+	// Create upcoming killmail partitions and apply the retention policy
+	if cfg.Partitions.Enabled {
+		service.StartPartitionMaintenance(ctx, cfg.Partitions.Interval, service.PartitionPolicy{
+			Ahead:           cfg.Partitions.Ahead,
+			RetentionMonths: cfg.Partitions.RetentionMonths,
+			Action:          cfg.Partitions.RetentionAction,
+			ArchiveDir:      cfg.Partitions.ArchiveDir,
+		})
+	}
+
+	// API keys and rate limits, and the token guarding key administration
+	controller.RateLimitEnabled = cfg.RateLimit.Enabled
+	controller.AdminToken = cfg.RateLimit.AdminToken
+	controller.TrustedProxies = cfg.TrustedProxyPrefixes()
+	service.Tiers = make(map[string]service.Tier, len(cfg.RateLimit.Tiers))
+	for name, t := range cfg.RateLimit.Tiers {
+		service.Tiers[name] = service.Tier{Rate: t.Rate, Burst: t.Burst, MaxCost: t.MaxCost}
+	}
+
+	// Deadlines for the queries behind each request, and readiness
+	controller.DefaultQueryTimeout = cfg.Query.Timeout
+	controller.QueryTimeouts = cfg.Query.Timeouts
+	service.AggregateTimeout = cfg.Query.Timeout
+	for _, timeout := range cfg.Query.Timeouts {
+		service.AggregateTimeout = max(service.AggregateTimeout, timeout)
+	}
+	service.MaxIngestionLag = cfg.Readiness.MaxIngestionLag
 
-	dba.InitDB()
+	// Cross-origin requests and the Swagger UI
+	controller.CORSConfig = controller.CORSPolicy{
+		AllowedOrigins: cfg.CORS.AllowedOrigins,
+		AllowedMethods: cfg.CORS.AllowedMethods,
+		AllowedHeaders: cfg.CORS.AllowedHeaders,
+		ExposedHeaders: cfg.CORS.ExposedHeaders,
+		MaxAge:         cfg.CORS.MaxAge,
+	}
+	controller.DocsEnabled = cfg.Docs.Enabled
+	controller.DocsURL = cfg.Docs.URL
+	docs.SwaggerInfo.Host = cfg.Docs.Host
 
 	r := chi.NewRouter()
 	controller.RegisterRoutes(r)
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	// Requests still running when the shutdown timeout passes are cancelled through their context
+	requests, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+	srv := &http.Server{
+		Addr:              ":" + strconv.Itoa(cfg.Server.Port),
+		Handler:           r,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+		BaseContext:       func(net.Listener) context.Context { return requests },
 	}
 
-	log.Printf("Server starting on port %s...", port)
-	log.Fatal(http.ListenAndServe(":"+port, r))
+	serveErr := make(chan error, 1)
+	go func() {
+		slog.Info("Server starting", "port", cfg.Server.Port)
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	// Metrics get their own listener, so they are never reachable through the public API port
+	var metricsSrv *http.Server
+	metricsErr := make(chan error, 1)
+	if cfg.Server.MetricsAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.Handler())
+		metricsSrv = &http.Server{
+			Addr:              cfg.Server.MetricsAddr,
+			Handler:           mux,
+			ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		}
+		go func() {
+			slog.Info("Metrics server starting", "addr", cfg.Server.MetricsAddr)
+			metricsErr <- metricsSrv.ListenAndServe()
+		}()
+	}
+
+	select {
+		case err := <-serveErr:
+			slog.Error("Server stopped", "err", err)
+			os.Exit(1)
+		case err := <-metricsErr:
+			slog.Error("Metrics server stopped", "err", err)
+			os.Exit(1)
+		case <-ctx.Done():
+	}
+
+	// Fail readiness and keep serving until load balancers have seen it, then let in-flight
+	// requests finish before closing the pool, cache and exporter
+	slog.Info("Shutting down", "delay", cfg.Server.ShutdownDelay, "timeout", cfg.Server.ShutdownTimeout)
+	service.BeginShutdown()
+	time.Sleep(cfg.Server.ShutdownDelay)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("Requests still running at the shutdown timeout, cancelling them", "err", err)
+		cancelRequests()
+		srv.Close()
+	}
+	if err := <-serveErr; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("Server stopped", "err", err)
+	}
+	if metricsSrv != nil {
+		metricsSrv.Close()
+	}
+	slog.Info("Server stopped")
 }
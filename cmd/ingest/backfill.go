@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/astrocartics-xyz/Astrocartics-API/dba"
+	"github.com/astrocartics-xyz/Astrocartics-API/ingest"
+)
+
+// runBackfill implements `ingest backfill [flags] <file or directory>...`.
+func runBackfill(args []string) {
+	batch, _ := strconv.Atoi(envOr("BACKFILL_BATCH_SIZE", "5000"))
+	opts := ingest.BackfillOptions{}
+	fs := flag.NewFlagSet("backfill", flag.ExitOnError)
+	fs.Usage = func() {
+		log.Printf("Usage: ingest backfill [flags] <file or directory>...\n\nImports zKillboard killmail_id -> hash dumps, ESI killmail JSON files and tar.bz2 archives.\n")
+		fs.PrintDefaults()
+	}
+	fs.IntVar(&opts.BatchSize, "batch-size", batch, "killmails per COPY batch")
+	fs.StringVar(&opts.StateFile, "state", envOr("BACKFILL_STATE_FILE", "backfill-state.json"), "progress file used to resume an interrupted import (empty disables)")
+	fs.StringVar(&opts.ESIURL, "esi-url", envOr("ESI_URL", "https://esi.evetech.net/latest"), "ESI base URL used to expand killmail_id -> hash dumps")
+	fs.IntVar(&opts.Concurrency, "concurrency", 8, "parallel ESI requests when expanding hash dumps")
+	fs.StringVar(&opts.UserAgent, "user-agent", envOr("INGEST_USER_AGENT", "Astrocartics-API ingest"), "User-Agent sent to ESI")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	dba.InitDB(dba.DefaultConnConfig())
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	b := &ingest.Backfill{Options: opts, Client: &http.Client{Timeout: 30 * time.Second}}
+	if err := b.Run(ctx, fs.Args()); err != nil {
+		log.Fatalf("Backfill stopped: %v", err)
+	}
+}
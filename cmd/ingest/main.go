@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/astrocartics-xyz/Astrocartics-API/dba"
+	"github.com/astrocartics-xyz/Astrocartics-API/ingest"
+	"github.com/joho/godotenv"
+)
+
+// envOr returns the environment variable key, or fallback when it is unset.
+func envOr(key string, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// Ingest consumes a zKillboard RedisQ or R2Z2 style feed and upserts killmails into the database.
+// `ingest backfill` imports historical dumps instead.
+func main() {
+	err := godotenv.Load()
+	if err != nil {
+		log.Println("Error loading .env file, using environment variables")
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "backfill" {
+		runBackfill(os.Args[2:])
+		return
+	}
+
+	ttw, _ := strconv.Atoi(envOr("INGEST_TTW", "10"))
+	cfg := ingest.FeedConfig{}
+	flag.StringVar(&cfg.Format, "format", envOr("INGEST_FORMAT", ingest.FormatRedisQ), "feed format: redisq or r2z2")
+	flag.StringVar(&cfg.URL, "feed-url", envOr("INGEST_FEED_URL", "https://zkillredisq.stream/listen.php"), "feed endpoint (RedisQ listen URL or R2Z2 base URL)")
+	flag.StringVar(&cfg.QueueID, "queue-id", os.Getenv("INGEST_QUEUE_ID"), "RedisQ queue identifier")
+	flag.IntVar(&cfg.TTW, "ttw", ttw, "RedisQ seconds to wait for a killmail per request")
+	flag.Int64Var(&cfg.StartSeq, "start-seq", 0, "R2Z2 sequence to start from (0 = current)")
+	flag.StringVar(&cfg.UserAgent, "user-agent", envOr("INGEST_USER_AGENT", "Astrocartics-API ingest"), "User-Agent sent to the feed and ESI")
+	statsEvery := flag.Duration("stats-interval", time.Minute, "how often to log ingest statistics")
+	flag.Parse()
+
+	client := &http.Client{Timeout: time.Duration(cfg.TTW+30) * time.Second}
+	feed, err := ingest.NewFeed(cfg, client)
+	if err != nil {
+		log.Fatalf("Invalid feed configuration: %v", err)
+	}
+
+	dba.InitDB(dba.DefaultConnConfig())
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	in := &ingest.Ingester{Feed: feed, Client: client, UserAgent: cfg.UserAgent}
+	go func() {
+		ticker := time.NewTicker(*statsEvery)
+		defer ticker.Stop()
+		for {
+			select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					log.Printf("Ingest stats: %+v", in.Stats())
+			}
+		}
+	}()
+
+	log.Printf("Ingesting %s feed from %s...", cfg.Format, cfg.URL)
+	if err := in.Run(ctx); err != nil {
+		log.Fatalf("Ingest stopped: %v", err)
+	}
+	log.Printf("Ingest stopped. Final stats: %+v", in.Stats())
+}
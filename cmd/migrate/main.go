@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/astrocartics-xyz/Astrocartics-API/config"
+	"github.com/astrocartics-xyz/Astrocartics-API/dba"
+	"github.com/astrocartics-xyz/Astrocartics-API/service"
+	"github.com/joho/godotenv"
+)
+
+// Migrate applies, rolls back or lists the schema migrations embedded in the dba package.
+func main() {
+	err := godotenv.Load()
+	if err != nil {
+		log.Println("Error loading .env file, using environment variables")
+	}
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage:\n  migrate up          apply all pending migrations\n  migrate down [n]    roll back the last n migrations (default 1)\n  migrate status      list migrations and whether they are applied\n  migrate partition   convert killmails into monthly partitions\n  migrate maintain    create upcoming killmail partitions and apply the retention policy\n")
+	}
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Only the database and partition settings are used, from CONFIG_FILE and the environment
+	cfg, err := config.Load(nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	dba.InitDB(dba.ConnConfig{
+		URL:               cfg.Database.URL,
+		MaxOpenConns:      cfg.Database.MaxOpenConns,
+		MaxIdleConns:      cfg.Database.MaxIdleConns,
+		ConnMaxLifetime:   cfg.Database.ConnMaxLifetime,
+		ConnMaxIdleTime:   cfg.Database.ConnMaxIdleTime,
+		ConnectRetries:    cfg.Database.ConnectRetries,
+		ConnectRetryDelay: cfg.Database.ConnectRetryDelay,
+	})
+
+	switch flag.Arg(0) {
+		case "up":
+			applied, err := dba.MigrateUp()
+			if err != nil {
+				log.Fatalf("Migration failed: %v", err)
+			}
+			log.Printf("Applied %d migration(s).", len(applied))
+		case "down":
+			steps := 1
+			if flag.NArg() > 1 {
+				steps, err = strconv.Atoi(flag.Arg(1))
+				if err != nil || steps < 1 {
+					log.Fatalf("Invalid number of migrations to roll back: %s", flag.Arg(1))
+				}
+			}
+			rolledBack, err := dba.MigrateDown(steps)
+			if err != nil {
+				log.Fatalf("Rollback failed: %v", err)
+			}
+			log.Printf("Rolled back %d migration(s).", len(rolledBack))
+		case "status":
+			status, err := dba.GetMigrationStatus()
+			if err != nil {
+				log.Fatalf("Failed to read migration status: %v", err)
+			}
+			for _, s := range status {
+				state := "pending"
+				if s.Applied {
+					state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+				}
+				fmt.Printf("%04d_%-30s %s\n", s.Version, s.Name, state)
+			}
+		case "partition":
+			if err := dba.PartitionKillmails(context.Background()); err != nil {
+				log.Fatalf("Partitioning failed: %v", err)
+			}
+			log.Println("Partitioned killmails by month.")
+			fallthrough
+		case "maintain":
+			policy := service.PartitionPolicy{
+				Ahead:           cfg.Partitions.Ahead,
+				RetentionMonths: cfg.Partitions.RetentionMonths,
+				Action:          cfg.Partitions.RetentionAction,
+				ArchiveDir:      cfg.Partitions.ArchiveDir,
+			}
+			if err := service.MaintainKillmailPartitions(context.Background(), policy); err != nil {
+				log.Fatalf("Partition maintenance failed: %v", err)
+			}
+			partitions, err := dba.GetKillmailPartitions(context.Background())
+			if err != nil {
+				log.Fatalf("Failed to list partitions: %v", err)
+			}
+			log.Printf("killmails has %d monthly partition(s).", len(partitions))
+		default:
+			flag.Usage()
+			os.Exit(2)
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/astrocartics-xyz/Astrocartics-API/dba"
+	"github.com/astrocartics-xyz/Astrocartics-API/sde"
+	"github.com/joho/godotenv"
+)
+
+// Sdeimport loads regions, constellations, systems, stargates, planets and stations from a local
+// Static Data Export directory, printing what changes against the current database.
+func main() {
+	err := godotenv.Load()
+	if err != nil {
+		log.Println("Error loading .env file, using environment variables")
+	}
+
+	dryRun := flag.Bool("dry-run", false, "only report the diff, do not write anything")
+	prune := flag.Bool("prune", true, "delete rows that are no longer in the SDE")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: sdeimport [flags] <sde directory>\n\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	dir := flag.Arg(0)
+
+	log.Printf("Reading SDE from %s...", dir)
+	next, err := sde.Load(dir)
+	if err != nil {
+		log.Fatalf("Failed to read SDE: %v", err)
+	}
+
+	dba.InitDB(dba.DefaultConnConfig())
+
+	current, err := sde.Current(context.Background())
+	if err != nil {
+		log.Fatalf("Failed to read current static data: %v", err)
+	}
+	changed := false
+	for _, d := range sde.Diff(current, next) {
+		fmt.Println(d)
+		if !d.Empty() && (*prune || len(d.Added) > 0 || len(d.Changed) > 0) {
+			changed = true
+		}
+	}
+	if *dryRun {
+		log.Println("Dry run, nothing written.")
+		return
+	}
+	if !changed {
+		log.Println("Static data is already up to date.")
+		return
+	}
+	if err := dba.ReplaceStaticData(context.Background(), next.Tables(), *prune); err != nil {
+		log.Fatalf("Failed to import static data: %v", err)
+	}
+	log.Println("Static data imported.")
+}
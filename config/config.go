@@ -0,0 +1,430 @@
+// Package config loads the API server's settings from an optional YAML or TOML file, environment
+// variables and command-line flags, in increasing order of precedence, and validates them.
+// It depends on no other package of the module; the commands hand the settings to the packages.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config holds every setting of the API server. Each field can be set under its yaml/toml key
+// in the config file; fields with an env tag can also be set by that environment variable,
+// and fields with a flag tag by that command-line flag.
+type Config struct {
+	Server     Server     `yaml:"server" toml:"server"`
+	Database   Database   `yaml:"database" toml:"database"`
+	Log        Log        `yaml:"log" toml:"log"`
+	Tracing    Tracing    `yaml:"tracing" toml:"tracing"`
+	Cache      Cache      `yaml:"cache" toml:"cache"`
+	CORS       CORS       `yaml:"cors" toml:"cors"`
+	RateLimit  RateLimit  `yaml:"rate_limit" toml:"rate_limit"`
+	Query      Query      `yaml:"query" toml:"query"`
+	Readiness  Readiness  `yaml:"readiness" toml:"readiness"`
+	Rollups    Rollups    `yaml:"rollups" toml:"rollups"`
+	Partitions Partitions `yaml:"partitions" toml:"partitions"`
+	Docs       Docs       `yaml:"docs" toml:"docs"`
+}
+
+// Server configures the HTTP server.
+type Server struct {
+	Port              int           `yaml:"port" toml:"port" env:"PORT" flag:"port" usage:"port to listen on"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" toml:"read_header_timeout" env:"SERVER_READ_HEADER_TIMEOUT" usage:"time allowed to read request headers"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" toml:"read_timeout" env:"SERVER_READ_TIMEOUT" usage:"time allowed to read a whole request, 0 for none"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" toml:"write_timeout" env:"SERVER_WRITE_TIMEOUT" usage:"time allowed to handle and write a response, 0 for none"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" toml:"idle_timeout" env:"SERVER_IDLE_TIMEOUT" usage:"how long idle keep-alive connections are kept"`
+	ShutdownDelay     time.Duration `yaml:"shutdown_delay" toml:"shutdown_delay" env:"SHUTDOWN_DELAY" usage:"time between failing readiness and closing the listener on shutdown"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" flag:"shutdown-timeout" usage:"time in-flight requests get to finish on shutdown"`
+	MetricsAddr       string        `yaml:"metrics_addr" toml:"metrics_addr" env:"METRICS_ADDR" flag:"metrics-addr" usage:"address serving /metrics, apart from the API, empty disables it"`
+}
+
+// Database configures the PostgreSQL connection pool.
+type Database struct {
+	URL               string        `yaml:"url" toml:"url" env:"DATABASE_URL" flag:"database-url" usage:"PostgreSQL connection URL"`
+	MaxOpenConns      int           `yaml:"max_open_conns" toml:"max_open_conns" env:"DB_MAX_OPEN_CONNS" usage:"maximum open connections, 0 for unlimited"`
+	MaxIdleConns      int           `yaml:"max_idle_conns" toml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS" usage:"maximum idle connections kept in the pool"`
+	ConnMaxLifetime   time.Duration `yaml:"conn_max_lifetime" toml:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME" usage:"how long a connection is reused, 0 for forever"`
+	ConnMaxIdleTime   time.Duration `yaml:"conn_max_idle_time" toml:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME" usage:"how long an idle connection is kept, 0 for forever"`
+	ConnectRetries    int           `yaml:"connect_retries" toml:"connect_retries" env:"DB_CONNECT_RETRIES" usage:"attempts to connect at startup"`
+	ConnectRetryDelay time.Duration `yaml:"connect_retry_delay" toml:"connect_retry_delay" env:"DB_CONNECT_RETRY_DELAY" usage:"wait between connection attempts"`
+	MigrateOnStart    bool          `yaml:"migrate_on_start" toml:"migrate_on_start" env:"MIGRATE_ON_START" flag:"migrate" usage:"apply pending migrations before serving"`
+}
+
+// Log configures the structured logger.
+type Log struct {
+	Format string `yaml:"format" toml:"format" env:"LOG_FORMAT" flag:"log-format" usage:"log format: text or json"`
+	Level  string `yaml:"level" toml:"level" env:"LOG_LEVEL" flag:"log-level" usage:"log level: debug, info, warn or error"`
+}
+
+// Tracing configures the trace exporter. The OTLP exporter itself is configured by the
+// standard OTEL_EXPORTER_OTLP_* variables.
+type Tracing struct {
+	Exporter string `yaml:"exporter" toml:"exporter" env:"TRACE_EXPORTER" flag:"trace-exporter" usage:"trace exporter: none, otlp or stdout"`
+}
+
+// Cache configures the aggregate cache and the lifetimes of cached responses.
+type Cache struct {
+	Backend       string                   `yaml:"backend" toml:"backend" env:"CACHE_BACKEND" usage:"aggregate cache backend: memory or redis"`
+	RedisURL      string                   `yaml:"redis_url" toml:"redis_url" env:"REDIS_URL" usage:"Redis URL of the redis backend"`
+	AggregateTTLs map[string]time.Duration `yaml:"aggregate_ttls" toml:"aggregate_ttls" env:"CACHE_AGGREGATE_TTLS" usage:"kill aggregate lifetime per mode, as mode=duration,...; 0 disables caching"`
+	StaticMaxAge  time.Duration            `yaml:"static_max_age" toml:"static_max_age" env:"STATIC_MAX_AGE" usage:"how long clients may reuse static data responses"`
+}
+
+// CORS configures cross-origin requests. No allowed origins disables CORS.
+type CORS struct {
+	AllowedOrigins []string      `yaml:"allowed_origins" toml:"allowed_origins" env:"CORS_ALLOWED_ORIGINS" usage:"origins allowed to call the API, * for any"`
+	AllowedMethods []string      `yaml:"allowed_methods" toml:"allowed_methods" env:"CORS_ALLOWED_METHODS" usage:"methods allowed in cross-origin requests"`
+	AllowedHeaders []string      `yaml:"allowed_headers" toml:"allowed_headers" env:"CORS_ALLOWED_HEADERS" usage:"request headers allowed in cross-origin requests"`
+	ExposedHeaders []string      `yaml:"exposed_headers" toml:"exposed_headers" env:"CORS_EXPOSED_HEADERS" usage:"response headers readable by cross-origin callers"`
+	MaxAge         time.Duration `yaml:"max_age" toml:"max_age" env:"CORS_MAX_AGE" usage:"how long browsers may cache preflight responses"`
+}
+
+// Tier is a usage tier: tokens refilled per second, bucket size and the heaviest request cost.
+type Tier struct {
+	Rate    float64 `yaml:"rate" toml:"rate"`
+	Burst   float64 `yaml:"burst" toml:"burst"`
+	MaxCost float64 `yaml:"max_cost" toml:"max_cost"`
+}
+
+// RateLimit configures API keys and rate limiting. Tiers can only be set in the config file;
+// each tier listed replaces the default tier of the same name.
+type RateLimit struct {
+	Enabled        bool            `yaml:"enabled" toml:"enabled" env:"RATE_LIMIT" usage:"enforce rate limits"`
+	AdminToken     string          `yaml:"admin_token" toml:"admin_token" env:"ADMIN_TOKEN" usage:"bearer token for /v1/admin, empty disables it"`
+	TrustedProxies []string        `yaml:"trusted_proxies" toml:"trusted_proxies" env:"TRUSTED_PROXIES" usage:"addresses or CIDR ranges of proxies whose X-Forwarded-For is believed"`
+	Tiers          map[string]Tier `yaml:"tiers" toml:"tiers"`
+}
+
+// Query configures the deadlines of the queries behind each request.
+type Query struct {
+	Timeout  time.Duration            `yaml:"timeout" toml:"timeout" env:"QUERY_TIMEOUT" usage:"query deadline of each request, 0 for none"`
+	Timeouts map[string]time.Duration `yaml:"timeouts" toml:"timeouts" env:"QUERY_TIMEOUTS" usage:"query deadlines per route pattern, as route=duration,..."`
+}
+
+// Readiness configures /readyz.
+type Readiness struct {
+	MaxIngestionLag time.Duration `yaml:"max_ingestion_lag" toml:"max_ingestion_lag" env:"READY_MAX_INGESTION_LAG" usage:"time since the last ingested killmail that fails readiness, 0 to only report it"`
+}
+
+// Rollups configures the kill rollup worker.
+type Rollups struct {
+	Enabled  bool          `yaml:"enabled" toml:"enabled" env:"KILL_ROLLUPS" usage:"serve kill aggregates from rollups"`
+	Interval time.Duration `yaml:"interval" toml:"interval" env:"KILL_ROLLUP_INTERVAL" usage:"how often the rollups are refreshed"`
+}
+
+// Partitions configures killmail partition maintenance.
+type Partitions struct {
+	Enabled         bool          `yaml:"enabled" toml:"enabled" env:"KILLMAIL_PARTITIONS" usage:"maintain monthly killmail partitions"`
+	Interval        time.Duration `yaml:"interval" toml:"interval" env:"KILLMAIL_PARTITIONS_INTERVAL" usage:"how often partitions are maintained"`
+	Ahead           int           `yaml:"ahead" toml:"ahead" env:"KILLMAIL_PARTITIONS_AHEAD" usage:"months of partitions created ahead"`
+	RetentionMonths int           `yaml:"retention_months" toml:"retention_months" env:"KILLMAIL_RETENTION_MONTHS" usage:"months kept before the current one, 0 keeps everything"`
+	RetentionAction string        `yaml:"retention_action" toml:"retention_action" env:"KILLMAIL_RETENTION_ACTION" usage:"what happens to older partitions: detach, drop or archive"`
+	ArchiveDir      string        `yaml:"archive_dir" toml:"archive_dir" env:"KILLMAIL_ARCHIVE_DIR" usage:"directory for archived partitions"`
+}
+
+// Docs configures the Swagger UI.
+type Docs struct {
+	Enabled bool   `yaml:"enabled" toml:"enabled" env:"DOCS_ENABLED" usage:"serve the Swagger UI under /swagger"`
+	URL     string `yaml:"url" toml:"url" env:"DOCS_URL" usage:"URL the Swagger UI loads the API definition from"`
+	Host    string `yaml:"host" toml:"host" env:"DOCS_HOST" usage:"host the API definition sends requests to, empty for the host serving it"`
+}
+
+// Log formats, trace exporters and cache backends.
+const (
+	FormatText     = "text"
+	FormatJSON     = "json"
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	BackendMemory  = "memory"
+	BackendRedis   = "redis"
+)
+
+// Partition retention actions.
+const (
+	RetentionDetach  = "detach"
+	RetentionDrop    = "drop"
+	RetentionArchive = "archive"
+)
+
+// TierAnonymous is the tier of requests without an API key, which must always be configured.
+const TierAnonymous = "anonymous"
+
+// Default returns the settings used for everything left unset.
+func Default() Config {
+	return Config{
+		Server: Server{
+			Port:              8080,
+			ReadHeaderTimeout: 10 * time.Second,
+			ReadTimeout:       30 * time.Second,
+			WriteTimeout:      2 * time.Minute,
+			IdleTimeout:       2 * time.Minute,
+			ShutdownDelay:     5 * time.Second,
+			ShutdownTimeout:   30 * time.Second,
+			MetricsAddr:       ":9090",
+		},
+		Database: Database{
+			MaxOpenConns:      25,
+			MaxIdleConns:      5,
+			ConnMaxLifetime:   30 * time.Minute,
+			ConnMaxIdleTime:   5 * time.Minute,
+			ConnectRetries:    10,
+			ConnectRetryDelay: 5 * time.Second,
+		},
+		Log:     Log{Format: FormatText, Level: "info"},
+		Tracing: Tracing{Exporter: ExporterNone},
+		Cache: Cache{
+			Backend: BackendMemory,
+			AggregateTTLs: map[string]time.Duration{
+				"hour":  30 * time.Second,
+				"day":   2 * time.Minute,
+				"week":  5 * time.Minute,
+				"month": 10 * time.Minute,
+			},
+			StaticMaxAge: 24 * time.Hour,
+		},
+		CORS: CORS{
+			AllowedMethods: []string{http.MethodGet, http.MethodPost, http.MethodDelete},
+			AllowedHeaders: []string{"Authorization", "Content-Type", "X-API-Key", "X-Request-ID", "If-None-Match"},
+			ExposedHeaders: []string{"ETag", "X-Request-ID", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "Retry-After"},
+			MaxAge:         10 * time.Minute,
+		},
+		RateLimit: RateLimit{
+			Enabled: true,
+			Tiers: map[string]Tier{
+				TierAnonymous: {Rate: 2, Burst: 30, MaxCost: 5},
+				"free":        {Rate: 5, Burst: 60, MaxCost: 5},
+				"pro":         {Rate: 25, Burst: 300, MaxCost: 20},
+				"internal":    {Rate: 200, Burst: 2000, MaxCost: 100},
+			},
+		},
+		Query: Query{
+			Timeout: 10 * time.Second,
+			Timeouts: map[string]time.Duration{
+				"/v1/regions/{regionID}/heatmap":                        30 * time.Second,
+				"/v1/killmails/distribution":                            30 * time.Second,
+				"/v1/systems/{systemID}/activity-profile":               30 * time.Second,
+				"/v1/constellations/{constellationID}/activity-profile": 30 * time.Second,
+				"/v1/regions/{regionID}/activity-profile":               30 * time.Second,
+				"/v1/regions/{regionID}/battles":                        30 * time.Second,
+				"/v1/battles/{battleID}":                                30 * time.Second,
+				"/v1/alerts/camps":                                      30 * time.Second,
+			},
+		},
+		Rollups: Rollups{Interval: time.Minute},
+		Partitions: Partitions{
+			Interval:        time.Hour,
+			Ahead:           3,
+			RetentionAction: RetentionDetach,
+			ArchiveDir:      "archive",
+		},
+		Docs: Docs{
+			Enabled: true,
+			URL:     "https://api.astrocartics.xyz/swagger/doc.json",
+			Host:    "api.astrocartics.xyz",
+		},
+	}
+}
+
+// Validate checks every setting and reports all the invalid ones at once, by their file keys.
+func (c Config) Validate() error {
+	var errs []error
+	fail := func(key string, format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+	}
+	notNegative := func(key string, d time.Duration) {
+		if d < 0 {
+			fail(key, "must not be negative, got %s", d)
+		}
+	}
+	positive := func(key string, d time.Duration) {
+		if d <= 0 {
+			fail(key, "must be positive, got %s", d)
+		}
+	}
+
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		fail("server.port", "must be between 1 and 65535, got %d", c.Server.Port)
+	}
+	positive("server.read_header_timeout", c.Server.ReadHeaderTimeout)
+	notNegative("server.read_timeout", c.Server.ReadTimeout)
+	notNegative("server.write_timeout", c.Server.WriteTimeout)
+	notNegative("server.idle_timeout", c.Server.IdleTimeout)
+	notNegative("server.shutdown_delay", c.Server.ShutdownDelay)
+	positive("server.shutdown_timeout", c.Server.ShutdownTimeout)
+	if c.Server.MetricsAddr != "" {
+		_, port, err := net.SplitHostPort(c.Server.MetricsAddr)
+		if n, perr := strconv.Atoi(port); err != nil || perr != nil || n < 1 || n > 65535 {
+			fail("server.metrics_addr", "%q is not an address like :9090 or 127.0.0.1:9090", c.Server.MetricsAddr)
+		} else if n == c.Server.Port {
+			fail("server.metrics_addr", "must not use the API port %d", c.Server.Port)
+		}
+	}
+
+	if c.Database.URL == "" {
+		fail("database.url", "is required")
+	} else if u, err := url.Parse(c.Database.URL); err != nil || (u.Scheme != "postgres" && u.Scheme != "postgresql") {
+		fail("database.url", "must be a postgres:// URL")
+	}
+	if c.Database.MaxOpenConns < 0 {
+		fail("database.max_open_conns", "must not be negative, got %d", c.Database.MaxOpenConns)
+	}
+	if c.Database.MaxIdleConns < 0 {
+		fail("database.max_idle_conns", "must not be negative, got %d", c.Database.MaxIdleConns)
+	}
+	if c.Database.MaxOpenConns > 0 && c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		fail("database.max_idle_conns", "must not exceed max_open_conns (%d), got %d", c.Database.MaxOpenConns, c.Database.MaxIdleConns)
+	}
+	notNegative("database.conn_max_lifetime", c.Database.ConnMaxLifetime)
+	notNegative("database.conn_max_idle_time", c.Database.ConnMaxIdleTime)
+	if c.Database.ConnectRetries < 1 {
+		fail("database.connect_retries", "must be at least 1, got %d", c.Database.ConnectRetries)
+	}
+	notNegative("database.connect_retry_delay", c.Database.ConnectRetryDelay)
+
+	switch strings.ToLower(c.Log.Format) {
+		case FormatText, FormatJSON:
+		default:
+			fail("log.format", "must be 'text' or 'json', got %q", c.Log.Format)
+	}
+	switch strings.ToLower(c.Log.Level) {
+		case "debug", "info", "warn", "error":
+		default:
+			fail("log.level", "must be 'debug', 'info', 'warn' or 'error', got %q", c.Log.Level)
+	}
+
+	switch c.Tracing.Exporter {
+		case ExporterNone, ExporterOTLP, ExporterStdout:
+		default:
+			fail("tracing.exporter", "must be 'none', 'otlp' or 'stdout', got %q", c.Tracing.Exporter)
+	}
+
+	switch c.Cache.Backend {
+		case BackendMemory:
+		case BackendRedis:
+			if c.Cache.RedisURL == "" {
+				fail("cache.redis_url", "is required by the redis backend")
+			}
+		default:
+			fail("cache.backend", "must be 'memory' or 'redis', got %q", c.Cache.Backend)
+	}
+	modes := Default().Cache.AggregateTTLs
+	for mode, ttl := range c.Cache.AggregateTTLs {
+		if _, ok := modes[mode]; !ok {
+			fail("cache.aggregate_ttls", "unknown mode %q; supported: 'hour', 'day', 'week', 'month'", mode)
+		}
+		notNegative("cache.aggregate_ttls."+mode, ttl)
+	}
+	notNegative("cache.static_max_age", c.Cache.StaticMaxAge)
+
+	for _, origin := range c.CORS.AllowedOrigins {
+		if origin == "*" {
+			continue
+		}
+		if u, err := url.Parse(origin); err != nil || u.Scheme == "" || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			fail("cors.allowed_origins", "%q is not an origin like https://example.com", origin)
+		}
+	}
+	if len(c.CORS.AllowedOrigins) > 0 && len(c.CORS.AllowedMethods) == 0 {
+		fail("cors.allowed_methods", "must not be empty when origins are allowed")
+	}
+	notNegative("cors.max_age", c.CORS.MaxAge)
+
+	if len(c.RateLimit.Tiers) == 0 {
+		fail("rate_limit.tiers", "must not be empty")
+	} else if _, ok := c.RateLimit.Tiers[TierAnonymous]; !ok {
+		fail("rate_limit.tiers", "must include the %s tier", TierAnonymous)
+	}
+	for _, proxy := range c.RateLimit.TrustedProxies {
+		if _, err := parsePrefix(proxy); err != nil {
+			fail("rate_limit.trusted_proxies", "%q is not an IP address or CIDR range like 10.0.0.0/8", proxy)
+		}
+	}
+	for name, t := range c.RateLimit.Tiers {
+		if t.Rate <= 0 || t.Burst <= 0 || t.MaxCost <= 0 {
+			fail("rate_limit.tiers."+name, "rate, burst and max_cost must be positive")
+		} else if t.MaxCost > t.Burst {
+			fail("rate_limit.tiers."+name, "max_cost (%g) must not exceed burst (%g)", t.MaxCost, t.Burst)
+		}
+	}
+
+	for pattern, timeout := range c.Query.Timeouts {
+		if !strings.HasPrefix(pattern, "/") {
+			fail("query.timeouts", "%q is not a route pattern like /v1/alerts/camps", pattern)
+		}
+		if c.Server.WriteTimeout > 0 && timeout > c.Server.WriteTimeout {
+			fail("query.timeouts."+pattern, "%s exceeds server.write_timeout (%s), so the error response could not be sent", timeout, c.Server.WriteTimeout)
+		}
+	}
+	if c.Server.WriteTimeout > 0 && c.Query.Timeout > c.Server.WriteTimeout {
+		fail("query.timeout", "%s exceeds server.write_timeout (%s), so the error response could not be sent", c.Query.Timeout, c.Server.WriteTimeout)
+	}
+
+	notNegative("readiness.max_ingestion_lag", c.Readiness.MaxIngestionLag)
+
+	if c.Rollups.Enabled {
+		positive("rollups.interval", c.Rollups.Interval)
+	}
+
+	if c.Partitions.Enabled {
+		positive("partitions.interval", c.Partitions.Interval)
+	}
+	if c.Partitions.Ahead < 0 {
+		fail("partitions.ahead", "must not be negative, got %d", c.Partitions.Ahead)
+	}
+	if c.Partitions.RetentionMonths < 0 {
+		fail("partitions.retention_months", "must not be negative, got %d", c.Partitions.RetentionMonths)
+	}
+	if c.Partitions.RetentionMonths > 0 {
+		switch c.Partitions.RetentionAction {
+			case RetentionDetach, RetentionDrop:
+			case RetentionArchive:
+				if c.Partitions.ArchiveDir == "" {
+					fail("partitions.archive_dir", "is required by the archive retention action")
+				}
+			default:
+				fail("partitions.retention_action", "must be 'detach', 'drop' or 'archive', got %q", c.Partitions.RetentionAction)
+		}
+	}
+
+	if c.Docs.Enabled && c.Docs.URL == "" {
+		fail("docs.url", "is required when the docs are enabled")
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration:\n%w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// TrustedProxyPrefixes returns the trusted proxies for controller.TrustedProxies.
+// Invalid entries are left out; Validate reports them.
+func (c Config) TrustedProxyPrefixes() []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, proxy := range c.RateLimit.TrustedProxies {
+		if prefix, err := parsePrefix(proxy); err == nil {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	return prefixes
+}
+
+// parsePrefix parses a CIDR range, or a single address as the range holding only it.
+func parsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		return prefix.Masked(), err
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
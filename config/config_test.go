@@ -0,0 +1,92 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/astrocartics-xyz/Astrocartics-API/cache"
+	"github.com/astrocartics-xyz/Astrocartics-API/config"
+	"github.com/astrocartics-xyz/Astrocartics-API/controller"
+	"github.com/astrocartics-xyz/Astrocartics-API/dba"
+	"github.com/astrocartics-xyz/Astrocartics-API/logging"
+	"github.com/astrocartics-xyz/Astrocartics-API/service"
+	"github.com/astrocartics-xyz/Astrocartics-API/tracing"
+)
+
+// The defaults are spelled out in config so it imports none of the packages it configures;
+// they must stay those packages' own defaults.
+func TestDefaultMatchesPackages(t *testing.T) {
+	cfg := config.Default()
+
+	conn := dba.DefaultConnConfig()
+	if cfg.Database.MaxOpenConns != conn.MaxOpenConns || cfg.Database.MaxIdleConns != conn.MaxIdleConns ||
+		cfg.Database.ConnMaxLifetime != conn.ConnMaxLifetime || cfg.Database.ConnMaxIdleTime != conn.ConnMaxIdleTime ||
+		cfg.Database.ConnectRetries != conn.ConnectRetries || cfg.Database.ConnectRetryDelay != conn.ConnectRetryDelay {
+		t.Errorf("database defaults %+v differ from dba.DefaultConnConfig %+v", cfg.Database, conn)
+	}
+	if cfg.Log.Format != logging.FormatText || config.FormatJSON != logging.FormatJSON {
+		t.Error("log formats differ from the logging package")
+	}
+	if cfg.Tracing.Exporter != tracing.ExporterNone || config.ExporterOTLP != tracing.ExporterOTLP || config.ExporterStdout != tracing.ExporterStdout {
+		t.Error("trace exporters differ from the tracing package")
+	}
+	if cfg.Cache.Backend != cache.BackendMemory || config.BackendRedis != cache.BackendRedis {
+		t.Error("cache backends differ from the cache package")
+	}
+	ttls := service.AggregateTTLs()
+	if len(cfg.Cache.AggregateTTLs) != len(ttls) {
+		t.Errorf("aggregate TTLs %v differ from service %v", cfg.Cache.AggregateTTLs, ttls)
+	}
+	for mode, ttl := range ttls {
+		if cfg.Cache.AggregateTTLs[mode] != ttl {
+			t.Errorf("aggregate TTL of %s is %s, service has %s", mode, cfg.Cache.AggregateTTLs[mode], ttl)
+		}
+	}
+	if cfg.Cache.StaticMaxAge != controller.StaticMaxAge {
+		t.Errorf("static max age %s differs from controller %s", cfg.Cache.StaticMaxAge, controller.StaticMaxAge)
+	}
+	if config.TierAnonymous != service.TierAnonymous || len(cfg.RateLimit.Tiers) != len(service.Tiers) {
+		t.Errorf("tiers %v differ from service %v", cfg.RateLimit.Tiers, service.Tiers)
+	}
+	for name, tier := range service.Tiers {
+		if got := cfg.RateLimit.Tiers[name]; got != (config.Tier{Rate: tier.Rate, Burst: tier.Burst, MaxCost: tier.MaxCost}) {
+			t.Errorf("tier %s is %+v, service has %+v", name, got, tier)
+		}
+	}
+	if cfg.Query.Timeout != controller.DefaultQueryTimeout || len(cfg.Query.Timeouts) != len(controller.QueryTimeouts) {
+		t.Errorf("query timeouts differ from controller")
+	}
+	for pattern, timeout := range controller.QueryTimeouts {
+		if cfg.Query.Timeouts[pattern] != timeout {
+			t.Errorf("query timeout of %s is %s, controller has %s", pattern, cfg.Query.Timeouts[pattern], timeout)
+		}
+	}
+	if cfg.Readiness.MaxIngestionLag != service.MaxIngestionLag {
+		t.Errorf("max ingestion lag %s differs from service %s", cfg.Readiness.MaxIngestionLag, service.MaxIngestionLag)
+	}
+	if config.RetentionDetach != service.RetentionDetach || config.RetentionDrop != service.RetentionDrop || config.RetentionArchive != service.RetentionArchive {
+		t.Error("retention actions differ from the service package")
+	}
+}
+
+func TestDefaultIsValid(t *testing.T) {
+	cfg := config.Default()
+	cfg.Database.URL = "postgres://localhost/astrocartics"
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidatePartitions(t *testing.T) {
+	cfg := config.Default()
+	cfg.Database.URL = "postgres://localhost/astrocartics"
+	cfg.Partitions.RetentionMonths = 6
+	cfg.Partitions.RetentionAction = config.RetentionArchive
+	cfg.Partitions.ArchiveDir = ""
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected archiving without a directory to be invalid")
+	}
+	cfg.Partitions.RetentionAction = "shred"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected an unknown retention action to be invalid")
+	}
+}
@@ -0,0 +1,188 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// fileEnv names the config file when the -config flag is not given.
+const fileEnv = "CONFIG_FILE"
+
+// Load builds the configuration from the defaults, the config file named by -config or
+// CONFIG_FILE, the environment and the flags in args, each overriding the one before,
+// and validates it.
+func Load(args []string) (Config, error) {
+	cfg := Default()
+
+	fs := flag.NewFlagSet("api", flag.ContinueOnError)
+	path := fs.String("config", os.Getenv(fileEnv), "YAML or TOML config file (env "+fileEnv+")")
+	var flagged []func() error
+	for _, f := range fields(&cfg) {
+		if f.flag == "" {
+			continue
+		}
+		// Applied once the file and environment are loaded, so flags win over both
+		apply := func(s string) error {
+			flagged = append(flagged, func() error { return f.set(s, "-"+f.flag) })
+			return nil
+		}
+		if f.value.Kind() == reflect.Bool {
+			fs.BoolFunc(f.flag, f.usage+" (env "+f.env+")", apply)
+		} else {
+			fs.Func(f.flag, f.usage+" (env "+f.env+")", apply)
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return cfg, err
+	}
+
+	if *path != "" {
+		if err := loadFile(&cfg, *path); err != nil {
+			return cfg, err
+		}
+	}
+	for _, f := range fields(&cfg) {
+		v, ok := os.LookupEnv(f.env)
+		// A variable set but empty clears a text or list setting, like DOCS_HOST= or ADMIN_TOKEN=;
+		// numbers, switches and durations have no empty value and keep theirs
+		if !ok || (v == "" && f.value.Kind() != reflect.String && f.value.Kind() != reflect.Slice) {
+			continue
+		}
+		if err := f.set(v, f.env); err != nil {
+			return cfg, err
+		}
+	}
+	for _, apply := range flagged {
+		if err := apply(); err != nil {
+			return cfg, err
+		}
+	}
+	return cfg, cfg.Validate()
+}
+
+// loadFile decodes the YAML (.yaml, .yml) or TOML (.toml) file at path over cfg.
+// Unknown keys are errors, so typos do not go unnoticed.
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+		case ".yaml", ".yml":
+			dec := yaml.NewDecoder(bytes.NewReader(data))
+			dec.KnownFields(true)
+			if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+				return fmt.Errorf("invalid config file %s: %w", path, err)
+			}
+		case ".toml":
+			md, err := toml.Decode(string(data), cfg)
+			if err != nil {
+				return fmt.Errorf("invalid config file %s: %w", path, err)
+			}
+			if undecoded := md.Undecoded(); len(undecoded) > 0 {
+				return fmt.Errorf("invalid config file %s: unknown key %s", path, undecoded[0])
+			}
+		default:
+			return fmt.Errorf("invalid config file %s: supported: '.yaml', '.yml', '.toml'", path)
+	}
+	return nil
+}
+
+// field is a setting that can be overridden by an environment variable or flag.
+type field struct {
+	value reflect.Value
+	env   string
+	flag  string
+	usage string
+}
+
+// fields lists the settings of cfg carrying an env tag.
+func fields(cfg *Config) []field {
+	var out []field
+	sections := reflect.ValueOf(cfg).Elem()
+	for i := 0; i < sections.NumField(); i++ {
+		section := sections.Field(i)
+		for j := 0; j < section.NumField(); j++ {
+			tag := section.Type().Field(j).Tag
+			if tag.Get("env") == "" {
+				continue
+			}
+			out = append(out, field{
+				value: section.Field(j),
+				env:   tag.Get("env"),
+				flag:  tag.Get("flag"),
+				usage: tag.Get("usage"),
+			})
+		}
+	}
+	return out
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// set parses s into the field. Lists are comma separated, and maps are key=value pairs
+// merged into the field's current entries. source names the variable or flag for errors.
+func (f field) set(s string, source string) error {
+	v := f.value
+	switch {
+		case v.Type() == durationType:
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %q is not a duration like 30s or 5m", source, s)
+			}
+			v.SetInt(int64(d))
+		case v.Kind() == reflect.String:
+			v.SetString(s)
+		case v.Kind() == reflect.Int:
+			n, err := strconv.Atoi(s)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %q is not an integer", source, s)
+			}
+			v.SetInt(int64(n))
+		case v.Kind() == reflect.Bool:
+			b, err := strconv.ParseBool(s)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %q is not true or false", source, s)
+			}
+			v.SetBool(b)
+		case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+			var list []string
+			for _, item := range strings.Split(s, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					list = append(list, item)
+				}
+			}
+			v.Set(reflect.ValueOf(list))
+		case v.Kind() == reflect.Map && v.Type().Elem() == durationType:
+			if v.IsNil() {
+				v.Set(reflect.MakeMap(v.Type()))
+			}
+			for _, entry := range strings.Split(s, ",") {
+				entry = strings.TrimSpace(entry)
+				if entry == "" {
+					continue
+				}
+				key, value, ok := strings.Cut(entry, "=")
+				d, err := time.ParseDuration(value)
+				if !ok || err != nil {
+					return fmt.Errorf("invalid %s: %q is not key=duration", source, entry)
+				}
+				v.SetMapIndex(reflect.ValueOf(key), reflect.ValueOf(d))
+			}
+		default:
+			return fmt.Errorf("%s cannot be set from text", source)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadEnvironment(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	file := "database:\n  url: postgres://localhost/astrocartics\nrate_limit:\n  admin_token: from-file\n  trusted_proxies: [10.0.0.0/8]\nquery:\n  timeout: 5s\n"
+	if err := os.WriteFile(path, []byte(file), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(fileEnv, path)
+	t.Setenv("DATABASE_URL", "postgres://db/astrocartics")
+	t.Setenv("DOCS_HOST", "")
+	t.Setenv("ADMIN_TOKEN", "")
+	t.Setenv("TRUSTED_PROXIES", "")
+	t.Setenv("QUERY_TIMEOUT", "")
+	t.Setenv("PORT", "9000")
+
+	cfg, err := Load([]string{"-port", "9001"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Docs.Host != "" || cfg.RateLimit.AdminToken != "" || len(cfg.RateLimit.TrustedProxies) != 0 {
+		t.Errorf("empty variables did not clear their settings: docs.host=%q admin_token=%q trusted_proxies=%v",
+			cfg.Docs.Host, cfg.RateLimit.AdminToken, cfg.RateLimit.TrustedProxies)
+	}
+	if cfg.Database.URL != "postgres://db/astrocartics" {
+		t.Errorf("database.url = %q, want the environment's", cfg.Database.URL)
+	}
+	// QUERY_TIMEOUT has no empty value, so it keeps the file's
+	if cfg.Query.Timeout != 5*time.Second {
+		t.Errorf("query.timeout = %s, want the file's 5s", cfg.Query.Timeout)
+	}
+	if cfg.Server.Port != 9001 {
+		t.Errorf("server.port = %d, want the flag's 9001", cfg.Server.Port)
+	}
+}
@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/astrocartics-xyz/Astrocartics-API/models"
+	"github.com/astrocartics-xyz/Astrocartics-API/service"
+	"github.com/go-chi/chi/v5"
+)
+
+// AdminToken guards the admin endpoints, which are disabled while it is empty.
+var AdminToken string
+
+// AdminAuth only lets requests carrying "Authorization: Bearer <AdminToken>" through.
+func AdminAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if AdminToken == "" {
+			respondError(w, http.StatusNotFound, "Not found")
+			return
+		}
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(AdminToken)) != 1 {
+			respondError(w, http.StatusUnauthorized, "Invalid admin token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// CreateAPIKeyHandler godoc
+// @Summary Issue an API key
+// @Description Issue an API key for a usage tier. The key is only returned by this call
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Param request body models.NewAPIKeyRequest true "Key name and tier (free, pro, internal)"
+// @Success 201 {object} models.NewAPIKey
+// @Security AdminToken
+// @Router /admin/keys [post]
+func CreateAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	var req models.NewAPIKeyRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid body")
+		return
+	}
+	key, err := service.CreateAPIKey(r.Context(), req.Name, req.Tier)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid request") {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		slog.ErrorContext(r.Context(), "Error creating API key", "err", err)
+		respondError(w, queryErrorStatus(r, err), "Failed to create API key")
+		return
+	}
+	respondJSON(w, http.StatusCreated, key)
+}
+
+// GetAPIKeysHandler godoc
+// @Summary List API keys
+// @Description List every issued API key, without the keys themselves
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Success 200 {array} models.APIKey
+// @Security AdminToken
+// @Router /admin/keys [get]
+func GetAPIKeysHandler(w http.ResponseWriter, r *http.Request) {
+	keys, err := service.GetAllAPIKeys(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error fetching API keys", "err", err)
+		respondError(w, queryErrorStatus(r, err), "Failed to retrieve API keys")
+		return
+	}
+	respondJSON(w, http.StatusOK, keys)
+}
+
+// RevokeAPIKeyHandler godoc
+// @Summary Revoke an API key
+// @Description Revoke an API key. Replicas may keep accepting it for up to a minute
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Param keyID path int true "API key ID"
+// @Success 204
+// @Security AdminToken
+// @Router /admin/keys/{keyID} [delete]
+func RevokeAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "keyID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid key ID")
+		return
+	}
+	revoked, err := service.RevokeAPIKey(r.Context(), id)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error revoking API key", "api_key_id", id, "err", err)
+		respondError(w, queryErrorStatus(r, err), "Failed to revoke API key")
+		return
+	}
+	if !revoked {
+		respondError(w, http.StatusNotFound, "API key not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
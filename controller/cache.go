@@ -0,0 +1,155 @@
+package controller
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/astrocartics-xyz/Astrocartics-API/service"
+)
+
+// StaticMaxAge is how long clients and CDNs may reuse static data responses.
+var StaticMaxAge = 24 * time.Hour
+
+// killRefreshPeriods sets how often kill responses of each mode (or window) expire.
+// Expiry is aligned to multiples of the period, so every cache refetches at the same moment.
+var killRefreshPeriods = map[string]time.Duration{
+	"hour":  time.Minute,
+	"day":   5 * time.Minute,
+	"week":  15 * time.Minute,
+	"month": 15 * time.Minute,
+}
+
+// defaultKillRefreshPeriod applies to kill endpoints without a mode.
+const defaultKillRefreshPeriod = time.Minute
+
+// bufferedResponse holds a response back so its ETag can be computed before anything is sent.
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header { return b.header }
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	return b.body.Write(p)
+}
+
+// etagMatches reports whether an If-None-Match header matches the ETag.
+func etagMatches(header string, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// conditional serves GET and HEAD requests through a buffer, adding a strong ETag, the
+// Cache-Control value and Last-Modified (unless zero) from policy to successful responses,
+// and answering 304 Not Modified when the request's validators match.
+func conditional(policy func(r *http.Request) (cacheControl string, lastModified time.Time)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+			buf := &bufferedResponse{header: w.Header()}
+			next.ServeHTTP(buf, r)
+			if buf.status == 0 {
+				buf.status = http.StatusOK
+			}
+			if buf.status != http.StatusOK {
+				w.WriteHeader(buf.status)
+				w.Write(buf.body.Bytes())
+				return
+			}
+			sum := sha256.Sum256(buf.body.Bytes())
+			etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+			cacheControl, lastModified := policy(r)
+			h := w.Header()
+			// Responses to API keys are charged to, and may be gated by, the key, so shared caches
+			// must not reuse them; shared anonymous responses drop the caller's rate limit state
+			h.Add("Vary", apiKeyHeader)
+			if r.Header.Get(apiKeyHeader) != "" {
+				cacheControl = "private" + strings.TrimPrefix(cacheControl, "public")
+			} else if strings.HasPrefix(cacheControl, "public") {
+				h.Del("X-RateLimit-Limit")
+				h.Del("X-RateLimit-Remaining")
+				h.Del("X-RateLimit-Reset")
+			}
+			h.Set("ETag", etag)
+			h.Set("Cache-Control", cacheControl)
+			if !lastModified.IsZero() {
+				h.Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+			}
+			// If-None-Match takes precedence over If-Modified-Since
+			notModified := false
+			if inm := r.Header.Get("If-None-Match"); inm != "" {
+				notModified = etagMatches(inm, etag)
+			} else if ims := r.Header.Get("If-Modified-Since"); ims != "" && !lastModified.IsZero() {
+				if t, err := http.ParseTime(ims); err == nil {
+					notModified = !lastModified.Truncate(time.Second).After(t)
+				}
+			}
+			if notModified {
+				h.Del("Content-Type")
+				h.Del("Content-Length")
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			h.Set("Content-Length", strconv.Itoa(buf.body.Len()))
+			w.WriteHeader(http.StatusOK)
+			if r.Method != http.MethodHead {
+				w.Write(buf.body.Bytes())
+			}
+		})
+	}
+}
+
+// StaticCache caches static data responses for StaticMaxAge, validated by the time of the last static data import.
+func StaticCache(next http.Handler) http.Handler {
+	return conditional(func(r *http.Request) (string, time.Time) {
+		return "public, max-age=" + strconv.Itoa(int(StaticMaxAge.Seconds())), service.GetStaticDataUpdatedAt(r.Context())
+	})(next)
+}
+
+// KillCache caches kill responses until the next refresh boundary of their mode or window.
+func KillCache(next http.Handler) http.Handler {
+	return conditional(func(r *http.Request) (string, time.Time) {
+		mode := r.URL.Query().Get("mode")
+		if mode == "" {
+			mode = r.URL.Query().Get("window")
+		}
+		period, ok := killRefreshPeriods[mode]
+		if !ok {
+			period = defaultKillRefreshPeriod
+		}
+		return "public, max-age=" + strconv.Itoa(int(windowMaxAge(time.Now(), period).Seconds())), time.Time{}
+	})(next)
+}
+
+// windowMaxAge returns the time left until the next multiple of period, at least one second.
+func windowMaxAge(now time.Time, period time.Duration) time.Duration {
+	left := period - time.Duration(now.UnixNano())%period
+	if left < time.Second {
+		left = time.Second
+	}
+	return left.Truncate(time.Second)
+}
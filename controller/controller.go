@@ -3,10 +3,11 @@ package controller
 import (
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/astrocartics-xyz/Astrocartics-API/service"
 	"github.com/astrocartics-xyz/Astrocartics-API/models"
@@ -37,15 +38,19 @@ func respondError(w http.ResponseWriter, status int, message string) {
 // @Accept  json
 // @Produce  json
 // @Param name query string false "Region name to search for"
+// @Param limit query int false "Page size (1-1000); returns a models.Page envelope instead of an array"
+// @Param cursor query string false "Cursor from the next link of the previous page"
+// @Param sort query string false "Field to sort by, prefixed with - for descending"
+// @Param fields query string false "Comma-separated fields to return"
 // @Success 200 {array} models.Region
 // @Router /regions [get]
 func GetRegionsHandler(w http.ResponseWriter, r *http.Request) {
 	name := r.URL.Query().Get("name")
 	if name != "" {
-		region, err := service.GetRegionByName(name)
+		region, err := service.GetRegionByName(r.Context(), name)
 		if err != nil {
-			log.Printf("Error fetching region by name '%s': %v", name, err)
-			respondError(w, http.StatusInternalServerError, "Failed to retrieve region")
+			slog.ErrorContext(r.Context(), "Error fetching region", "name", name, "err", err)
+			respondError(w, queryErrorStatus(r, err), "Failed to retrieve region")
 			return
 		}
 		if region == nil {
@@ -56,13 +61,13 @@ func GetRegionsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	regions, err := service.GetAllRegions()
+	regions, err := service.GetAllRegions(r.Context())
 	if err != nil {
-		log.Printf("Error fetching regions: %v", err)
-		respondError(w, http.StatusInternalServerError, "Failed to retrieve regions")
+		slog.ErrorContext(r.Context(), "Error fetching regions", "err", err)
+		respondError(w, queryErrorStatus(r, err), "Failed to retrieve regions")
 		return
 	}
-	respondJSON(w, http.StatusOK, regions)
+	respondList(w, r, regions)
 }
 
 // GetRegionByIDHandler godoc
@@ -82,10 +87,10 @@ func GetRegionByIDHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	region, err := service.GetRegionByID(id)
+	region, err := service.GetRegionByID(r.Context(), id)
 	if err != nil {
-		log.Printf("Error fetching region %d: %v", id, err)
-		respondError(w, http.StatusInternalServerError, "Failed to retrieve region")
+		slog.ErrorContext(r.Context(), "Error fetching region", "region_id", id, "err", err)
+		respondError(w, queryErrorStatus(r, err), "Failed to retrieve region")
 		return
 	}
 	if region == nil {
@@ -102,15 +107,19 @@ func GetRegionByIDHandler(w http.ResponseWriter, r *http.Request) {
 // @Accept  json
 // @Produce  json
 // @Param name query string false "Constellation name to search for"
+// @Param limit query int false "Page size (1-1000); returns a models.Page envelope instead of an array"
+// @Param cursor query string false "Cursor from the next link of the previous page"
+// @Param sort query string false "Field to sort by, prefixed with - for descending"
+// @Param fields query string false "Comma-separated fields to return"
 // @Success 200 {array} models.Constellation
 // @Router /constellations [get]
 func GetConstellationsHandler(w http.ResponseWriter, r *http.Request) {
 	name := r.URL.Query().Get("name")
 	if name != "" {
-		constellation, err := service.GetConstellationByName(name)
+		constellation, err := service.GetConstellationByName(r.Context(), name)
 		if err != nil {
-			log.Printf("Error fetching constellation by name '%s': %v", name, err)
-			respondError(w, http.StatusInternalServerError, "Failed to retrieve constellation")
+			slog.ErrorContext(r.Context(), "Error fetching constellation", "name", name, "err", err)
+			respondError(w, queryErrorStatus(r, err), "Failed to retrieve constellation")
 			return
 		}
 		if constellation == nil {
@@ -121,13 +130,13 @@ func GetConstellationsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	constellations, err := service.GetAllConstellations()
+	constellations, err := service.GetAllConstellations(r.Context())
 	if err != nil {
-		log.Printf("Error fetching constellations: %v", err)
-		respondError(w, http.StatusInternalServerError, "Failed to retrieve constellations")
+		slog.ErrorContext(r.Context(), "Error fetching constellations", "err", err)
+		respondError(w, queryErrorStatus(r, err), "Failed to retrieve constellations")
 		return
 	}
-	respondJSON(w, http.StatusOK, constellations)
+	respondList(w, r, constellations)
 }
 
 // GetConstellationByIDHandler godoc
@@ -147,10 +156,10 @@ func GetConstellationByIDHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	constellation, err := service.GetConstellationByIDOrRegionID(id)
+	constellation, err := service.GetConstellationByIDOrRegionID(r.Context(), id)
 	if err != nil {
-		log.Printf("Error fetching constellation %d: %v", id, err)
-		respondError(w, http.StatusInternalServerError, "Failed to retrieve constellation")
+		slog.ErrorContext(r.Context(), "Error fetching constellation", "constellation_id", id, "err", err)
+		respondError(w, queryErrorStatus(r, err), "Failed to retrieve constellation")
 		return
 	}
 	if constellation == nil {
@@ -167,6 +176,10 @@ func GetConstellationByIDHandler(w http.ResponseWriter, r *http.Request) {
 // @Accept  json
 // @Produce  json
 // @Param regionID path int true "Region ID"
+// @Param limit query int false "Page size (1-1000); returns a models.Page envelope instead of an array"
+// @Param cursor query string false "Cursor from the next link of the previous page"
+// @Param sort query string false "Field to sort by, prefixed with - for descending"
+// @Param fields query string false "Comma-separated fields to return"
 // @Success 200 {array} models.Constellation
 // @Router /regions/{regionID}/constellations [get]
 func GetConstellationsByRegionIDHandler(w http.ResponseWriter, r *http.Request) {
@@ -177,35 +190,48 @@ func GetConstellationsByRegionIDHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	constellations, err := service.GetConstellationByIDOrRegionID(id)
+	constellations, err := service.GetConstellationByIDOrRegionID(r.Context(), id)
 	if err != nil {
-		log.Printf("Error fetching constellations for region %d: %v", id, err)
-		respondError(w, http.StatusInternalServerError, "Failed to retrieve constellations")
+		slog.ErrorContext(r.Context(), "Error fetching constellations for region", "region_id", id, "err", err)
+		respondError(w, queryErrorStatus(r, err), "Failed to retrieve constellations")
 		return
 	}
 	if constellations == nil {
 		respondError(w, http.StatusNotFound, "No constellations found for this region")
 		return
 	}
-	respondJSON(w, http.StatusOK, constellations)
+	respondList(w, r, constellations)
 }
 
 // GetSystemsHandler godoc
 // @Summary Get systems
-// @Description Get all systems, or search for a system by name
+// @Description Get all systems, search for a system by name, or list the systems matching a combination of filters
 // @Tags systems
 // @Accept  json
 // @Produce  json
 // @Param name query string false "System name to search for"
+// @Param min_security query number false "Minimum security status"
+// @Param max_security query number false "Maximum security status"
+// @Param security_class query string false "Comma-separated security classes"
+// @Param spectral_class query string false "Comma-separated spectral classes"
+// @Param region_id query int false "Only systems in this region"
+// @Param constellation_id query int false "Only systems in this constellation"
+// @Param has_station query bool false "Only systems with (true) or without (false) NPC stations"
+// @Param min_planets query int false "Minimum number of planets"
+// @Param bbox query string false "Bounding box minX,minY,minZ,maxX,maxY,maxZ in meters"
+// @Param limit query int false "Page size (1-1000); returns a models.Page envelope instead of an array"
+// @Param cursor query string false "Cursor from the next link of the previous page"
+// @Param sort query string false "Field to sort by, prefixed with - for descending"
+// @Param fields query string false "Comma-separated fields to return"
 // @Success 200 {array} models.System
 // @Router /systems [get]
 func GetSystemsHandler(w http.ResponseWriter, r *http.Request) {
 	name := r.URL.Query().Get("name")
 	if name != "" {
-		system, err := service.GetSystemByName(name)
+		system, err := service.GetSystemByName(r.Context(), name)
 		if err != nil {
-			log.Printf("Error fetching system by name '%s': %v", name, err)
-			respondError(w, http.StatusInternalServerError, "Failed to retrieve system")
+			slog.ErrorContext(r.Context(), "Error fetching system", "name", name, "err", err)
+			respondError(w, queryErrorStatus(r, err), "Failed to retrieve system")
 			return
 		}
 		if system == nil {
@@ -216,13 +242,104 @@ func GetSystemsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	systems, err := service.GetAllSystems()
+	filter, err := parseSystemFilter(r)
 	if err != nil {
-		log.Printf("Error fetching systems: %v", err)
-		respondError(w, http.StatusInternalServerError, "Failed to retrieve systems")
+		respondError(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	respondJSON(w, http.StatusOK, systems)
+	p, page, err := parsePageRequest[models.System](r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	systems, total, next, err := service.GetSystems(r.Context(), filter, page)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid filter") {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		slog.ErrorContext(r.Context(), "Error fetching systems", "err", err)
+		respondError(w, queryErrorStatus(r, err), "Failed to retrieve systems")
+		return
+	}
+	respondPage(w, r, p, systems, total, next)
+}
+
+// parseSystemFilter reads the systems filters from the query.
+func parseSystemFilter(r *http.Request) (models.SystemFilter, error) {
+	q := r.URL.Query()
+	var filter models.SystemFilter
+	parseFloat := func(name string) (*float64, error) {
+		v := q.Get(name)
+		if v == "" {
+			return nil, nil
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s. Must be a number", name)
+		}
+		return &f, nil
+	}
+	parseInt := func(name string) (*int, error) {
+		v := q.Get(name)
+		if v == "" {
+			return nil, nil
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s. Must be an integer", name)
+		}
+		return &n, nil
+	}
+	parseList := func(name string) []string {
+		var values []string
+		for _, v := range strings.Split(q.Get(name), ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+		return values
+	}
+	var err error
+	if filter.MinSecurity, err = parseFloat("min_security"); err != nil {
+		return filter, err
+	}
+	if filter.MaxSecurity, err = parseFloat("max_security"); err != nil {
+		return filter, err
+	}
+	filter.SecurityClasses = parseList("security_class")
+	filter.SpectralClasses = parseList("spectral_class")
+	if filter.RegionID, err = parseInt("region_id"); err != nil {
+		return filter, err
+	}
+	if filter.ConstellationID, err = parseInt("constellation_id"); err != nil {
+		return filter, err
+	}
+	if filter.MinPlanets, err = parseInt("min_planets"); err != nil {
+		return filter, err
+	}
+	if v := q.Get("has_station"); v != "" {
+		hasStation, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid has_station. Must be true or false")
+		}
+		filter.HasStation = &hasStation
+	}
+	if v := q.Get("bbox"); v != "" {
+		parts := strings.Split(v, ",")
+		if len(parts) != 6 {
+			return filter, fmt.Errorf("invalid bbox. Must be minX,minY,minZ,maxX,maxY,maxZ")
+		}
+		var coords [6]float64
+		for i, part := range parts {
+			coords[i], err = strconv.ParseFloat(strings.TrimSpace(part), 64)
+			if err != nil {
+				return filter, fmt.Errorf("invalid bbox. Must be minX,minY,minZ,maxX,maxY,maxZ")
+			}
+		}
+		filter.BoundingBox = &models.BoundingBox{MinX: coords[0], MinY: coords[1], MinZ: coords[2], MaxX: coords[3], MaxY: coords[4], MaxZ: coords[5]}
+	}
+	return filter, nil
 }
 
 // GetSystemByIDHandler godoc
@@ -242,10 +359,10 @@ func GetSystemByIDHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	system, err := service.GetSystemByIDOrConstellationID(id)
+	system, err := service.GetSystemByIDOrConstellationID(r.Context(), id)
 	if err != nil {
-		log.Printf("Error fetching system %d: %v", id, err)
-		respondError(w, http.StatusInternalServerError, "Failed to retrieve system")
+		slog.ErrorContext(r.Context(), "Error fetching system", "system_id", id, "err", err)
+		respondError(w, queryErrorStatus(r, err), "Failed to retrieve system")
 		return
 	}
 	if system == nil {
@@ -262,6 +379,10 @@ func GetSystemByIDHandler(w http.ResponseWriter, r *http.Request) {
 // @Accept  json
 // @Produce  json
 // @Param regionID path int true "Region ID"
+// @Param limit query int false "Page size (1-1000); returns a models.Page envelope instead of an array"
+// @Param cursor query string false "Cursor from the next link of the previous page"
+// @Param sort query string false "Field to sort by, prefixed with - for descending"
+// @Param fields query string false "Comma-separated fields to return"
 // @Success 200 {array} models.System
 // @Router /regions/{regionID}/systems [get]
 func GetSystemsByRegionIDHandler(w http.ResponseWriter, r *http.Request) {
@@ -272,17 +393,17 @@ func GetSystemsByRegionIDHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	systems, err := service.GetSystemsByRegionID(id)
+	systems, err := service.GetSystemsByRegionID(r.Context(), id)
 	if err != nil {
-		log.Printf("Error fetching systems for region %d: %v", id, err)
-		respondError(w, http.StatusInternalServerError, "Failed to retrieve systems")
+		slog.ErrorContext(r.Context(), "Error fetching systems for region", "region_id", id, "err", err)
+		respondError(w, queryErrorStatus(r, err), "Failed to retrieve systems")
 		return
 	}
 	if systems == nil {
 		respondError(w, http.StatusNotFound, "No systems found for this region")
 		return
 	}
-	respondJSON(w, http.StatusOK, systems)
+	respondList(w, r, systems)
 }
 
 // GetSystemsByConstellationIDHandler godoc
@@ -292,6 +413,10 @@ func GetSystemsByRegionIDHandler(w http.ResponseWriter, r *http.Request) {
 // @Accept  json
 // @Produce  json
 // @Param constellationID path int true "Constellation ID"
+// @Param limit query int false "Page size (1-1000); returns a models.Page envelope instead of an array"
+// @Param cursor query string false "Cursor from the next link of the previous page"
+// @Param sort query string false "Field to sort by, prefixed with - for descending"
+// @Param fields query string false "Comma-separated fields to return"
 // @Success 200 {array} models.System
 // @Router /constellations/{constellationID}/systems [get]
 func GetSystemsByConstellationIDHandler(w http.ResponseWriter, r *http.Request) {
@@ -302,17 +427,17 @@ func GetSystemsByConstellationIDHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	systems, err := service.GetSystemByIDOrConstellationID(id)
+	systems, err := service.GetSystemByIDOrConstellationID(r.Context(), id)
 	if err != nil {
-		log.Printf("Error fetching systems for constellation %d: %v", id, err)
-		respondError(w, http.StatusInternalServerError, "Failed to retrieve systems")
+		slog.ErrorContext(r.Context(), "Error fetching systems for constellation", "constellation_id", id, "err", err)
+		respondError(w, queryErrorStatus(r, err), "Failed to retrieve systems")
 		return
 	}
 	if systems == nil {
 		respondError(w, http.StatusNotFound, "No systems found for this constellation")
 		return
 	}
-	respondJSON(w, http.StatusOK, systems)
+	respondList(w, r, systems)
 }
 
 // GetStargatesHandler godoc
@@ -321,16 +446,25 @@ func GetSystemsByConstellationIDHandler(w http.ResponseWriter, r *http.Request)
 // @Tags stargates
 // @Accept  json
 // @Produce  json
+// @Param limit query int false "Page size (1-1000); returns a models.Page envelope instead of an array"
+// @Param cursor query string false "Cursor from the next link of the previous page"
+// @Param sort query string false "Field to sort by, prefixed with - for descending"
+// @Param fields query string false "Comma-separated fields to return"
 // @Success 200 {array} models.Stargate
 // @Router /stargates [get]
 func GetStargatesHandler(w http.ResponseWriter, r *http.Request) {
-	stargates, err := service.GetAllStargates()
+	p, page, err := parsePageRequest[models.Stargate](r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	stargates, total, next, err := service.GetStargates(r.Context(), page)
 	if err != nil {
-		log.Printf("Error fetching stargates: %v", err)
-		respondError(w, http.StatusInternalServerError, "Failed to retrieve stargates")
+		slog.ErrorContext(r.Context(), "Error fetching stargates", "err", err)
+		respondError(w, queryErrorStatus(r, err), "Failed to retrieve stargates")
 		return
 	}
-	respondJSON(w, http.StatusOK, stargates)
+	respondPage(w, r, p, stargates, total, next)
 }
 
 // GetStargateBySystemIDHandler godoc
@@ -340,6 +474,10 @@ func GetStargatesHandler(w http.ResponseWriter, r *http.Request) {
 // @Accept  json
 // @Produce  json
 // @Param systemID path int true "System ID"
+// @Param limit query int false "Page size (1-1000); returns a models.Page envelope instead of an array"
+// @Param cursor query string false "Cursor from the next link of the previous page"
+// @Param sort query string false "Field to sort by, prefixed with - for descending"
+// @Param fields query string false "Comma-separated fields to return"
 // @Success 200 {array} models.Stargate
 // @Router /systems/{systemID}/stargates [get]
 func GetStargateBySystemIDHandler(w http.ResponseWriter, r *http.Request) {
@@ -350,17 +488,17 @@ func GetStargateBySystemIDHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	stargates, err := service.GetStargateBySystemID(id)
+	stargates, err := service.GetStargateBySystemID(r.Context(), id)
 	if err != nil {
-		log.Printf("Error fetching stargates for system %d: %v", id, err)
-		respondError(w, http.StatusInternalServerError, "Failed to retrieve stargates")
+		slog.ErrorContext(r.Context(), "Error fetching stargates for system", "system_id", id, "err", err)
+		respondError(w, queryErrorStatus(r, err), "Failed to retrieve stargates")
 		return
 	}
 	if len(stargates) == 0 {
-		respondJSON(w, http.StatusOK, []models.Stargate{})
+		respondList(w, r, []models.Stargate{})
 		return
 	}
-	respondJSON(w, http.StatusOK, stargates)
+	respondList(w, r, stargates)
 }
 
 // GetStargateByConstellationIDHandler godoc
@@ -370,6 +508,10 @@ func GetStargateBySystemIDHandler(w http.ResponseWriter, r *http.Request) {
 // @Accept  json
 // @Produce  json
 // @Param constellationID path int true "Constellation ID"
+// @Param limit query int false "Page size (1-1000); returns a models.Page envelope instead of an array"
+// @Param cursor query string false "Cursor from the next link of the previous page"
+// @Param sort query string false "Field to sort by, prefixed with - for descending"
+// @Param fields query string false "Comma-separated fields to return"
 // @Success 200 {array} models.Stargate
 // @Router /constellations/{constellationID}/stargates [get]
 func GetStargateByConstellationIDHandler(w http.ResponseWriter, r *http.Request) {
@@ -380,17 +522,17 @@ func GetStargateByConstellationIDHandler(w http.ResponseWriter, r *http.Request)
                 return
         }
 
-        stargates, err := service.GetStargateByConstellationID(id)
+        stargates, err := service.GetStargateByConstellationID(r.Context(), id)
         if err != nil {
-                log.Printf("Error fetching stargates for constellation %d: %v", id, err)
-                respondError(w, http.StatusInternalServerError, "Failed to retrieve stargates")
+                slog.ErrorContext(r.Context(), "Error fetching stargates for constellation", "constellation_id", id, "err", err)
+                respondError(w, queryErrorStatus(r, err), "Failed to retrieve stargates")
                 return
         }
         if len(stargates) == 0 {
-                respondJSON(w, http.StatusOK, []models.Stargate{})
+                respondList(w, r, []models.Stargate{})
                 return
         }
-        respondJSON(w, http.StatusOK, stargates)
+        respondList(w, r, stargates)
 }
 
 // GetStargateByRegionIDHandler godoc
@@ -400,6 +542,10 @@ func GetStargateByConstellationIDHandler(w http.ResponseWriter, r *http.Request)
 // @Accept  json
 // @Produce  json
 // @Param regionID path int true "Region ID"
+// @Param limit query int false "Page size (1-1000); returns a models.Page envelope instead of an array"
+// @Param cursor query string false "Cursor from the next link of the previous page"
+// @Param sort query string false "Field to sort by, prefixed with - for descending"
+// @Param fields query string false "Comma-separated fields to return"
 // @Success 200 {array} models.Stargate
 // @Router /regions/{regionID}/stargates [get]
 func GetStargateByRegionIDHandler(w http.ResponseWriter, r *http.Request) {
@@ -410,17 +556,17 @@ func GetStargateByRegionIDHandler(w http.ResponseWriter, r *http.Request) {
                 return
         }
 
-        stargates, err := service.GetStargateByRegionID(id)
+        stargates, err := service.GetStargateByRegionID(r.Context(), id)
         if err != nil {
-                log.Printf("Error fetching stargates for region %d: %v", id, err)
-                respondError(w, http.StatusInternalServerError, "Failed to retrieve stargates")
+                slog.ErrorContext(r.Context(), "Error fetching stargates for region", "region_id", id, "err", err)
+                respondError(w, queryErrorStatus(r, err), "Failed to retrieve stargates")
                 return
         }
         if len(stargates) == 0 {
-                respondJSON(w, http.StatusOK, []models.Stargate{})
+                respondList(w, r, []models.Stargate{})
                 return
         }
-        respondJSON(w, http.StatusOK, stargates)
+        respondList(w, r, stargates)
 }
 
 // GetSpectralClassCountsHandler godoc
@@ -432,10 +578,10 @@ func GetStargateByRegionIDHandler(w http.ResponseWriter, r *http.Request) {
 // @Success 200 {array} models.SpectralClassCount
 // @Router /reports/spectral-class-counts [get]
 func GetSpectralClassCountsHandler(w http.ResponseWriter, r *http.Request) {
-	counts, err := service.GetSpectralClassCounts()
+	counts, err := service.GetSpectralClassCounts(r.Context())
 	if err != nil {
-		log.Printf("Error fetching spectral class counts: %v", err)
-		respondError(w, http.StatusInternalServerError, "Failed to retrieve spectral class counts")
+		slog.ErrorContext(r.Context(), "Error fetching spectral class counts", "err", err)
+		respondError(w, queryErrorStatus(r, err), "Failed to retrieve spectral class counts")
 		return
 	}
 	respondJSON(w, http.StatusOK, counts)
@@ -448,15 +594,19 @@ func GetSpectralClassCountsHandler(w http.ResponseWriter, r *http.Request) {
 // @Accept  json
 // @Produce  json
 // @Param name query string false "Planet name to search for"
+// @Param limit query int false "Page size (1-1000); returns a models.Page envelope instead of an array"
+// @Param cursor query string false "Cursor from the next link of the previous page"
+// @Param sort query string false "Field to sort by, prefixed with - for descending"
+// @Param fields query string false "Comma-separated fields to return"
 // @Success 200 {array} models.Planet
 // @Router /planets [get]
 func GetPlanetsHandler(w http.ResponseWriter, r *http.Request) {
 	name := r.URL.Query().Get("name")
 	if name != "" {
-		planet, err := service.GetPlanetByName(name)
+		planet, err := service.GetPlanetByName(r.Context(), name)
 		if err != nil {
-			log.Printf("Error fetching planet by name '%s': %v", name, err)
-			respondError(w, http.StatusInternalServerError, "Failed to retrieve planet")
+			slog.ErrorContext(r.Context(), "Error fetching planet", "name", name, "err", err)
+			respondError(w, queryErrorStatus(r, err), "Failed to retrieve planet")
 			return
 		}
 		if planet == nil {
@@ -467,13 +617,18 @@ func GetPlanetsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	planets, err := service.GetAllPlanets()
+	p, page, err := parsePageRequest[models.Planet](r)
 	if err != nil {
-		log.Printf("Error fetching planets: %v", err)
-		respondError(w, http.StatusInternalServerError, "Failed to retrieve planets")
+		respondError(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	respondJSON(w, http.StatusOK, planets)
+	planets, total, next, err := service.GetPlanets(r.Context(), page)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error fetching planets", "err", err)
+		respondError(w, queryErrorStatus(r, err), "Failed to retrieve planets")
+		return
+	}
+	respondPage(w, r, p, planets, total, next)
 }
 
 // GetPlanetByIDHandler godoc
@@ -493,10 +648,10 @@ func GetPlanetByIDHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	planet, err := service.GetPlanetByID(id)
+	planet, err := service.GetPlanetByID(r.Context(), id)
 	if err != nil {
-		log.Printf("Error fetching planet %d: %v", id, err)
-		respondError(w, http.StatusInternalServerError, "Failed to retrieve planet")
+		slog.ErrorContext(r.Context(), "Error fetching planet", "planet_id", id, "err", err)
+		respondError(w, queryErrorStatus(r, err), "Failed to retrieve planet")
 		return
 	}
 	if planet == nil {
@@ -513,6 +668,10 @@ func GetPlanetByIDHandler(w http.ResponseWriter, r *http.Request) {
 // @Accept  json
 // @Produce  json
 // @Param systemID path int true "System ID"
+// @Param limit query int false "Page size (1-1000); returns a models.Page envelope instead of an array"
+// @Param cursor query string false "Cursor from the next link of the previous page"
+// @Param sort query string false "Field to sort by, prefixed with - for descending"
+// @Param fields query string false "Comma-separated fields to return"
 // @Success 200 {array} models.Planet
 // @Router /systems/{systemID}/planets [get]
 func GetPlanetsBySystemIDHandler(w http.ResponseWriter, r *http.Request) {
@@ -523,17 +682,17 @@ func GetPlanetsBySystemIDHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	planets, err := service.GetPlanetsBySystemID(id)
+	planets, err := service.GetPlanetsBySystemID(r.Context(), id)
 	if err != nil {
-		log.Printf("Error fetching planets for system %d: %v", id, err)
-		respondError(w, http.StatusInternalServerError, "Failed to retrieve planets")
+		slog.ErrorContext(r.Context(), "Error fetching planets for system", "system_id", id, "err", err)
+		respondError(w, queryErrorStatus(r, err), "Failed to retrieve planets")
 		return
 	}
 	if planets == nil {
 		respondError(w, http.StatusNotFound, "No planets found for this system")
 		return
 	}
-	respondJSON(w, http.StatusOK, planets)
+	respondList(w, r, planets)
 }
 
 // GetStationsHandler godoc
@@ -543,15 +702,19 @@ func GetPlanetsBySystemIDHandler(w http.ResponseWriter, r *http.Request) {
 // @Accept  json
 // @Produce  json
 // @Param name query string false "Station name to search for"
+// @Param limit query int false "Page size (1-1000); returns a models.Page envelope instead of an array"
+// @Param cursor query string false "Cursor from the next link of the previous page"
+// @Param sort query string false "Field to sort by, prefixed with - for descending"
+// @Param fields query string false "Comma-separated fields to return"
 // @Success 200 {array} models.Station
 // @Router /stations [get]
 func GetStationsHandler(w http.ResponseWriter, r *http.Request) {
 	name := r.URL.Query().Get("name")
 	if name != "" {
-		station, err := service.GetStationByName(name)
+		station, err := service.GetStationByName(r.Context(), name)
 		if err != nil {
-			log.Printf("Error fetching station by name '%s': %v", name, err)
-			respondError(w, http.StatusInternalServerError, "Failed to retrieve station")
+			slog.ErrorContext(r.Context(), "Error fetching station", "name", name, "err", err)
+			respondError(w, queryErrorStatus(r, err), "Failed to retrieve station")
 			return
 		}
 		if station == nil {
@@ -562,13 +725,18 @@ func GetStationsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	stations, err := service.GetAllStations()
+	p, page, err := parsePageRequest[models.Station](r)
 	if err != nil {
-		log.Printf("Error fetching stations: %v", err)
-		respondError(w, http.StatusInternalServerError, "Failed to retrieve stations")
+		respondError(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	respondJSON(w, http.StatusOK, stations)
+	stations, total, next, err := service.GetStations(r.Context(), page)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error fetching stations", "err", err)
+		respondError(w, queryErrorStatus(r, err), "Failed to retrieve stations")
+		return
+	}
+	respondPage(w, r, p, stations, total, next)
 }
 
 // GetStationByIDHandler godoc
@@ -588,10 +756,10 @@ func GetStationByIDHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	station, err := service.GetStationByID(id)
+	station, err := service.GetStationByID(r.Context(), id)
 	if err != nil {
-		log.Printf("Error fetching station %d: %v", id, err)
-		respondError(w, http.StatusInternalServerError, "Failed to retrieve station")
+		slog.ErrorContext(r.Context(), "Error fetching station", "station_id", id, "err", err)
+		respondError(w, queryErrorStatus(r, err), "Failed to retrieve station")
 		return
 	}
 	if station == nil {
@@ -608,6 +776,10 @@ func GetStationByIDHandler(w http.ResponseWriter, r *http.Request) {
 // @Accept  json
 // @Produce  json
 // @Param systemID path int true "System ID"
+// @Param limit query int false "Page size (1-1000); returns a models.Page envelope instead of an array"
+// @Param cursor query string false "Cursor from the next link of the previous page"
+// @Param sort query string false "Field to sort by, prefixed with - for descending"
+// @Param fields query string false "Comma-separated fields to return"
 // @Success 200 {array} models.Station
 // @Router /systems/{systemID}/stations [get]
 func GetStationsBySystemIDHandler(w http.ResponseWriter, r *http.Request) {
@@ -618,17 +790,17 @@ func GetStationsBySystemIDHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	stations, err := service.GetStationsBySystemID(id)
+	stations, err := service.GetStationsBySystemID(r.Context(), id)
 	if err != nil {
-		log.Printf("Error fetching stations for system %d: %v", id, err)
-		respondError(w, http.StatusInternalServerError, "Failed to retrieve stations")
+		slog.ErrorContext(r.Context(), "Error fetching stations for system", "system_id", id, "err", err)
+		respondError(w, queryErrorStatus(r, err), "Failed to retrieve stations")
 		return
 	}
 	if stations == nil {
 		respondError(w, http.StatusNotFound, "No stations found for this system")
 		return
 	}
-	respondJSON(w, http.StatusOK, stations)
+	respondList(w, r, stations)
 }
 
 // GetSystemHeatmapByRegionHandler godoc
@@ -655,14 +827,15 @@ func GetSystemHeatmapByRegionHandler(w http.ResponseWriter, r *http.Request) {
 		mode = "hour"
 	}
 	// Call new service function that returns a full HeatmapReport (including window start/end).
-	report, err := service.GetSystemHeatmapReportByRegionMode(regionID, mode)
+	report, err := service.GetSystemHeatmapReportByRegionMode(r.Context(), regionID, mode)
 	if err != nil {
 		// If the error indicates an invalid mode, return 400
 		if strings.Contains(err.Error(), "invalid mode") {
 			http.Error(w, fmt.Sprintf("invalid mode: %v", err), http.StatusBadRequest)
 			return
 		}
-		http.Error(w, fmt.Sprintf("error fetching heatmap: %v", err), http.StatusInternalServerError)
+		slog.ErrorContext(r.Context(), "Error fetching heatmap", "region_id", regionID, "mode", mode, "err", err)
+		http.Error(w, fmt.Sprintf("error fetching heatmap: %v", err), queryErrorStatus(r, err))
 		return
 	}
 	respondJSON(w, http.StatusOK, report)
@@ -684,10 +857,10 @@ func GetRecentKillmailsBySystemIDHandler(w http.ResponseWriter, r *http.Request)
 		respondError(w, http.StatusBadRequest, "Invalid system ID")
 		return
 	}
-	kills, err := service.GetRecentKillmailsBySystemID(systemID)
+	kills, err := service.GetRecentKillmailsBySystemID(r.Context(), systemID)
 	if err != nil {
-		log.Printf("Error fetching recent killmails for system %d: %v", systemID, err)
-		respondError(w, http.StatusInternalServerError, "Failed to retrieve killmails")
+		slog.ErrorContext(r.Context(), "Error fetching recent killmails for system", "system_id", systemID, "err", err)
+		respondError(w, queryErrorStatus(r, err), "Failed to retrieve killmails")
 		return
 	}
 	if kills == nil {
@@ -696,6 +869,37 @@ func GetRecentKillmailsBySystemIDHandler(w http.ResponseWriter, r *http.Request)
 	respondJSON(w, http.StatusOK, kills)
 }
 
+// parseTimeRange reads the optional from and to query parameters, given as RFC 3339 timestamps or dates.
+// A missing parameter is returned as the zero time.
+func parseTimeRange(r *http.Request) (time.Time, time.Time, error) {
+	parse := func(name string) (time.Time, error) {
+		v := r.URL.Query().Get(name)
+		if v == "" {
+			return time.Time{}, nil
+		}
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			return t, nil
+		}
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid %s. Must be an RFC 3339 timestamp or a YYYY-MM-DD date", name)
+		}
+		return t, nil
+	}
+	from, err := parse("from")
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	to, err := parse("to")
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid range. from must be before to")
+	}
+	return from, to, nil
+}
+
 // GetKillsBySystemIDHandler godoc
 // @Summary Get kill count by system ID
 // @Description Get all kills for a specific system
@@ -704,6 +908,8 @@ func GetRecentKillmailsBySystemIDHandler(w http.ResponseWriter, r *http.Request)
 // @Produce  json
 // @Param systemID path int true "System ID"
 // @Param mode query string false "Mode for aggregating kills (hour, day, week, month)" Enums(hour,day,week,month)
+// @Param from query string false "Only count kills at or after this time (RFC 3339 or YYYY-MM-DD, UTC)"
+// @Param to query string false "Only count kills before this time (RFC 3339 or YYYY-MM-DD, UTC)"
 // @Success 200 {array} models.SystemKills
 // @Router /systems/{systemID}/kills/summary [get]
 func GetKillsBySystemIDHandler(w http.ResponseWriter, r *http.Request) {
@@ -719,10 +925,17 @@ func GetKillsBySystemIDHandler(w http.ResponseWriter, r *http.Request) {
 	if mode == "" {
 		mode = "day"
 	}
+	// Parse the optional time range
+	from, to, err := parseTimeRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	// Call the service layer
-	systemName, total, buckets, err := service.GetKillCountBySystemID(systemID, mode)
+	systemName, total, buckets, err := service.GetKillCountBySystemID(r.Context(), systemID, mode, from, to)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Error fetching kills: %v", err), http.StatusInternalServerError)
+		slog.ErrorContext(r.Context(), "Error fetching kills", "system_id", systemID, "mode", mode, "err", err)
+		http.Error(w, fmt.Sprintf("Error fetching kills: %v", err), queryErrorStatus(r, err))
 		return
 	}
 	// Create the response payload
@@ -747,6 +960,8 @@ func GetKillsBySystemIDHandler(w http.ResponseWriter, r *http.Request) {
 // @Produce  json
 // @Param constellationID path int true "Constellation ID"
 // @Param mode query string false "Mode for aggregating kills (hour, day, week, month)" Enums(hour,day,week,month)
+// @Param from query string false "Only count kills at or after this time (RFC 3339 or YYYY-MM-DD, UTC)"
+// @Param to query string false "Only count kills before this time (RFC 3339 or YYYY-MM-DD, UTC)"
 // @Success 200 {array} models.ConstellationKills
 // @Router /constellations/{constellationID}/kills/summary [get]
 func GetKillsByConstellationIDHandler(w http.ResponseWriter, r *http.Request) {
@@ -762,10 +977,17 @@ func GetKillsByConstellationIDHandler(w http.ResponseWriter, r *http.Request) {
 	if mode == "" {
 		mode = "day"
 	}
+	// Parse the optional time range
+	from, to, err := parseTimeRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	// Call the service layer
-	constellationName, total, buckets, err := service.GetKillCountByConstellationID(constellationID, mode)
+	constellationName, total, buckets, err := service.GetKillCountByConstellationID(r.Context(), constellationID, mode, from, to)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Error fetching kills: %v", err), http.StatusInternalServerError)
+		slog.ErrorContext(r.Context(), "Error fetching kills", "constellation_id", constellationID, "mode", mode, "err", err)
+		http.Error(w, fmt.Sprintf("Error fetching kills: %v", err), queryErrorStatus(r, err))
 		return
 	}
 	// Create the response payload
@@ -790,6 +1012,8 @@ func GetKillsByConstellationIDHandler(w http.ResponseWriter, r *http.Request) {
 // @Produce  json
 // @Param regionID path int true "Region ID"
 // @Param mode query string false "Mode for aggregating kills (hour, day, week, month)" Enums(hour,day,week,month)
+// @Param from query string false "Only count kills at or after this time (RFC 3339 or YYYY-MM-DD, UTC)"
+// @Param to query string false "Only count kills before this time (RFC 3339 or YYYY-MM-DD, UTC)"
 // @Success 200 {array} models.RegionKills
 // @Router /regions/{regionID}/kills/summary [get]
 func GetKillsByRegionIDHandler(w http.ResponseWriter, r *http.Request) {
@@ -805,10 +1029,17 @@ func GetKillsByRegionIDHandler(w http.ResponseWriter, r *http.Request) {
 	if mode == "" {
 		mode = "day"
 	}
+	// Parse the optional time range
+	from, to, err := parseTimeRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	// Call the service layer
-	regionName, total, buckets, err := service.GetKillCountByRegionID(regionID, mode)
+	regionName, total, buckets, err := service.GetKillCountByRegionID(r.Context(), regionID, mode, from, to)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Error fetching kills: %v", err), http.StatusInternalServerError)
+		slog.ErrorContext(r.Context(), "Error fetching kills", "region_id", regionID, "mode", mode, "err", err)
+		http.Error(w, fmt.Sprintf("Error fetching kills: %v", err), queryErrorStatus(r, err))
 		return
 	}
 	// Create the response payload
@@ -846,10 +1077,10 @@ func GetTopRegionsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	// Get the top regions
-	topRegions, err := service.GetTopRegionsByKills(mode)
+	topRegions, err := service.GetTopRegionsByKills(r.Context(), mode)
 	if err != nil {
-		log.Printf("Error fetching top regions for window %s: %v", mode, err)
-		respondError(w, http.StatusInternalServerError, "Failed to fetch rankings")
+		slog.ErrorContext(r.Context(), "Error fetching top regions", "mode", mode, "err", err)
+		respondError(w, queryErrorStatus(r, err), "Failed to fetch rankings")
 		return
 	}
 	// Return empty list instead of null if no results
@@ -879,10 +1110,10 @@ func GetTopConstellationsHandler(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusBadRequest, "Invalid mode. Must be 'hour', 'day', 'week', or 'month'")
 		return
 	}
-	topConstellations, err := service.GetTopConstellationsByKills(mode)
+	topConstellations, err := service.GetTopConstellationsByKills(r.Context(), mode)
 	if err != nil {
-		log.Printf("Error fetching top constellations for window %s: %v", mode, err)
-		respondError(w, http.StatusInternalServerError, "Failed to fetch rankings")
+		slog.ErrorContext(r.Context(), "Error fetching top constellations", "mode", mode, "err", err)
+		respondError(w, queryErrorStatus(r, err), "Failed to fetch rankings")
 		return
 	}
 	// Return empty list instead of null if no results
@@ -912,10 +1143,10 @@ func GetTopSystemsHandler(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusBadRequest, "Invalid mode. Must be 'hour', 'day', 'week', or 'month'")
 		return
 	}
-	topSystems, err := service.GetTopSystemsByKills(mode)
+	topSystems, err := service.GetTopSystemsByKills(r.Context(), mode)
 	if err != nil {
-		log.Printf("Error fetching top systems for window %s: %v", mode, err)
-		respondError(w, http.StatusInternalServerError, "Failed to fetch rankings")
+		slog.ErrorContext(r.Context(), "Error fetching top systems", "mode", mode, "err", err)
+		respondError(w, queryErrorStatus(r, err), "Failed to fetch rankings")
 		return
 	}
 	// Return empty list instead of null if no results
@@ -924,3 +1155,432 @@ func GetTopSystemsHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	respondJSON(w, http.StatusOK, topSystems)
 }
+
+// respondActivityProfile parses the shared activity profile parameters and writes the profile for a scope.
+func respondActivityProfile(w http.ResponseWriter, r *http.Request, scope string, idParam string) {
+	idStr := chi.URLParam(r, idParam)
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("Invalid %s ID", scope))
+		return
+	}
+	// Parse mode (default "month") and timezone (default "UTC")
+	mode := r.URL.Query().Get("mode")
+	if mode == "" {
+		mode = "month"
+	}
+	tz := r.URL.Query().Get("tz")
+	if tz == "" {
+		tz = "UTC"
+	}
+	profile, err := service.GetActivityProfile(r.Context(), scope, id, mode, tz)
+	if err != nil {
+		switch {
+			case strings.Contains(err.Error(), "invalid mode"), strings.Contains(err.Error(), "invalid timezone"):
+				respondError(w, http.StatusBadRequest, err.Error())
+			case strings.Contains(err.Error(), "not found"):
+				respondError(w, http.StatusNotFound, err.Error())
+			default:
+				slog.ErrorContext(r.Context(), "Error fetching activity profile", "scope", scope, "id", id, "err", err)
+				respondError(w, queryErrorStatus(r, err), "Failed to retrieve activity profile")
+		}
+		return
+	}
+	respondJSON(w, http.StatusOK, profile)
+}
+
+// GetSystemActivityProfileHandler godoc
+// @Summary Get activity profile by system ID
+// @Description Get a 7x24 day-of-week by hour-of-day matrix of kills and ISK for a system
+// @Tags reports
+// @Accept  json
+// @Produce  json
+// @Param systemID path int true "System ID"
+// @Param mode query string false "Lookback window (hour, day, week, month)" Enums(hour,day,week,month)
+// @Param tz query string false "IANA timezone used for bucketing, e.g. Europe/London (default UTC)"
+// @Success 200 {object} models.ActivityProfile
+// @Router /systems/{systemID}/activity-profile [get]
+func GetSystemActivityProfileHandler(w http.ResponseWriter, r *http.Request) {
+	respondActivityProfile(w, r, "system", "systemID")
+}
+
+// GetConstellationActivityProfileHandler godoc
+// @Summary Get activity profile by constellation ID
+// @Description Get a 7x24 day-of-week by hour-of-day matrix of kills and ISK for a constellation
+// @Tags reports
+// @Accept  json
+// @Produce  json
+// @Param constellationID path int true "Constellation ID"
+// @Param mode query string false "Lookback window (hour, day, week, month)" Enums(hour,day,week,month)
+// @Param tz query string false "IANA timezone used for bucketing, e.g. Europe/London (default UTC)"
+// @Success 200 {object} models.ActivityProfile
+// @Router /constellations/{constellationID}/activity-profile [get]
+func GetConstellationActivityProfileHandler(w http.ResponseWriter, r *http.Request) {
+	respondActivityProfile(w, r, "constellation", "constellationID")
+}
+
+// GetRegionActivityProfileHandler godoc
+// @Summary Get activity profile by region ID
+// @Description Get a 7x24 day-of-week by hour-of-day matrix of kills and ISK for a region
+// @Tags reports
+// @Accept  json
+// @Produce  json
+// @Param regionID path int true "Region ID"
+// @Param mode query string false "Lookback window (hour, day, week, month)" Enums(hour,day,week,month)
+// @Param tz query string false "IANA timezone used for bucketing, e.g. Europe/London (default UTC)"
+// @Success 200 {object} models.ActivityProfile
+// @Router /regions/{regionID}/activity-profile [get]
+func GetRegionActivityProfileHandler(w http.ResponseWriter, r *http.Request) {
+	respondActivityProfile(w, r, "region", "regionID")
+}
+
+// GetTopKillmailsHandler godoc
+// @Summary Get most valuable killmails
+// @Description Get the highest total_value killmails for a system, constellation or region (or universe-wide when no scope is given) within a time window
+// @Tags killmails
+// @Accept  json
+// @Produce  json
+// @Param scope query string false "Scope to rank killmails in (system, constellation, region)" Enums(system,constellation,region)
+// @Param id query int false "ID of the system, constellation or region (required with scope)"
+// @Param window query string false "Time window (hour, day, week, month)" Enums(hour,day,week,month)
+// @Param limit query int false "Number of killmails to return (1-100, default 10)"
+// @Success 200 {array} models.TopKillmail
+// @Router /killmails/top [get]
+func GetTopKillmailsHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	scope := q.Get("scope")
+	// Parse the scope ID, required whenever a scope is given
+	id := 0
+	if scope != "" {
+		var err error
+		id, err = strconv.Atoi(q.Get("id"))
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid or missing id for scope")
+			return
+		}
+	}
+	// Parse the window query parameter, default to "week"
+	window := q.Get("window")
+	if window == "" {
+		window = "week"
+	}
+	// Parse limit, default to 10
+	limit := 10
+	if limitStr := q.Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > 100 {
+			respondError(w, http.StatusBadRequest, "Invalid limit. Must be between 1 and 100")
+			return
+		}
+	}
+	kills, err := service.GetTopKillmails(r.Context(), scope, id, window, limit)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid mode") || strings.Contains(err.Error(), "invalid scope") {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		slog.ErrorContext(r.Context(), "Error fetching top killmails", "scope", scope, "id", id, "window", window, "err", err)
+		respondError(w, queryErrorStatus(r, err), "Failed to retrieve killmails")
+		return
+	}
+	respondJSON(w, http.StatusOK, kills)
+}
+
+// parseBattleGap reads the optional gap query parameter (minutes between kills of one battle).
+func parseBattleGap(r *http.Request) (time.Duration, error) {
+	gapStr := r.URL.Query().Get("gap")
+	if gapStr == "" {
+		return service.DefaultBattleGap, nil
+	}
+	gap, err := strconv.Atoi(gapStr)
+	if err != nil || gap < 1 || gap > 120 {
+		return 0, fmt.Errorf("invalid gap. Must be between 1 and 120 minutes")
+	}
+	return time.Duration(gap) * time.Minute, nil
+}
+
+// GetBattlesByRegionHandler godoc
+// @Summary Get recent battles by region ID
+// @Description Get battles with kills in a region, built by grouping killmails in the same or stargate-adjacent systems, across region borders, that happened less than gap minutes apart. Kills are grouped per UTC day, so a battle running past midnight UTC is split there.
+// @Tags battles
+// @Accept  json
+// @Produce  json
+// @Param regionID path int true "Region ID"
+// @Param mode query string false "Window to list battles for (hour, day, week, month)" Enums(hour,day,week,month)
+// @Param gap query int false "Maximum minutes between kills of the same battle (1-120, default 15)"
+// @Param min_kills query int false "Minimum kills for a battle to be listed (default 5)"
+// @Success 200 {array} models.Battle
+// @Router /regions/{regionID}/battles [get]
+func GetBattlesByRegionHandler(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "regionID")
+	regionID, err := strconv.Atoi(idStr)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid region ID")
+		return
+	}
+	// Parse mode (default "day"), gap and min_kills
+	mode := r.URL.Query().Get("mode")
+	if mode == "" {
+		mode = "day"
+	}
+	gap, err := parseBattleGap(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	minKills := service.DefaultBattleMinKills
+	if minStr := r.URL.Query().Get("min_kills"); minStr != "" {
+		minKills, err = strconv.Atoi(minStr)
+		if err != nil || minKills < 1 {
+			respondError(w, http.StatusBadRequest, "Invalid min_kills. Must be a positive number")
+			return
+		}
+	}
+	battles, err := service.GetRecentBattlesByRegion(r.Context(), regionID, mode, gap, minKills)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid mode") {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		slog.ErrorContext(r.Context(), "Error fetching battles for region", "region_id", regionID, "err", err)
+		respondError(w, queryErrorStatus(r, err), "Failed to retrieve battles")
+		return
+	}
+	respondJSON(w, http.StatusOK, battles)
+}
+
+// GetBattleHandler godoc
+// @Summary Get a battle by ID
+// @Description Get a single battle, including its killmails. The battle ID is the ID of its first killmail.
+// @Tags battles
+// @Accept  json
+// @Produce  json
+// @Param battleID path int true "Battle ID"
+// @Param gap query int false "Maximum minutes between kills of the same battle (1-120, default 15)"
+// @Success 200 {object} models.Battle
+// @Router /battles/{battleID} [get]
+func GetBattleHandler(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "battleID")
+	battleID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid battle ID")
+		return
+	}
+	gap, err := parseBattleGap(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	battle, err := service.GetBattle(r.Context(), battleID, gap)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error fetching battle", "battle_id", battleID, "err", err)
+		respondError(w, queryErrorStatus(r, err), "Failed to retrieve battle")
+		return
+	}
+	if battle == nil {
+		respondError(w, http.StatusNotFound, "Battle not found")
+		return
+	}
+	respondJSON(w, http.StatusOK, battle)
+}
+
+// GetCampAlertsHandler godoc
+// @Summary Get likely gate camps
+// @Description Flag gate systems with repeated kills in short succession by the same ship types. Kills in stargate-adjacent systems within minutes raise the score, so pipe systems camped in a row reinforce each other. Each system gets an active-camp score from 0 to 100.
+// @Tags alerts
+// @Accept  json
+// @Produce  json
+// @Param mode query string false "Window to look for camps in (hour, day)" Enums(hour,day)
+// @Param region_id query int false "Only report systems in this region"
+// @Param min_score query number false "Minimum camp score to report (0-100, default 50)"
+// @Success 200 {array} models.CampAlert
+// @Router /alerts/camps [get]
+func GetCampAlertsHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	// Parse mode (default "hour"), region_id and min_score
+	mode := q.Get("mode")
+	if mode == "" {
+		mode = "hour"
+	}
+	regionID := 0
+	if regionStr := q.Get("region_id"); regionStr != "" {
+		var err error
+		regionID, err = strconv.Atoi(regionStr)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid region ID")
+			return
+		}
+	}
+	minScore := service.DefaultCampMinScore
+	if scoreStr := q.Get("min_score"); scoreStr != "" {
+		var err error
+		minScore, err = strconv.ParseFloat(scoreStr, 64)
+		if err != nil || minScore < 0 || minScore > 100 {
+			respondError(w, http.StatusBadRequest, "Invalid min_score. Must be between 0 and 100")
+			return
+		}
+	}
+	alerts, err := service.GetGateCampAlerts(r.Context(), mode, regionID, minScore)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid mode") {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		slog.ErrorContext(r.Context(), "Error fetching camp alerts", "err", err)
+		respondError(w, queryErrorStatus(r, err), "Failed to retrieve camp alerts")
+		return
+	}
+	respondJSON(w, http.StatusOK, alerts)
+}
+
+// GetKillmailValueDistributionHandler godoc
+// @Summary Get killmail value distribution
+// @Description Get histogram bins, mean, median, p90 and p99 of total, destroyed and fitted value for a system, constellation or region (or universe-wide when no scope is given) within a time window
+// @Tags killmails
+// @Accept  json
+// @Produce  json
+// @Param scope query string false "Scope to compute the distribution for (system, constellation, region)" Enums(system,constellation,region)
+// @Param id query int false "ID of the system, constellation or region (required with scope)"
+// @Param window query string false "Time window (hour, day, week, month)" Enums(hour,day,week,month)
+// @Param bins query int false "Number of logarithmic histogram bins (1-100, default 20)"
+// @Success 200 {object} models.KillmailValueDistribution
+// @Router /killmails/distribution [get]
+func GetKillmailValueDistributionHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	scope := q.Get("scope")
+	// Parse the scope ID, required whenever a scope is given
+	id := 0
+	if scope != "" {
+		var err error
+		id, err = strconv.Atoi(q.Get("id"))
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid or missing id for scope")
+			return
+		}
+	}
+	// Parse the window query parameter, default to "week"
+	window := q.Get("window")
+	if window == "" {
+		window = "week"
+	}
+	// Parse bins, default to 20
+	bins := 20
+	if binsStr := q.Get("bins"); binsStr != "" {
+		var err error
+		bins, err = strconv.Atoi(binsStr)
+		if err != nil || bins < 1 || bins > 100 {
+			respondError(w, http.StatusBadRequest, "Invalid bins. Must be between 1 and 100")
+			return
+		}
+	}
+	report, err := service.GetKillmailValueDistribution(r.Context(), scope, id, window, bins)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid mode") || strings.Contains(err.Error(), "invalid scope") {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		slog.ErrorContext(r.Context(), "Error fetching value distribution", "scope", scope, "id", id, "window", window, "err", err)
+		respondError(w, queryErrorStatus(r, err), "Failed to retrieve value distribution")
+		return
+	}
+	respondJSON(w, http.StatusOK, report)
+}
+
+// SearchHandler godoc
+// @Summary Search names
+// @Description Case-insensitive prefix and fuzzy search over region, constellation, system, planet and station names, best matches first
+// @Tags search
+// @Accept  json
+// @Produce  json
+// @Param q query string true "Search text (at least 2 characters)"
+// @Param types query string false "Comma-separated types to search (region, constellation, system, planet, station), default all"
+// @Param limit query int false "Number of results to return (1-100, default 20)"
+// @Success 200 {array} models.SearchResult
+// @Router /search [get]
+func SearchHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var types []string
+	for _, t := range strings.Split(q.Get("types"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			types = append(types, t)
+		}
+	}
+	// Parse limit, default to 20
+	limit := 20
+	if limitStr := q.Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > 100 {
+			respondError(w, http.StatusBadRequest, "Invalid limit. Must be between 1 and 100")
+			return
+		}
+	}
+	results, err := service.Search(r.Context(), q.Get("q"), types, limit)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid query") || strings.Contains(err.Error(), "invalid type") {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		slog.ErrorContext(r.Context(), "Error searching", "query", q.Get("q"), "err", err)
+		respondError(w, queryErrorStatus(r, err), "Failed to search")
+		return
+	}
+	respondJSON(w, http.StatusOK, results)
+}
+
+// GetUniverseNamesHandler godoc
+// @Summary Resolve IDs to names
+// @Description Resolve up to 1000 region, constellation, system, planet, station or stargate IDs to their category and name. Unknown IDs are left out of the response
+// @Tags universe
+// @Accept  json
+// @Produce  json
+// @Param ids body []int true "IDs to resolve"
+// @Success 200 {array} models.UniverseName
+// @Router /universe/names [post]
+func GetUniverseNamesHandler(w http.ResponseWriter, r *http.Request) {
+	var ids []int
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&ids); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid body. Must be a JSON array of IDs")
+		return
+	}
+	names, err := service.GetUniverseNames(r.Context(), ids)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid request") {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		slog.ErrorContext(r.Context(), "Error resolving universe IDs", "ids", len(ids), "err", err)
+		respondError(w, queryErrorStatus(r, err), "Failed to resolve IDs")
+		return
+	}
+	respondJSON(w, http.StatusOK, names)
+}
+
+// GetUniverseIDsHandler godoc
+// @Summary Resolve names to IDs
+// @Description Resolve up to 1000 exact names (ignoring case) to the IDs of the regions, constellations, systems, planets, stations and stargates carrying them. Unknown names are left out of the response
+// @Tags universe
+// @Accept  json
+// @Produce  json
+// @Param names body []string true "Names to resolve"
+// @Success 200 {object} models.UniverseIDs
+// @Router /universe/ids [post]
+func GetUniverseIDsHandler(w http.ResponseWriter, r *http.Request) {
+	var names []string
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&names); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid body. Must be a JSON array of names")
+		return
+	}
+	ids, err := service.GetUniverseIDs(r.Context(), names)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid request") {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		slog.ErrorContext(r.Context(), "Error resolving universe names", "names", len(names), "err", err)
+		respondError(w, queryErrorStatus(r, err), "Failed to resolve names")
+		return
+	}
+	respondJSON(w, http.StatusOK, ids)
+}
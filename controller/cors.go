@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSPolicy configures cross-origin requests, see CORS.
+type CORSPolicy struct {
+	AllowedOrigins []string // "*" allows any origin; none disables CORS
+	AllowedMethods []string
+	AllowedHeaders []string
+	ExposedHeaders []string // Response headers readable by cross-origin callers
+	MaxAge         time.Duration
+}
+
+// CORSConfig is the policy applied by CORS. Set it before RegisterRoutes.
+var CORSConfig CORSPolicy
+
+// CORS lets browsers call the API from the allowed origins and answers their preflight requests.
+// Requests from other origins are served without CORS headers, so browsers withhold the response.
+func CORS(next http.Handler) http.Handler {
+	policy := CORSConfig
+	allowAny := slices.Contains(policy.AllowedOrigins, "*")
+	methods := strings.Join(policy.AllowedMethods, ", ")
+	headers := strings.Join(policy.AllowedHeaders, ", ")
+	exposed := strings.Join(policy.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(policy.MaxAge.Seconds()))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" || len(policy.AllowedOrigins) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		h := w.Header()
+		h.Add("Vary", "Origin")
+		if !allowAny && !slices.Contains(policy.AllowedOrigins, origin) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if allowAny {
+			h.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			h.Set("Access-Control-Allow-Origin", origin)
+		}
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+			h.Set("Access-Control-Allow-Methods", methods)
+			h.Set("Access-Control-Allow-Headers", headers)
+			h.Set("Access-Control-Max-Age", maxAge)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if exposed != "" {
+			h.Set("Access-Control-Expose-Headers", exposed)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
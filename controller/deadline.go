@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// DefaultQueryTimeout bounds the queries behind a request unless QueryTimeouts sets its route.
+// Zero or less leaves requests without a deadline.
+var DefaultQueryTimeout = 10 * time.Second
+
+// QueryTimeouts overrides DefaultQueryTimeout per route pattern. The analyses scanning whole
+// windows of killmails get longer than the lookups.
+var QueryTimeouts = map[string]time.Duration{
+	"/v1/regions/{regionID}/heatmap":                        30 * time.Second,
+	"/v1/killmails/distribution":                            30 * time.Second,
+	"/v1/systems/{systemID}/activity-profile":               30 * time.Second,
+	"/v1/constellations/{constellationID}/activity-profile": 30 * time.Second,
+	"/v1/regions/{regionID}/activity-profile":               30 * time.Second,
+	"/v1/regions/{regionID}/battles":                        30 * time.Second,
+	"/v1/battles/{battleID}":                                30 * time.Second,
+	"/v1/alerts/camps":                                      30 * time.Second,
+}
+
+// QueryDeadline cancels the request context once the timeout of the matched route has passed,
+// so the queries it is waiting on are abandoned rather than left running.
+func QueryDeadline(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timeout, ok := QueryTimeouts[routePattern(r)]
+		if !ok {
+			timeout = DefaultQueryTimeout
+		}
+		if timeout <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// queryErrorStatus picks the status for a failed lookup: 504 when it ran out of time,
+// 503 when the request was cancelled (the client went away or the server is shutting down)
+// and 500 for anything else.
+func queryErrorStatus(r *http.Request, err error) int {
+	switch {
+		case errors.Is(err, context.DeadlineExceeded), errors.Is(r.Context().Err(), context.DeadlineExceeded):
+			return http.StatusGatewayTimeout
+		case errors.Is(err, context.Canceled), errors.Is(r.Context().Err(), context.Canceled):
+			return http.StatusServiceUnavailable
+		default:
+			return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,26 @@
+package controller
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/astrocartics-xyz/Astrocartics-API/service"
+)
+
+// HealthzHandler answers liveness probes: the process is up and serving HTTP.
+// It checks nothing else, so a database outage does not get the API restarted.
+func HealthzHandler(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, map[string]string{"status": service.StatusOK})
+}
+
+// ReadyzHandler answers readiness probes with the status of each component the API depends on,
+// and 503 unless all of them are ok.
+func ReadyzHandler(w http.ResponseWriter, r *http.Request) {
+	readiness := service.CheckReadiness(r.Context())
+	if readiness.Status != service.StatusOK {
+		slog.WarnContext(r.Context(), "Not ready", "components", readiness.Components)
+		respondJSON(w, http.StatusServiceUnavailable, readiness)
+		return
+	}
+	respondJSON(w, http.StatusOK, readiness)
+}
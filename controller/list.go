@@ -0,0 +1,292 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/astrocartics-xyz/Astrocartics-API/models"
+)
+
+const (
+	defaultPageLimit = 100
+	maxPageLimit     = 1000
+)
+
+// listParams holds the paging, sorting and field selection requested for a list endpoint.
+type listParams struct {
+	paged  bool // limit or cursor was given, so the response is a models.Page
+	limit  int
+	cursor string // cursor of the next page handed out with the previous one
+	sort   string // JSON field to sort by, empty keeps the stored order
+	desc   bool
+	fields []string // JSON fields to keep, empty keeps all
+}
+
+// encodeCursor turns an offset into the opaque cursor handed out in next links of lists paged in memory.
+func encodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("o:" + strconv.Itoa(offset)))
+}
+
+// decodeCursor reads an offset back from a cursor.
+func decodeCursor(cursor string) (int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(raw), "o:") {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	offset, err := strconv.Atoi(strings.TrimPrefix(string(raw), "o:"))
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	return offset, nil
+}
+
+// keyCursor is the content of the cursors of lists paged by the database: the sort the page was
+// read in, and the sort key and ID of its last row.
+type keyCursor struct {
+	Sort string `json:"s,omitempty"`
+	Desc bool   `json:"d,omitempty"`
+	Key  string `json:"k"`
+	ID   int    `json:"i"`
+}
+
+// encodeKeyCursor turns the last row of a page into the opaque cursor handed out in next links.
+func encodeKeyCursor(p listParams, last models.PageCursor) string {
+	data, _ := json.Marshal(keyCursor{Sort: p.sort, Desc: p.desc, Key: last.Key, ID: last.ID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeKeyCursor reads the position of a row back from a cursor, which must come from a page
+// in the same sort order.
+func decodeKeyCursor(cursor string, p listParams) (*models.PageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	var c keyCursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	if c.Sort != p.sort || c.Desc != p.desc {
+		return nil, fmt.Errorf("invalid cursor: it belongs to a different sort order")
+	}
+	return &models.PageCursor{Key: c.Key, ID: c.ID}, nil
+}
+
+// jsonFields returns the JSON field names of T. Every item of a list has the same fields,
+// so the zero value tells which are known.
+func jsonFields[T any]() map[string]bool {
+	known := map[string]bool{}
+	var zero T
+	sample, _ := json.Marshal(zero)
+	var fields map[string]interface{}
+	if json.Unmarshal(sample, &fields) == nil {
+		for f := range fields {
+			known[f] = true
+		}
+	}
+	return known
+}
+
+// toRows turns items into their JSON form, so sort and fields use the names clients see.
+func toRows[T any](items []T) ([]map[string]interface{}, error) {
+	data, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	rows := []map[string]interface{}{}
+	if err := dec.Decode(&rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// pickFields keeps only the given fields of each row, or every field if none are given.
+func pickFields(rows []map[string]interface{}, fields []string) {
+	if len(fields) == 0 {
+		return
+	}
+	for i, row := range rows {
+		picked := make(map[string]interface{}, len(fields))
+		for _, f := range fields {
+			picked[f] = row[f]
+		}
+		rows[i] = picked
+	}
+}
+
+// parseListParams reads limit, cursor, sort and fields, checking sort and fields against the item's JSON fields.
+func parseListParams(r *http.Request, known map[string]bool) (listParams, error) {
+	q := r.URL.Query()
+	p := listParams{limit: defaultPageLimit}
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			return p, fmt.Errorf("invalid limit. Must be between 1 and %d", maxPageLimit)
+		}
+		p.limit = limit
+		p.paged = true
+	}
+	if v := q.Get("cursor"); v != "" {
+		p.cursor = v
+		p.paged = true
+	}
+	if v := q.Get("sort"); v != "" {
+		p.sort = strings.TrimPrefix(v, "-")
+		p.desc = strings.HasPrefix(v, "-")
+		if !known[p.sort] {
+			return p, fmt.Errorf("invalid sort field: %s", p.sort)
+		}
+	}
+	if v := q.Get("fields"); v != "" {
+		for _, f := range strings.Split(v, ",") {
+			f = strings.TrimSpace(f)
+			if f == "" {
+				continue
+			}
+			if !known[f] {
+				return p, fmt.Errorf("invalid field: %s", f)
+			}
+			p.fields = append(p.fields, f)
+		}
+	}
+	return p, nil
+}
+
+// compareValues orders two decoded JSON values: nulls first, then numbers or strings.
+func compareValues(a interface{}, b interface{}) int {
+	switch {
+		case a == nil && b == nil:
+			return 0
+		case a == nil:
+			return -1
+		case b == nil:
+			return 1
+	}
+	if an, ok := a.(json.Number); ok {
+		if bn, ok := b.(json.Number); ok {
+			af, _ := an.Float64()
+			bf, _ := bn.Float64()
+			switch {
+				case af < bf:
+					return -1
+				case af > bf:
+					return 1
+			}
+			return 0
+		}
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+// respondList writes a list small enough to be loaded whole, applying the sort, fields, limit
+// and cursor query parameters in memory. Without limit or cursor the items are written as a plain
+// array, as before; with either, one page is wrapped in a models.Page with the total count and a
+// link to the next page.
+func respondList[T any](w http.ResponseWriter, r *http.Request, items []T) {
+	q := r.URL.Query()
+	if q.Get("limit") == "" && q.Get("cursor") == "" && q.Get("sort") == "" && q.Get("fields") == "" {
+		respondJSON(w, http.StatusOK, items)
+		return
+	}
+	p, err := parseListParams(r, jsonFields[T]())
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	offset := 0
+	if p.cursor != "" {
+		if offset, err = decodeCursor(p.cursor); err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	rows, err := toRows(items)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to encode list")
+		return
+	}
+	if p.sort != "" {
+		sort.SliceStable(rows, func(i, j int) bool {
+			c := compareValues(rows[i][p.sort], rows[j][p.sort])
+			if p.desc {
+				return c > 0
+			}
+			return c < 0
+		})
+	}
+	pickFields(rows, p.fields)
+	if !p.paged {
+		respondJSON(w, http.StatusOK, rows)
+		return
+	}
+	page := models.Page{Total: len(rows), Limit: p.limit, Data: []map[string]interface{}{}}
+	if offset < len(rows) {
+		end := offset + p.limit
+		if end > len(rows) {
+			end = len(rows)
+		}
+		page.Data = rows[offset:end]
+		if end < len(rows) {
+			q.Set("cursor", encodeCursor(end))
+			q.Set("limit", strconv.Itoa(p.limit))
+			next := r.URL.Path + "?" + q.Encode()
+			page.Next = &next
+		}
+	}
+	respondJSON(w, http.StatusOK, page)
+}
+
+// parsePageRequest reads the list query parameters of a list the database sorts and pages,
+// whose items are of type T.
+func parsePageRequest[T any](r *http.Request) (listParams, models.PageRequest, error) {
+	p, err := parseListParams(r, jsonFields[T]())
+	if err != nil {
+		return p, models.PageRequest{}, err
+	}
+	page := models.PageRequest{Sort: p.sort, Desc: p.desc}
+	if p.paged {
+		page.Limit = p.limit
+	}
+	if p.cursor != "" {
+		if page.After, err = decodeKeyCursor(p.cursor, p); err != nil {
+			return p, page, err
+		}
+	}
+	return p, page, nil
+}
+
+// respondPage writes the items the database returned for parsePageRequest: a plain array without
+// limit or cursor, otherwise a models.Page with the total count and a link to the next page.
+func respondPage[T any](w http.ResponseWriter, r *http.Request, p listParams, items []T, total int, next *models.PageCursor) {
+	if !p.paged && len(p.fields) == 0 {
+		respondJSON(w, http.StatusOK, items)
+		return
+	}
+	rows, err := toRows(items)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to encode list")
+		return
+	}
+	pickFields(rows, p.fields)
+	if !p.paged {
+		respondJSON(w, http.StatusOK, rows)
+		return
+	}
+	page := models.Page{Total: total, Limit: p.limit, Data: rows}
+	if next != nil {
+		q := r.URL.Query()
+		q.Set("cursor", encodeKeyCursor(p, *next))
+		q.Set("limit", strconv.Itoa(p.limit))
+		link := r.URL.Path + "?" + q.Encode()
+		page.Next = &link
+	}
+	respondJSON(w, http.StatusOK, page)
+}
@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/astrocartics-xyz/Astrocartics-API/models"
+)
+
+func TestParsePageRequest(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/v1/planets?limit=2&sort=-moon_count&fields=planet_id", nil)
+	p, page, err := parsePageRequest[models.Planet](r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Limit != 2 || page.Sort != "moon_count" || !page.Desc || page.After != nil {
+		t.Errorf("unexpected page request: %+v", page)
+	}
+
+	cursor := encodeKeyCursor(p, models.PageCursor{Key: "3", ID: 40000002})
+	r = httptest.NewRequest(http.MethodGet, "/v1/planets?sort=-moon_count&cursor="+cursor, nil)
+	_, page, err = parsePageRequest[models.Planet](r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Limit != defaultPageLimit || page.After == nil || *page.After != (models.PageCursor{Key: "3", ID: 40000002}) {
+		t.Errorf("unexpected page request: %+v", page)
+	}
+
+	// A cursor is tied to its sort order
+	r = httptest.NewRequest(http.MethodGet, "/v1/planets?sort=moon_count&cursor="+cursor, nil)
+	if _, _, err := parsePageRequest[models.Planet](r); err == nil {
+		t.Error("expected an error for a cursor of another sort order")
+	}
+	r = httptest.NewRequest(http.MethodGet, "/v1/planets?cursor=bm9wZQ", nil)
+	if _, _, err := parsePageRequest[models.Planet](r); err == nil {
+		t.Error("expected an error for an invalid cursor")
+	}
+	r = httptest.NewRequest(http.MethodGet, "/v1/planets?sort=luminosity", nil)
+	if _, _, err := parsePageRequest[models.Planet](r); err == nil {
+		t.Error("expected an error for an unknown sort field")
+	}
+}
+
+func TestRespondPage(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/v1/stations?limit=1&fields=station_name", nil)
+	p, _, err := parsePageRequest[models.Station](r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	stations := []models.Station{{StationID: 60003760, StationName: "Jita IV - Moon 4", SystemID: 30000142}}
+	respondPage(w, r, p, stations, 5, &models.PageCursor{Key: "Jita IV - Moon 4", ID: 60003760})
+
+	var page models.Page
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 5 || page.Limit != 1 || len(page.Data) != 1 {
+		t.Fatalf("unexpected page: %+v", page)
+	}
+	if len(page.Data[0]) != 1 || page.Data[0]["station_name"] != "Jita IV - Moon 4" {
+		t.Errorf("fields not picked: %v", page.Data[0])
+	}
+	if page.Next == nil {
+		t.Fatal("expected a next link")
+	}
+	next, err := url.Parse(*page.Next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, err := decodeKeyCursor(next.Query().Get("cursor"), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.ID != 60003760 || next.Query().Get("fields") != "station_name" {
+		t.Errorf("unexpected next link: %s", *page.Next)
+	}
+
+	// Without list parameters the items are written as they are
+	r = httptest.NewRequest(http.MethodGet, "/v1/stations", nil)
+	p, _, _ = parsePageRequest[models.Station](r)
+	w = httptest.NewRecorder()
+	respondPage(w, r, p, stations, 1, nil)
+	var plain []models.Station
+	if err := json.Unmarshal(w.Body.Bytes(), &plain); err != nil || len(plain) != 1 {
+		t.Errorf("expected a plain array, got %s", w.Body.String())
+	}
+}
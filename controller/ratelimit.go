@@ -0,0 +1,138 @@
+package controller
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"github.com/astrocartics-xyz/Astrocartics-API/service"
+)
+
+// RateLimitEnabled turns the API key checks and rate limits on.
+var RateLimitEnabled = true
+
+// Request costs in rate limit tokens.
+const (
+	CostLight  = 1 // Static data lookups
+	CostMedium = 2 // Kill aggregates served from indexes, rollups or cache
+	CostHeavy  = 5 // Wide scans and bulk lookups
+)
+
+// apiKeyHeader carries the caller's API key.
+const apiKeyHeader = "X-API-Key"
+
+// TrustedProxies are the load balancers and proxies whose X-Forwarded-For and X-Real-IP
+// headers name the client. Requests from any other peer are keyed on the peer's address.
+var TrustedProxies []netip.Prefix
+
+// trustedProxy reports whether addr belongs to one of the TrustedProxies.
+func trustedProxy(addr netip.Addr) bool {
+	for _, prefix := range TrustedProxies {
+		if prefix.Contains(addr.Unmap()) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupAPIKey finds the key a request sends; tests replace it.
+var lookupAPIKey = service.LookupAPIKey
+
+// clientIP returns the address of the caller. When the direct peer is a trusted proxy, that is
+// the nearest untrusted address in X-Forwarded-For, walking back from the hop the proxy appended,
+// or else X-Real-IP; addresses left of an untrusted hop could have been made up by the caller.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	peer, err := netip.ParseAddr(host)
+	if err != nil || !trustedProxy(peer) {
+		return host
+	}
+	if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		client := peer
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+			if err != nil {
+				break
+			}
+			client = hop
+			if !trustedProxy(hop) {
+				break
+			}
+		}
+		return client.Unmap().String()
+	}
+	if realIP, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+		return realIP.Unmap().String()
+	}
+	return host
+}
+
+// RateLimit charges each request cost tokens from the caller's bucket: the bucket of its API key,
+// or of its IP address under the anonymous tier when it sends none. Unknown or revoked keys get
+// 401, requests heavier than the tier allows 403, and callers out of tokens 429. Each unknown key
+// also costs its IP address a token under service.KeyCheckTier, and an IP address out of those
+// gets 429 before its keys are looked up, so random keys cannot flood the database.
+func RateLimit(cost float64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !RateLimitEnabled {
+				next.ServeHTTP(w, r)
+				return
+			}
+			ip := clientIP(r)
+			bucketKey := "ip:" + ip
+			tierName := service.TierAnonymous
+			if key := r.Header.Get(apiKeyHeader); key != "" {
+				checkKey := "keycheck:" + ip
+				if check := service.CheckTokens(checkKey, service.KeyCheckTier, 1); !check.Allowed {
+					w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(check.RetryAfter.Seconds()))))
+					respondError(w, http.StatusTooManyRequests, "Too many invalid API keys")
+					return
+				}
+				apiKey, err := lookupAPIKey(r.Context(), key)
+				if err != nil {
+					slog.ErrorContext(r.Context(), "Error looking up API key", "err", err)
+					respondError(w, queryErrorStatus(r, err), "Failed to check API key")
+					return
+				}
+				if apiKey == nil {
+					service.TakeTokens(checkKey, service.KeyCheckTier, 1)
+					respondError(w, http.StatusUnauthorized, "Invalid or revoked API key")
+					return
+				}
+				bucketKey = fmt.Sprintf("key:%d", apiKey.APIKeyID)
+				tierName = apiKey.Tier
+			}
+			tier, ok := service.Tiers[tierName]
+			if !ok {
+				slog.ErrorContext(r.Context(), "API key bucket has unknown tier", "bucket", bucketKey, "tier", tierName)
+				respondError(w, http.StatusForbidden, "Unknown usage tier")
+				return
+			}
+			if cost > tier.MaxCost {
+				respondError(w, http.StatusForbidden, fmt.Sprintf("This endpoint is not available on the %s tier", tierName))
+				return
+			}
+			result := service.TakeTokens(bucketKey, tier, cost)
+			h := w.Header()
+			h.Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+			h.Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			h.Set("X-RateLimit-Reset", strconv.Itoa(int(math.Ceil(result.Reset.Seconds()))))
+			if !result.Allowed {
+				h.Set("Retry-After", strconv.Itoa(int(math.Ceil(result.RetryAfter.Seconds()))))
+				respondError(w, http.StatusTooManyRequests, "Rate limit exceeded")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
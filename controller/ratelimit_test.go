@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/astrocartics-xyz/Astrocartics-API/models"
+	"github.com/astrocartics-xyz/Astrocartics-API/service"
+)
+
+func TestClientIP(t *testing.T) {
+	defer func(p []netip.Prefix) { TrustedProxies = p }(TrustedProxies)
+	TrustedProxies = []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+	tests := []struct {
+		name      string
+		peer      string
+		forwarded []string
+		realIP    string
+		want      string
+	}{
+		{"direct peer", "203.0.113.7:5000", nil, "", "203.0.113.7"},
+		{"untrusted peer's headers are ignored", "203.0.113.7:5000", []string{"198.51.100.1"}, "198.51.100.2", "203.0.113.7"},
+		{"trusted peer", "10.0.0.1:5000", []string{"198.51.100.1"}, "", "198.51.100.1"},
+		{"spoofed hops left of the client", "10.0.0.1:5000", []string{"1.2.3.4, 198.51.100.1, 10.0.0.2"}, "", "198.51.100.1"},
+		{"repeated headers", "10.0.0.1:5000", []string{"1.2.3.4", "198.51.100.1"}, "", "198.51.100.1"},
+		{"only trusted hops", "10.0.0.1:5000", []string{"10.0.0.3, 10.0.0.2"}, "", "10.0.0.3"},
+		{"invalid hop", "10.0.0.1:5000", []string{"1.2.3.4, unknown"}, "", "10.0.0.1"},
+		{"real IP", "10.0.0.1:5000", nil, "198.51.100.2", "198.51.100.2"},
+		{"trusted peer without headers", "10.0.0.1:5000", nil, "", "10.0.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/v1/regions", nil)
+			r.RemoteAddr = tt.peer
+			for _, v := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+			if tt.realIP != "" {
+				r.Header.Set("X-Real-IP", tt.realIP)
+			}
+			if got := clientIP(r); got != tt.want {
+				t.Errorf("clientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConditionalCallerHeaders(t *testing.T) {
+	handler := conditional(func(r *http.Request) (string, time.Time) {
+		return "public, max-age=60", time.Time{}
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	}))
+	serve := func(key string) http.Header {
+		r := httptest.NewRequest(http.MethodGet, "/v1/regions", nil)
+		if key != "" {
+			r.Header.Set(apiKeyHeader, key)
+		}
+		w := httptest.NewRecorder()
+		w.Header().Set("X-RateLimit-Remaining", "29")
+		handler.ServeHTTP(w, r)
+		return w.Header()
+	}
+
+	h := serve("")
+	if got := h.Get("Cache-Control"); got != "public, max-age=60" {
+		t.Errorf("anonymous Cache-Control = %q", got)
+	}
+	if h.Get("X-RateLimit-Remaining") != "" {
+		t.Error("shared anonymous response kept the caller's rate limit state")
+	}
+	if h.Get("Vary") != apiKeyHeader {
+		t.Errorf("Vary = %q, want %s", h.Get("Vary"), apiKeyHeader)
+	}
+
+	h = serve("secret")
+	if got := h.Get("Cache-Control"); got != "private, max-age=60" {
+		t.Errorf("keyed Cache-Control = %q", got)
+	}
+	if h.Get("X-RateLimit-Remaining") != "29" {
+		t.Error("private response lost the key's rate limit state")
+	}
+}
+
+func TestRateLimitInvalidKeys(t *testing.T) {
+	defer func(f func(context.Context, string) (*models.APIKey, error)) { lookupAPIKey = f }(lookupAPIKey)
+	lookups := 0
+	lookupAPIKey = func(ctx context.Context, key string) (*models.APIKey, error) {
+		lookups++
+		return nil, nil
+	}
+	handler := RateLimit(CostLight)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	serve := func(addr string, key string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/v1/regions", nil)
+		r.RemoteAddr = addr
+		r.Header.Set(apiKeyHeader, key)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	burst := int(service.KeyCheckTier.Burst)
+	for i := 0; i < burst; i++ {
+		if w := serve("192.0.2.10:5000", fmt.Sprintf("guess-%d", i)); w.Code != http.StatusUnauthorized {
+			t.Fatalf("guess %d answered %d, want 401", i, w.Code)
+		}
+	}
+	w := serve("192.0.2.10:5000", "guess-next")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Errorf("guess past the burst answered %d (Retry-After %q), want 429", w.Code, w.Header().Get("Retry-After"))
+	}
+	if lookups != burst {
+		t.Errorf("%d keys looked up, want %d", lookups, burst)
+	}
+	// Other addresses keep their own allowance
+	if w := serve("192.0.2.11:5000", "guess-other"); w.Code != http.StatusUnauthorized {
+		t.Errorf("another address answered %d, want 401", w.Code)
+	}
+}
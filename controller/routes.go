@@ -7,10 +7,27 @@ import (
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
+// DocsEnabled serves the Swagger UI under /swagger, loading the API definition from DocsURL.
+var (
+	DocsEnabled = true
+	DocsURL     = "https://api.astrocartics.xyz/swagger/doc.json"
+)
+
 func RegisterRoutes(r *chi.Mux) {
-	r.Get("/swagger/*", httpSwagger.Handler(
-		httpSwagger.URL("https://api.astrocartics.xyz/swagger/doc.json"), //The url pointing to API definition
-	))
+	r.Use(RequestID)
+	r.Use(CORS)
+	r.Use(Tracing)
+	r.Use(AccessLog)
+	r.Use(Metrics)
+
+	r.Get("/healthz", HealthzHandler)
+	r.Get("/readyz", ReadyzHandler)
+
+	if DocsEnabled {
+		r.Get("/swagger/*", httpSwagger.Handler(
+			httpSwagger.URL(DocsURL), //The url pointing to API definition
+		))
+	}
 
 	r.Route("/v1", func(r chi.Router) {
 		// Redirect from /v1 to swagger UI
@@ -18,41 +35,87 @@ func RegisterRoutes(r *chi.Mux) {
 			http.Redirect(w, r, "/swagger/index.html", http.StatusMovedPermanently)
 		})
 
-		r.Get("/regions", GetRegionsHandler)
-		r.Get("/regions/{regionID}", GetRegionByIDHandler)
+		// API key administration
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(AdminAuth)
+			r.Use(QueryDeadline)
+			r.Get("/keys", GetAPIKeysHandler)
+			r.Post("/keys", CreateAPIKeyHandler)
+			r.Delete("/keys/{keyID}", RevokeAPIKeyHandler)
+		})
 
-		r.Get("/constellations", GetConstellationsHandler)
-		r.Get("/constellations/{constellationID}", GetConstellationByIDHandler)
-		r.Get("/regions/{regionID}/constellations", GetConstellationsByRegionIDHandler)
+		r.With(QueryDeadline, RateLimit(CostHeavy)).Post("/universe/names", GetUniverseNamesHandler)
+		r.With(QueryDeadline, RateLimit(CostHeavy)).Post("/universe/ids", GetUniverseIDsHandler)
 
-		r.Get("/systems", GetSystemsHandler)
-		r.Get("/systems/{systemID}", GetSystemByIDHandler)
-		r.Get("/regions/{regionID}/systems", GetSystemsByRegionIDHandler)
-		r.Get("/constellations/{constellationID}/systems", GetSystemsByConstellationIDHandler)
+		// Static data only changes on import
+		r.Group(func(r chi.Router) {
+			r.Use(QueryDeadline)
+			r.Use(RateLimit(CostLight))
+			r.Use(StaticCache)
 
-		r.Get("/stargates", GetStargatesHandler)
-		r.Get("/systems/{systemID}/stargates", GetStargateBySystemIDHandler)
-		r.Get("/constellations/{constellationID}/stargates", GetStargateByConstellationIDHandler)
-		r.Get("/regions/{regionID}/stargates", GetStargateByRegionIDHandler)
+			r.Get("/search", SearchHandler)
 
-		r.Get("/planets", GetPlanetsHandler)
-		r.Get("/planets/{planetID}", GetPlanetByIDHandler)
-		r.Get("/systems/{systemID}/planets", GetPlanetsBySystemIDHandler)
+			r.Get("/regions", GetRegionsHandler)
+			r.Get("/regions/{regionID}", GetRegionByIDHandler)
 
-		r.Get("/stations", GetStationsHandler)
-		r.Get("/stations/{stationID}", GetStationByIDHandler)
-		r.Get("/systems/{systemID}/stations", GetStationsBySystemIDHandler)
+			r.Get("/constellations", GetConstellationsHandler)
+			r.Get("/constellations/{constellationID}", GetConstellationByIDHandler)
+			r.Get("/regions/{regionID}/constellations", GetConstellationsByRegionIDHandler)
 
-		r.Get("/regions/{regionID}/heatmap", GetSystemHeatmapByRegionHandler)
-		r.Get("/systems/{systemID}/kills/summary", GetKillsBySystemIDHandler)
-		r.Get("/constellations/{constellationID}/kills/summary", GetKillsByConstellationIDHandler)
-		r.Get("/regions/{regionID}/kills/summary", GetKillsByRegionIDHandler)
-		r.Get("/systems/{systemID}/killmails", GetRecentKillmailsBySystemIDHandler)
+			r.Get("/systems", GetSystemsHandler)
+			r.Get("/systems/{systemID}", GetSystemByIDHandler)
+			r.Get("/regions/{regionID}/systems", GetSystemsByRegionIDHandler)
+			r.Get("/constellations/{constellationID}/systems", GetSystemsByConstellationIDHandler)
 
-		r.Get("/rankings/regions/top", GetTopRegionsHandler)
-		r.Get("/rankings/constellations/top", GetTopConstellationsHandler)
-		r.Get("/rankings/systems/top", GetTopSystemsHandler)
+			r.Get("/stargates", GetStargatesHandler)
+			r.Get("/systems/{systemID}/stargates", GetStargateBySystemIDHandler)
+			r.Get("/constellations/{constellationID}/stargates", GetStargateByConstellationIDHandler)
+			r.Get("/regions/{regionID}/stargates", GetStargateByRegionIDHandler)
+
+			r.Get("/planets", GetPlanetsHandler)
+			r.Get("/planets/{planetID}", GetPlanetByIDHandler)
+			r.Get("/systems/{systemID}/planets", GetPlanetsBySystemIDHandler)
+
+			r.Get("/stations", GetStationsHandler)
+			r.Get("/stations/{stationID}", GetStationByIDHandler)
+			r.Get("/systems/{systemID}/stations", GetStationsBySystemIDHandler)
+
+			r.Get("/reports/spectral-class-counts", GetSpectralClassCountsHandler)
+		})
 
-		r.Get("/reports/spectral-class-counts", GetSpectralClassCountsHandler)
+		// Kill data changes with every ingested killmail
+		r.Group(func(r chi.Router) {
+			r.Use(QueryDeadline)
+			r.Use(RateLimit(CostMedium))
+			r.Use(KillCache)
+
+			r.Get("/systems/{systemID}/kills/summary", GetKillsBySystemIDHandler)
+			r.Get("/constellations/{constellationID}/kills/summary", GetKillsByConstellationIDHandler)
+			r.Get("/regions/{regionID}/kills/summary", GetKillsByRegionIDHandler)
+			r.Get("/systems/{systemID}/killmails", GetRecentKillmailsBySystemIDHandler)
+			r.Get("/killmails/top", GetTopKillmailsHandler)
+
+			r.Get("/rankings/regions/top", GetTopRegionsHandler)
+			r.Get("/rankings/constellations/top", GetTopConstellationsHandler)
+			r.Get("/rankings/systems/top", GetTopSystemsHandler)
+		})
+
+		// Kill analyses that scan whole windows of killmails
+		r.Group(func(r chi.Router) {
+			r.Use(QueryDeadline)
+			r.Use(RateLimit(CostHeavy))
+			r.Use(KillCache)
+
+			r.Get("/regions/{regionID}/heatmap", GetSystemHeatmapByRegionHandler)
+			r.Get("/killmails/distribution", GetKillmailValueDistributionHandler)
+
+			r.Get("/systems/{systemID}/activity-profile", GetSystemActivityProfileHandler)
+			r.Get("/constellations/{constellationID}/activity-profile", GetConstellationActivityProfileHandler)
+			r.Get("/regions/{regionID}/activity-profile", GetRegionActivityProfileHandler)
+
+			r.Get("/regions/{regionID}/battles", GetBattlesByRegionHandler)
+			r.Get("/battles/{battleID}", GetBattleHandler)
+			r.Get("/alerts/camps", GetCampAlertsHandler)
+		})
 	})
 }
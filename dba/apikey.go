@@ -0,0 +1,85 @@
+package dba
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/astrocartics-xyz/Astrocartics-API/models"
+)
+
+// apiKeyColumns are the api_keys columns scanned into models.APIKey, in scanAPIKey order.
+const apiKeyColumns = `api_key_id, prefix, name, tier, created_at::text, revoked_at::text`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAPIKey(row rowScanner) (models.APIKey, error) {
+	var k models.APIKey
+	err := row.Scan(&k.APIKeyID, &k.Prefix, &k.Name, &k.Tier, &k.CreatedAt, &k.RevokedAt)
+	return k, err
+}
+
+// CreateAPIKey stores a new API key by its hash.
+func CreateAPIKey(ctx context.Context, keyHash string, prefix string, name string, tier string) (models.APIKey, error) {
+	ctx, db, done := startQuery(ctx, "CreateAPIKey")
+	defer done()
+	row := db.QueryRowContext(ctx, `INSERT INTO api_keys (key_hash, prefix, name, tier)
+		VALUES ($1, $2, $3, $4)
+		RETURNING `+apiKeyColumns, keyHash, prefix, name, tier)
+	k, err := scanAPIKey(row)
+	if err != nil {
+		return k, fmt.Errorf("failed to create API key: %w", err)
+	}
+	return k, nil
+}
+
+// GetAPIKeyByHash fetches the API key with the given hash, revoked or not. Returns nil if there is none.
+func GetAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	ctx, db, done := startQuery(ctx, "GetAPIKeyByHash")
+	defer done()
+	k, err := scanAPIKey(db.QueryRowContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE key_hash = $1", keyHash))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch API key: %w", err)
+	}
+	return &k, nil
+}
+
+// GetAllAPIKeys lists every API key, newest first.
+func GetAllAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	ctx, db, done := startQuery(ctx, "GetAllAPIKeys")
+	defer done()
+	rows, err := db.QueryContext(ctx, "SELECT " + apiKeyColumns + " FROM api_keys ORDER BY api_key_id DESC")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query API keys: %w", err)
+	}
+	defer rows.Close()
+	keys := []models.APIKey{}
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan API key: %w", err)
+		}
+		keys = append(keys, k)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey marks an API key as revoked. Returns false if no unrevoked key has that ID.
+func RevokeAPIKey(ctx context.Context, id int) (bool, error) {
+	ctx, db, done := startQuery(ctx, "RevokeAPIKey")
+	defer done()
+	res, err := db.ExecContext(ctx, "UPDATE api_keys SET revoked_at = NOW() WHERE api_key_id = $1 AND revoked_at IS NULL", id)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke API key %d: %w", id, err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
@@ -1,8 +1,10 @@
 package dba
 
 import (
+	"context"
 	"database/sql"
-	"log"
+	"fmt"
+	"log/slog"
 	"os"
 	"time"
 
@@ -12,38 +14,90 @@ import (
 
 var db *sql.DB
 
-func InitDB() {
-	connStr := os.Getenv("DATABASE_URL")
+// ConnConfig sizes the connection pool and sets how long InitDB keeps trying to connect.
+type ConnConfig struct {
+	URL               string
+	MaxOpenConns      int           // 0 is unlimited
+	MaxIdleConns      int
+	ConnMaxLifetime   time.Duration // 0 keeps connections forever
+	ConnMaxIdleTime   time.Duration
+	ConnectRetries    int
+	ConnectRetryDelay time.Duration
+}
 
-	const maxRetries = 10
-	const retryDelay = 5 * time.Second
+// DefaultConnConfig connects to DATABASE_URL, retrying for up to 50 seconds.
+func DefaultConnConfig() ConnConfig {
+	return ConnConfig{
+		URL:               os.Getenv("DATABASE_URL"),
+		MaxOpenConns:      25,
+		MaxIdleConns:      5,
+		ConnMaxLifetime:   30 * time.Minute,
+		ConnMaxIdleTime:   5 * time.Minute,
+		ConnectRetries:    10,
+		ConnectRetryDelay: 5 * time.Second,
+	}
+}
 
-	for i := 0; i < maxRetries; i++ {
+func InitDB(cfg ConnConfig) {
+	for i := 0; i < cfg.ConnectRetries; i++ {
 		var err error
-		db, err = sql.Open("postgres", connStr)
-		//db, err = sql.Open("pgx", connStr)
+		db, err = sql.Open("postgres", cfg.URL)
+		//db, err = sql.Open("pgx", cfg.URL)
 		if err != nil {
-			log.Printf("Attempt %d/%d: Error opening database connection: %v. Retrying in %v...", i+1, maxRetries, err, retryDelay)
-			time.Sleep(retryDelay)
+			slog.Warn("Error opening database connection, retrying", "attempt", i+1, "max_attempts", cfg.ConnectRetries, "retry_in", cfg.ConnectRetryDelay, "err", err)
+			time.Sleep(cfg.ConnectRetryDelay)
 			continue
 		}
+		db.SetMaxOpenConns(cfg.MaxOpenConns)
+		db.SetMaxIdleConns(cfg.MaxIdleConns)
+		db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+		db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
 
 		err = db.Ping()
 		if err != nil {
-			log.Printf("Attempt %d/%d: Error connecting to the database: %v. Retrying in %v...", i+1, maxRetries, err, retryDelay)
+			slog.Warn("Error connecting to the database, retrying", "attempt", i+1, "max_attempts", cfg.ConnectRetries, "retry_in", cfg.ConnectRetryDelay, "err", err)
 			db.Close()
-			time.Sleep(retryDelay)
+			time.Sleep(cfg.ConnectRetryDelay)
 			continue
 		}
 
-		log.Println("Successfully connected to PostgreSQL database!")
+		slog.Info("Connected to PostgreSQL database")
 		return
 	}
 
-	log.Fatalf("Failed to connect to PostgreSQL database after %d retries.", maxRetries)
+	slog.Error("Failed to connect to PostgreSQL database", "attempts", cfg.ConnectRetries)
+	os.Exit(1)
 }
 
 func GetDB() *sql.DB {
 	return db
-} 
+}
+
+// CloseDB closes the connection pool once the server has stopped serving.
+func CloseDB() error {
+	if db == nil {
+		return nil
+	}
+	return db.Close()
+}
+
+// PingDB checks that the database is reachable.
+func PingDB(ctx context.Context) error {
+	ctx, q, done := startQuery(ctx, "PingDB")
+	defer done()
+	if err := q.PingContext(ctx); err != nil {
+		return q.fail(fmt.Errorf("failed to ping database: %w", err))
+	}
+	return nil
+}
 
+// HasStaticData reports whether the static universe data has been imported.
+func HasStaticData(ctx context.Context) (bool, error) {
+	ctx, db, done := startQuery(ctx, "HasStaticData")
+	defer done()
+	var loaded bool
+	if err := db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM regions)").Scan(&loaded); err != nil {
+		return false, fmt.Errorf("failed to check for static data: %w", err)
+	}
+	return loaded, nil
+}
@@ -1,17 +1,22 @@
 package dba
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"math"
+	"strings"
 	"time"
 
 	"github.com/astrocartics-xyz/Astrocartics-API/models"
+	"github.com/lib/pq"
 )
 
 // GetAllRegions fetches all regions from the database.
-func GetAllRegions() ([]models.Region, error) {
-	db := GetDB()
-	rows, err := db.Query("SELECT region_id, region_name FROM regions ORDER BY region_name")
+func GetAllRegions(ctx context.Context) ([]models.Region, error) {
+	ctx, db, done := startQuery(ctx, "GetAllRegions")
+	defer done()
+	rows, err := db.QueryContext(ctx, "SELECT region_id, region_name FROM regions ORDER BY region_name")
 	if err != nil {
 		return nil, fmt.Errorf("failed to query regions: %w", err)
 	}
@@ -31,10 +36,11 @@ func GetAllRegions() ([]models.Region, error) {
 }
 
 // GetRegionByID fetching region by ID from the database.
-func GetRegionByID(id int) (*models.Region, error) {
-	db := GetDB()
+func GetRegionByID(ctx context.Context, id int) (*models.Region, error) {
+	ctx, db, done := startQuery(ctx, "GetRegionByID")
+	defer done()
 	var s models.Region
-	err := db.QueryRow("SELECT region_id, region_name FROM regions WHERE region_id = $1", id).
+	err := db.QueryRowContext(ctx, "SELECT region_id, region_name FROM regions WHERE region_id = $1", id).
 		Scan(&s.RegionID, &s.RegionName)
 	if err == sql.ErrNoRows {
 		return nil, nil // Not found
@@ -46,11 +52,12 @@ func GetRegionByID(id int) (*models.Region, error) {
 }
 
 // GetRegionByName fetches all regions by name.
-func GetRegionByName(name string) (*models.Region, error) {
-	db := GetDB()
+func GetRegionByName(ctx context.Context, name string) (*models.Region, error) {
+	ctx, db, done := startQuery(ctx, "GetRegionByName")
+	defer done()
 	var s models.Region
 	// Modify the SQL query to filter by region_name
-	err := db.QueryRow("SELECT region_id, region_name FROM regions WHERE region_name = $1", name).
+	err := db.QueryRowContext(ctx, "SELECT region_id, region_name FROM regions WHERE region_name = $1", name).
 		Scan(&s.RegionID, &s.RegionName)
 	if err == sql.ErrNoRows {
 		return nil, nil // Not found
@@ -62,10 +69,11 @@ func GetRegionByName(name string) (*models.Region, error) {
 }
 
 // GetAllConstellations fetches all constellations from the database.
-func GetAllConstellations() ([]models.Constellation, error) {
-	db := GetDB()
+func GetAllConstellations(ctx context.Context) ([]models.Constellation, error) {
+	ctx, db, done := startQuery(ctx, "GetAllConstellations")
+	defer done()
 	// Query all columns from the 'constellations' table, ordered by constellation_name.
-	rows, err := db.Query("SELECT constellation_id, constellation_name, region_id FROM constellations ORDER BY constellation_name")
+	rows, err := db.QueryContext(ctx, "SELECT constellation_id, constellation_name, region_id FROM constellations ORDER BY constellation_name")
 	if err != nil {
 		// Return an error if the query execution fails.
 		return nil, fmt.Errorf("failed to query constellations: %w", err)
@@ -90,13 +98,14 @@ func GetAllConstellations() ([]models.Constellation, error) {
 }
 
 // GetConstellationByIDOrRegionID fetches a single constellation by its ConstellationID and RegionID.
-func GetConstellationByIDOrRegionID(id int) ([]models.Constellation, error) {
-	db := GetDB()
+func GetConstellationByIDOrRegionID(ctx context.Context, id int) ([]models.Constellation, error) {
+	ctx, db, done := startQuery(ctx, "GetConstellationByIDOrRegionID")
+	defer done()
 	// Base query with an OR condition for the single ID parameter
 	// We use $1 for both conditions as it's the same input ID.
 	sqlQuery := "SELECT constellation_id, constellation_name, region_id FROM constellations WHERE constellation_id = $1 OR region_id = $1 ORDER BY constellation_name"
 	// Execute the query with the single ID parameter
-	rows, err := db.Query(sqlQuery, id)
+	rows, err := db.QueryContext(ctx, sqlQuery, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query constellations by constellation or region ID: %w", err)
 	}
@@ -119,12 +128,13 @@ func GetConstellationByIDOrRegionID(id int) ([]models.Constellation, error) {
 }
 
 // GetConstellationByName fetches a single constellation by its name.
-func GetConstellationByName(name string) (*models.Constellation, error) {
-	db := GetDB()
+func GetConstellationByName(ctx context.Context, name string) (*models.Constellation, error) {
+	ctx, db, done := startQuery(ctx, "GetConstellationByName")
+	defer done()
 	var c models.Constellation // Declare a variable of type Constellation to hold the fetched data
 	// Query a single row from the 'constellations' table where constellation_name matches the provided name.
 	// Use $1 as a placeholder for the name parameter.
-	err := db.QueryRow("SELECT constellation_id, constellation_name, region_id FROM constellations WHERE constellation_name = $1", name).
+	err := db.QueryRowContext(ctx, "SELECT constellation_id, constellation_name, region_id FROM constellations WHERE constellation_name = $1", name).
 		Scan(&c.ConstellationID, &c.ConstellationName, &c.RegionID)
 	// Check if no rows were returned (constellation not found).
 	if err == sql.ErrNoRows {
@@ -138,9 +148,10 @@ func GetConstellationByName(name string) (*models.Constellation, error) {
 }
 
 // GetAllSystems fetches all systems from the database.
-func GetAllSystems() ([]models.System, error) {
-	db := GetDB()
-	rows, err := db.Query(
+func GetAllSystems(ctx context.Context) ([]models.System, error) {
+	ctx, db, done := startQuery(ctx, "GetAllSystems")
+	defer done()
+	rows, err := db.QueryContext(ctx, 
 		`SELECT s.system_id,
 			s.system_name,
 			s.security_status,
@@ -172,9 +183,111 @@ func GetAllSystems() ([]models.System, error) {
 	return systems, nil
 }
 
+// systemsOrder pages the systems listing, by name unless asked otherwise.
+var systemsOrder = listOrder{
+	columns: map[string]string{
+		"system_id":        "s.system_id",
+		"system_name":      "s.system_name",
+		"security_status":  "s.security_status",
+		"security_class":   "COALESCE(s.security_class, '')",
+		"x_pos":            "s.x_pos",
+		"y_pos":            "s.y_pos",
+		"z_pos":            "s.z_pos",
+		"constellation_id": "s.constellation_id",
+		"region_id":        "c.region_id",
+		"spectral_class":   "COALESCE(s.spectral_class, '')",
+	},
+	def: "system_name",
+	id:  "s.system_id",
+}
+
+// GetSystems fetches one page of the systems matching every condition of the filter.
+// Returns the systems, the number of matches across all pages and the cursor of the next page,
+// nil on the last one.
+func GetSystems(ctx context.Context, filter models.SystemFilter, page models.PageRequest) ([]models.System, int, *models.PageCursor, error) {
+	ctx, db, done := startQuery(ctx, "GetSystems")
+	defer done()
+	var conditions []string
+	var args []interface{}
+	// add appends a condition, replacing ? with the next placeholder
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, strings.Replace(condition, "?", fmt.Sprintf("$%d", len(args)), 1))
+	}
+	if filter.MinSecurity != nil {
+		add("s.security_status >= ?", *filter.MinSecurity)
+	}
+	if filter.MaxSecurity != nil {
+		add("s.security_status <= ?", *filter.MaxSecurity)
+	}
+	if len(filter.SecurityClasses) > 0 {
+		add("s.security_class = ANY(?)", pq.Array(filter.SecurityClasses))
+	}
+	if len(filter.SpectralClasses) > 0 {
+		add("s.spectral_class = ANY(?)", pq.Array(filter.SpectralClasses))
+	}
+	if filter.RegionID != nil {
+		add("c.region_id = ?", *filter.RegionID)
+	}
+	if filter.ConstellationID != nil {
+		add("s.constellation_id = ?", *filter.ConstellationID)
+	}
+	if filter.HasStation != nil {
+		if *filter.HasStation {
+			conditions = append(conditions, "EXISTS (SELECT 1 FROM stations st WHERE st.system_id = s.system_id)")
+		} else {
+			conditions = append(conditions, "NOT EXISTS (SELECT 1 FROM stations st WHERE st.system_id = s.system_id)")
+		}
+	}
+	if filter.MinPlanets != nil {
+		add("(SELECT COUNT(*) FROM planets p WHERE p.system_id = s.system_id) >= ?", *filter.MinPlanets)
+	}
+	if b := filter.BoundingBox; b != nil {
+		add("s.x_pos >= ?", b.MinX)
+		add("s.x_pos <= ?", b.MaxX)
+		add("s.y_pos >= ?", b.MinY)
+		add("s.y_pos <= ?", b.MaxY)
+		add("s.z_pos >= ?", b.MinZ)
+		add("s.z_pos <= ?", b.MaxZ)
+	}
+	from := "systems s JOIN constellations c ON c.constellation_id = s.constellation_id"
+	query, queryArgs, err := systemsOrder.pageQuery(`s.system_id,
+			s.system_name,
+			s.security_status,
+			s.security_class,
+			s.x_pos,
+			s.y_pos,
+			s.z_pos,
+			s.constellation_id,
+			c.region_id,
+			s.spectral_class`, from, conditions, args, page)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	rows, err := db.QueryContext(ctx, query, queryArgs...)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("failed to query systems: %w", err)
+	}
+	defer rows.Close()
+	systems, next, err := scanPage(rows, page.Limit, func(s *models.System) []interface{} {
+		return []interface{}{&s.SystemID, &s.SystemName, &s.SecurityStatus, &s.SecurityClass, &s.XPos, &s.YPos, &s.ZPos, &s.ConstellationID, &s.RegionID, &s.SpectralClass}
+	})
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("failed to read systems: %w", err)
+	}
+	total := len(systems)
+	if page.Limit > 0 {
+		if total, err = countRows(ctx, db, from, conditions, args); err != nil {
+			return nil, 0, nil, fmt.Errorf("failed to count systems: %w", err)
+		}
+	}
+	return systems, total, next, nil
+}
+
 // GetSystemByIDOrConstellationID fetches a single system by its SystemID and ConstellationID.
-func GetSystemByIDOrConstellationID(id int) ([]models.System, error) {
-	db := GetDB()
+func GetSystemByIDOrConstellationID(ctx context.Context, id int) ([]models.System, error) {
+	ctx, db, done := startQuery(ctx, "GetSystemByIDOrConstellationID")
+	defer done()
 	// Base query with an OR condition for the single ID parameter.
 	// We use $1 for both conditions as it's the same input ID.
 	sqlQuery := `SELECT s.system_id,
@@ -192,7 +305,7 @@ func GetSystemByIDOrConstellationID(id int) ([]models.System, error) {
 		WHERE s.system_id = $1 OR s.constellation_id = $1
 		ORDER BY s.system_name`
 	// Execute the query with the single ID parameter.
-	rows, err := db.Query(sqlQuery, id)
+	rows, err := db.QueryContext(ctx, sqlQuery, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query systems by system or constellation ID: %w", err)
 	}
@@ -226,15 +339,16 @@ func GetSystemByIDOrConstellationID(id int) ([]models.System, error) {
 }
 
 // GetSystemsByRegionID fetches all systems for a specific region ID.
-func GetSystemsByRegionID(regionID int) ([]models.System, error) {
-	db := GetDB()
+func GetSystemsByRegionID(ctx context.Context, regionID int) ([]models.System, error) {
+	ctx, db, done := startQuery(ctx, "GetSystemsByRegionID")
+	defer done()
 	sqlQuery := `
 		SELECT s.system_id, s.system_name, s.security_status, s.security_class, s.x_pos, s.y_pos, s.z_pos, s.constellation_id, c.region_id, s.spectral_class
 		FROM systems s
 		JOIN constellations c ON s.constellation_id = c.constellation_id
 		WHERE c.region_id = $1
 		ORDER BY s.system_name`
-	rows, err := db.Query(sqlQuery, regionID)
+	rows, err := db.QueryContext(ctx, sqlQuery, regionID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query systems by region ID: %w", err)
 	}
@@ -265,12 +379,13 @@ func GetSystemsByRegionID(regionID int) ([]models.System, error) {
 }
 
 // GetSystemByName fetches a single system by its name.
-func GetSystemByName(name string) (*models.System, error) {
-	db := GetDB()
+func GetSystemByName(ctx context.Context, name string) (*models.System, error) {
+	ctx, db, done := startQuery(ctx, "GetSystemByName")
+	defer done()
 	var s models.System // Declare a variable of type System to hold the fetched data
 	// Modify the SQL query to filter by system_name instead of system_id.
 	// Use $1 as a placeholder for the name parameter.
-	err := db.QueryRow(`SELECT s.system_id,
+	err := db.QueryRowContext(ctx, `SELECT s.system_id,
 		s.system_name,
 		s.security_status,
 		s.security_class,
@@ -296,12 +411,13 @@ func GetSystemByName(name string) (*models.System, error) {
 }
 
 // GetSystemIDByName fetches single system id by name
-func GetSystemNameByID(systemID int) (string, error) {
-	db := GetDB() // Get the database connection
+func GetSystemNameByID(ctx context.Context, systemID int) (string, error) {
+	ctx, db, done := startQuery(ctx, "GetSystemNameByID")
+	defer done()
 	// Query to fetch the system name by its ID
 	var systemName string
 	query := `SELECT system_name FROM systems WHERE system_id = $1`
-	err := db.QueryRow(query, systemID).Scan(&systemName)
+	err := db.QueryRowContext(ctx, query, systemID).Scan(&systemName)
 	if err == sql.ErrNoRows {
 		// If no rows are found, return an error
 		return "", fmt.Errorf("system with ID %d not found", systemID)
@@ -313,9 +429,10 @@ func GetSystemNameByID(systemID int) (string, error) {
 }
 
 // GetAllStargates fetches all stargate connections.
-func GetAllStargates() ([]models.Stargate, error) {
-	db := GetDB()
-	rows, err := db.Query("SELECT stargate_id, stargate_name, system_id, destination_stargate_id, destination_system_id FROM stargates ORDER BY stargate_name")
+func GetAllStargates(ctx context.Context) ([]models.Stargate, error) {
+	ctx, db, done := startQuery(ctx, "GetAllStargates")
+	defer done()
+	rows, err := db.QueryContext(ctx, "SELECT stargate_id, stargate_name, system_id, destination_stargate_id, destination_system_id FROM stargates ORDER BY stargate_name")
 	if err != nil {
 		return nil, fmt.Errorf("failed to query stargates: %w", err)
 	}
@@ -334,11 +451,54 @@ func GetAllStargates() ([]models.Stargate, error) {
 	return stargates, nil
 }
 
+// stargatesOrder pages the stargates listing, by name unless asked otherwise.
+var stargatesOrder = listOrder{
+	columns: map[string]string{
+		"stargate_id":             "stargate_id",
+		"stargate_name":           "stargate_name",
+		"system_id":               "system_id",
+		"destination_stargate_id": "destination_stargate_id",
+		"destination_system_id":   "destination_system_id",
+	},
+	def: "stargate_name",
+	id:  "stargate_id",
+}
+
+// GetStargates fetches one page of all stargates. Returns the stargates, the number of stargates
+// and the cursor of the next page, nil on the last one.
+func GetStargates(ctx context.Context, page models.PageRequest) ([]models.Stargate, int, *models.PageCursor, error) {
+	ctx, db, done := startQuery(ctx, "GetStargates")
+	defer done()
+	query, args, err := stargatesOrder.pageQuery("stargate_id, stargate_name, system_id, destination_stargate_id, destination_system_id", "stargates", nil, nil, page)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("failed to query stargates: %w", err)
+	}
+	defer rows.Close()
+	stargates, next, err := scanPage(rows, page.Limit, func(sg *models.Stargate) []interface{} {
+		return []interface{}{&sg.StargateID, &sg.StargateName, &sg.SystemID, &sg.DestinationStargateID, &sg.DestinationSystemID}
+	})
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("failed to read stargates: %w", err)
+	}
+	total := len(stargates)
+	if page.Limit > 0 {
+		if total, err = countRows(ctx, db, "stargates", nil, nil); err != nil {
+			return nil, 0, nil, fmt.Errorf("failed to count stargates: %w", err)
+		}
+	}
+	return stargates, total, next, nil
+}
+
 // GetStargateBySystemID fetches stargates associated with a given system ID.
-func GetStargateBySystemID(systemID int) ([]models.Stargate, error) {
-	db := GetDB()
+func GetStargateBySystemID(ctx context.Context, systemID int) ([]models.Stargate, error) {
+	ctx, db, done := startQuery(ctx, "GetStargateBySystemID")
+	defer done()
 	// Prepare the SQL query with a WHERE clause to filter by system_id.
-	rows, err := db.Query("SELECT stargate_id, stargate_name, system_id, destination_stargate_id, destination_system_id FROM stargates WHERE system_id = $1 ORDER BY stargate_name", systemID)
+	rows, err := db.QueryContext(ctx, "SELECT stargate_id, stargate_name, system_id, destination_stargate_id, destination_system_id FROM stargates WHERE system_id = $1 ORDER BY stargate_name", systemID)
 	if err != nil {
 		// Return an error if the query itself fails (e.g., database connection issues, syntax error).
 		return nil, fmt.Errorf("failed to query stargates by system ID: %w", err)
@@ -362,10 +522,11 @@ func GetStargateBySystemID(systemID int) ([]models.Stargate, error) {
 }
 
 // GetStargateByConstellationID fetches stargates with a given constellation ID
-func GetStargateByConstellationID(constellationID int) ([]models.Stargate, error) {
-	db := GetDB()
+func GetStargateByConstellationID(ctx context.Context, constellationID int) ([]models.Stargate, error) {
+	ctx, db, done := startQuery(ctx, "GetStargateByConstellationID")
+	defer done()
 	// Query
-	rows, err := db.Query(`SELECT st.stargate_id, st.stargate_name, st.system_id,
+	rows, err := db.QueryContext(ctx, `SELECT st.stargate_id, st.stargate_name, st.system_id,
 		st.destination_stargate_id, st.destination_system_id
 		FROM stargates st
 		WHERE st.system_id IN (SELECT system_id FROM systems WHERE constellation_id = $1)
@@ -392,10 +553,11 @@ func GetStargateByConstellationID(constellationID int) ([]models.Stargate, error
 }
 
 // GetStargateByRegionID fetches stargates with a given region ID.
-func GetStargateByRegionID(regionID int) ([]models.Stargate, error) {
-	db := GetDB()
+func GetStargateByRegionID(ctx context.Context, regionID int) ([]models.Stargate, error) {
+	ctx, db, done := startQuery(ctx, "GetStargateByRegionID")
+	defer done()
 	// Query
-	rows, err := db.Query(`SELECT st.stargate_id, st.stargate_name, st.system_id, st.destination_stargate_id, st.destination_system_id
+	rows, err := db.QueryContext(ctx, `SELECT st.stargate_id, st.stargate_name, st.system_id, st.destination_stargate_id, st.destination_system_id
 		FROM stargates st
 		WHERE st.system_id IN (SELECT s.system_id
 		FROM systems s
@@ -424,9 +586,10 @@ func GetStargateByRegionID(regionID int) ([]models.Stargate, error) {
 }
 
 // GetSpectralClassCounts fetches counts of systems by spectral class.
-func GetSpectralClassCounts() ([]models.SpectralClassCount, error) {
-	db := GetDB()
-	rows, err := db.Query("SELECT spectral_class, COUNT(system_id) AS system_count FROM systems WHERE spectral_class IS NOT NULL GROUP BY spectral_class ORDER BY system_count DESC")
+func GetSpectralClassCounts(ctx context.Context) ([]models.SpectralClassCount, error) {
+	ctx, db, done := startQuery(ctx, "GetSpectralClassCounts")
+	defer done()
+	rows, err := db.QueryContext(ctx, "SELECT spectral_class, COUNT(system_id) AS system_count FROM systems WHERE spectral_class IS NOT NULL GROUP BY spectral_class ORDER BY system_count DESC")
 	if err != nil {
 		return nil, fmt.Errorf("failed to query spectral class counts: %w", err)
 	}
@@ -446,9 +609,10 @@ func GetSpectralClassCounts() ([]models.SpectralClassCount, error) {
 }
 
 // Planet database functions
-func GetAllPlanets() ([]models.Planet, error) {
-	db := GetDB()
-	rows, err := db.Query("SELECT planet_id, planet_name, system_id, type, moon_count, asteroid_belt_count FROM planets ORDER BY planet_name")
+func GetAllPlanets(ctx context.Context) ([]models.Planet, error) {
+	ctx, db, done := startQuery(ctx, "GetAllPlanets")
+	defer done()
+	rows, err := db.QueryContext(ctx, "SELECT planet_id, planet_name, system_id, type, moon_count, asteroid_belt_count FROM planets ORDER BY planet_name")
 	if err != nil {
 		return nil, fmt.Errorf("failed to query planets: %w", err)
 	}
@@ -467,10 +631,54 @@ func GetAllPlanets() ([]models.Planet, error) {
 	return planets, nil
 }
 
-func GetPlanetByID(id int) (*models.Planet, error) {
-	db := GetDB()
+// planetsOrder pages the planets listing, by name unless asked otherwise.
+var planetsOrder = listOrder{
+	columns: map[string]string{
+		"planet_id":           "planet_id",
+		"planet_name":         "planet_name",
+		"system_id":           "system_id",
+		"type":                "COALESCE(type, '')",
+		"moon_count":          "moon_count",
+		"asteroid_belt_count": "asteroid_belt_count",
+	},
+	def: "planet_name",
+	id:  "planet_id",
+}
+
+// GetPlanets fetches one page of all planets. Returns the planets, the number of planets
+// and the cursor of the next page, nil on the last one.
+func GetPlanets(ctx context.Context, page models.PageRequest) ([]models.Planet, int, *models.PageCursor, error) {
+	ctx, db, done := startQuery(ctx, "GetPlanets")
+	defer done()
+	query, args, err := planetsOrder.pageQuery("planet_id, planet_name, system_id, type, moon_count, asteroid_belt_count", "planets", nil, nil, page)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("failed to query planets: %w", err)
+	}
+	defer rows.Close()
+	planets, next, err := scanPage(rows, page.Limit, func(p *models.Planet) []interface{} {
+		return []interface{}{&p.PlanetID, &p.PlanetName, &p.SystemID, &p.Type, &p.MoonCount, &p.AsteroidBeltCount}
+	})
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("failed to read planets: %w", err)
+	}
+	total := len(planets)
+	if page.Limit > 0 {
+		if total, err = countRows(ctx, db, "planets", nil, nil); err != nil {
+			return nil, 0, nil, fmt.Errorf("failed to count planets: %w", err)
+		}
+	}
+	return planets, total, next, nil
+}
+
+func GetPlanetByID(ctx context.Context, id int) (*models.Planet, error) {
+	ctx, db, done := startQuery(ctx, "GetPlanetByID")
+	defer done()
 	var p models.Planet
-	err := db.QueryRow("SELECT planet_id, planet_name, system_id, type, moon_count, asteroid_belt_count FROM planets WHERE planet_id = $1", id).
+	err := db.QueryRowContext(ctx, "SELECT planet_id, planet_name, system_id, type, moon_count, asteroid_belt_count FROM planets WHERE planet_id = $1", id).
 		Scan(&p.PlanetID, &p.PlanetName, &p.SystemID, &p.Type, &p.MoonCount, &p.AsteroidBeltCount)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -481,10 +689,11 @@ func GetPlanetByID(id int) (*models.Planet, error) {
 	return &p, nil
 }
 
-func GetPlanetByName(name string) (*models.Planet, error) {
-	db := GetDB()
+func GetPlanetByName(ctx context.Context, name string) (*models.Planet, error) {
+	ctx, db, done := startQuery(ctx, "GetPlanetByName")
+	defer done()
 	var p models.Planet
-	err := db.QueryRow("SELECT planet_id, planet_name, system_id, type, moon_count, asteroid_belt_count FROM planets WHERE planet_name = $1", name).
+	err := db.QueryRowContext(ctx, "SELECT planet_id, planet_name, system_id, type, moon_count, asteroid_belt_count FROM planets WHERE planet_name = $1", name).
 		Scan(&p.PlanetID, &p.PlanetName, &p.SystemID, &p.Type, &p.MoonCount, &p.AsteroidBeltCount)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -495,9 +704,10 @@ func GetPlanetByName(name string) (*models.Planet, error) {
 	return &p, nil
 }
 
-func GetPlanetsBySystemID(systemID int) ([]models.Planet, error) {
-	db := GetDB()
-	rows, err := db.Query("SELECT planet_id, planet_name, system_id, type, moon_count, asteroid_belt_count FROM planets WHERE system_id = $1 ORDER BY planet_name", systemID)
+func GetPlanetsBySystemID(ctx context.Context, systemID int) ([]models.Planet, error) {
+	ctx, db, done := startQuery(ctx, "GetPlanetsBySystemID")
+	defer done()
+	rows, err := db.QueryContext(ctx, "SELECT planet_id, planet_name, system_id, type, moon_count, asteroid_belt_count FROM planets WHERE system_id = $1 ORDER BY planet_name", systemID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query planets by system ID: %w", err)
 	}
@@ -516,9 +726,10 @@ func GetPlanetsBySystemID(systemID int) ([]models.Planet, error) {
 	return planets, nil
 }
 
-func GetAllStations() ([]models.Station, error) {
-	db := GetDB()
-	rows, err := db.Query("SELECT station_id, station_name, system_id FROM stations ORDER BY station_name")
+func GetAllStations(ctx context.Context) ([]models.Station, error) {
+	ctx, db, done := startQuery(ctx, "GetAllStations")
+	defer done()
+	rows, err := db.QueryContext(ctx, "SELECT station_id, station_name, system_id FROM stations ORDER BY station_name")
 	if err != nil {
 		return nil, fmt.Errorf("failed to query stations: %w", err)
 	}
@@ -537,10 +748,51 @@ func GetAllStations() ([]models.Station, error) {
 	return stations, nil
 }
 
-func GetStationByID(id int) (*models.Station, error) {
-	db := GetDB()
+// stationsOrder pages the stations listing, by name unless asked otherwise.
+var stationsOrder = listOrder{
+	columns: map[string]string{
+		"station_id":   "station_id",
+		"station_name": "station_name",
+		"system_id":    "system_id",
+	},
+	def: "station_name",
+	id:  "station_id",
+}
+
+// GetStations fetches one page of all stations. Returns the stations, the number of stations
+// and the cursor of the next page, nil on the last one.
+func GetStations(ctx context.Context, page models.PageRequest) ([]models.Station, int, *models.PageCursor, error) {
+	ctx, db, done := startQuery(ctx, "GetStations")
+	defer done()
+	query, args, err := stationsOrder.pageQuery("station_id, station_name, system_id", "stations", nil, nil, page)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("failed to query stations: %w", err)
+	}
+	defer rows.Close()
+	stations, next, err := scanPage(rows, page.Limit, func(s *models.Station) []interface{} {
+		return []interface{}{&s.StationID, &s.StationName, &s.SystemID}
+	})
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("failed to read stations: %w", err)
+	}
+	total := len(stations)
+	if page.Limit > 0 {
+		if total, err = countRows(ctx, db, "stations", nil, nil); err != nil {
+			return nil, 0, nil, fmt.Errorf("failed to count stations: %w", err)
+		}
+	}
+	return stations, total, next, nil
+}
+
+func GetStationByID(ctx context.Context, id int) (*models.Station, error) {
+	ctx, db, done := startQuery(ctx, "GetStationByID")
+	defer done()
 	var s models.Station
-	err := db.QueryRow("SELECT station_id, station_name, system_id FROM stations WHERE station_id = $1", id).
+	err := db.QueryRowContext(ctx, "SELECT station_id, station_name, system_id FROM stations WHERE station_id = $1", id).
 		Scan(&s.StationID, &s.StationName, &s.SystemID)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -551,10 +803,11 @@ func GetStationByID(id int) (*models.Station, error) {
 	return &s, nil
 }
 
-func GetStationByName(name string) (*models.Station, error) {
-	db := GetDB()
+func GetStationByName(ctx context.Context, name string) (*models.Station, error) {
+	ctx, db, done := startQuery(ctx, "GetStationByName")
+	defer done()
 	var s models.Station
-	err := db.QueryRow("SELECT station_id, station_name, system_id FROM stations WHERE station_name = $1", name).
+	err := db.QueryRowContext(ctx, "SELECT station_id, station_name, system_id FROM stations WHERE station_name = $1", name).
 		Scan(&s.StationID, &s.StationName, &s.SystemID)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -565,9 +818,10 @@ func GetStationByName(name string) (*models.Station, error) {
 	return &s, nil
 }
 
-func GetStationsBySystemID(systemID int) ([]models.Station, error) {
-	db := GetDB()
-	rows, err := db.Query("SELECT station_id, station_name, system_id FROM stations WHERE system_id = $1 ORDER BY station_name", systemID)
+func GetStationsBySystemID(ctx context.Context, systemID int) ([]models.Station, error) {
+	ctx, db, done := startQuery(ctx, "GetStationsBySystemID")
+	defer done()
+	rows, err := db.QueryContext(ctx, "SELECT station_id, station_name, system_id FROM stations WHERE system_id = $1 ORDER BY station_name", systemID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query stations by system ID: %w", err)
 	}
@@ -588,8 +842,9 @@ func GetStationsBySystemID(systemID int) ([]models.Station, error) {
 
 // GetSystemHeatmapByRegionMode queries per-period per-system metrics for a region.
 // Returns region name and a slice ordered by period desc, kills desc.
-func GetSystemHeatmapByRegionMode(regionID int, mode string) (string, []models.SystemPeriodHeatPoint, string, string, error) {
-	db := GetDB()
+func GetSystemHeatmapByRegionMode(ctx context.Context, regionID int, mode string) (string, []models.SystemPeriodHeatPoint, string, string, error) {
+	ctx, db, done := startQuery(ctx, "GetSystemHeatmapByRegionMode")
+	defer done()
 	if db == nil {
 		return "", nil, "", "", fmt.Errorf("database not initialized")
 	}
@@ -600,17 +855,17 @@ func GetSystemHeatmapByRegionMode(regionID int, mode string) (string, []models.S
 	}
         // Fetch region name
         var regionName string
-        _ = db.QueryRow("SELECT region_name FROM regions WHERE region_id = $1", regionID).Scan(&regionName)
+        _ = db.QueryRowContext(ctx, "SELECT region_name FROM regions WHERE region_id = $1", regionID).Scan(&regionName)
 	// Get window
 	var windowStart time.Time
 	var windowEnd time.Time
-	if err := db.QueryRow("SELECT (NOW() AT TIME ZONE 'UTC' - $1::interval) AS window_start, (NOW() AT TIME ZONE 'UTC') AS window_end", interval).Scan(&windowStart, &windowEnd); err != nil {
+	if err := db.QueryRowContext(ctx, "SELECT (NOW() AT TIME ZONE 'UTC' - $1::interval) AS window_start, (NOW() AT TIME ZONE 'UTC') AS window_end", interval).Scan(&windowStart, &windowEnd); err != nil {
 		return regionName, nil, "", "", fmt.Errorf("get window bounds: %w", err)
 	}
 	windowStartStr := windowStart.Format(time.RFC3339)
 	windowEndStr := time.Now().UTC().Format(time.RFC3339)
 	// query
-	const query = `SELECT
+	query := `SELECT
 		s.system_id,
 		s.system_name,
 		COUNT(k.killmail_id) AS kills,
@@ -624,8 +879,25 @@ func GetSystemHeatmapByRegionMode(regionID int, mode string) (string, []models.S
 		WHERE c.region_id = $1
 		GROUP BY s.system_id, s.system_name
 		ORDER BY kills DESC;`
+	// Long windows can be answered from the hourly rollups
+	if rollup := rollupTableForWindow(mode); rollup != "" {
+		query = `SELECT
+			s.system_id,
+			s.system_name,
+			COALESCE(SUM(h.kills), 0) AS kills,
+			COALESCE(SUM(h.destroyed_value), 0) AS destroyed_value,
+			COALESCE(SUM(h.dropped_value), 0) AS dropped_value
+			FROM systems s
+			JOIN constellations c ON s.constellation_id = c.constellation_id
+			LEFT JOIN ` + rollup + ` h
+			ON h.system_id = s.system_id
+			AND h.bucket >= DATE_TRUNC('hour', NOW() AT TIME ZONE 'UTC' - $2::interval)
+			WHERE c.region_id = $1
+			GROUP BY s.system_id, s.system_name
+			ORDER BY kills DESC;`
+	}
 	// Check for errors
-	rows, err := db.Query(query, regionID, interval)
+	rows, err := db.QueryContext(ctx, query, regionID, interval)
 	if err != nil {
 		return regionName, nil, "", "", fmt.Errorf("query system heatmap by mode: %w", err)
 	}
@@ -665,8 +937,9 @@ func GetSystemHeatmapByRegionMode(regionID int, mode string) (string, []models.S
 }
 
 // GetRecentKillmailsBySystemID returns the most recent 15 killmails for a given system.
-func GetRecentKillmailsBySystemID(systemID int) ([]models.Killmails, error) {
-	db := GetDB()
+func GetRecentKillmailsBySystemID(ctx context.Context, systemID int) ([]models.Killmails, error) {
+	ctx, db, done := startQuery(ctx, "GetRecentKillmailsBySystemID")
+	defer done()
 	// Build query
 	query := `SELECT
 		killmail_id,
@@ -683,7 +956,7 @@ func GetRecentKillmailsBySystemID(systemID int) ([]models.Killmails, error) {
 		WHERE solar_system_id = $1
 		ORDER BY killmail_time DESC
 		LIMIT 15`
-	rows, err := db.Query(query, systemID)
+	rows, err := db.QueryContext(ctx, query, systemID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query recent killmails by system: %w", err)
 	}
@@ -705,10 +978,11 @@ func GetRecentKillmailsBySystemID(systemID int) ([]models.Killmails, error) {
 }
 
 // GetKillsBySystemID fetches kill counts grouped by time periods
-func GetKillsBySystemID(systemID int, mode string) (string, []models.PeriodCount, error) {
-	db := GetDB()
+func GetKillsBySystemID(ctx context.Context, systemID int, mode string, from time.Time, to time.Time) (string, []models.PeriodCount, error) {
+	ctx, db, done := startQuery(ctx, "GetKillsBySystemID")
+	defer done()
 	// Fetch the system name using GetSystemNameByID
-	systemName, err := GetSystemNameByID(systemID)
+	systemName, err := GetSystemNameByID(ctx, systemID)
 	if err != nil {
 		return "", nil, fmt.Errorf("failed to fetch system name: %w", err)
 	}
@@ -718,11 +992,23 @@ func GetKillsBySystemID(systemID int, mode string) (string, []models.PeriodCount
 		COALESCE(SUM(destroyed_value), 0) AS destroyed_value,
 		COALESCE(SUM(dropped_value), 0) AS dropped_value
 		FROM killmails
-		WHERE solar_system_id = $1
+		WHERE solar_system_id = $1` + killTimeRange("killmail_time", from, to, 3) + `
 		GROUP BY period
 		ORDER BY period DESC`
+	// Read the pre-aggregated buckets instead when they are fine enough for the mode
+	if rollup := rollupTableForMode(mode, from, to); rollup != "" {
+		query = `SELECT DATE_TRUNC($2, bucket) AS period,
+			SUM(kills) AS count,
+			SUM(destroyed_value) AS destroyed_value,
+			SUM(dropped_value) AS dropped_value
+			FROM ` + rollup + `
+			WHERE system_id = $1` + killTimeRange("bucket", from, to, 3) + `
+			GROUP BY period
+			ORDER BY period DESC`
+	}
 	// Check for rows
-	rows, err := db.Query(query, systemID, mode)
+	rangeArgs := killTimeRangeArgs(from, to)
+	rows, err := db.QueryContext(ctx, query, append([]interface{}{systemID, mode}, rangeArgs...)...)
 	if err != nil {
         	return systemName, nil, fmt.Errorf("failed to query kills: %w", err)
 	}
@@ -746,10 +1032,11 @@ func GetKillsBySystemID(systemID int, mode string) (string, []models.PeriodCount
 }
 
 // GetKillsByConstellationID fetches kill counts grouped by time periods
-func GetKillsByConstellationID(constellationID int, mode string) (string, []models.PeriodCount, error) {
-	db := GetDB()
+func GetKillsByConstellationID(ctx context.Context, constellationID int, mode string, from time.Time, to time.Time) (string, []models.PeriodCount, error) {
+	ctx, db, done := startQuery(ctx, "GetKillsByConstellationID")
+	defer done()
 	// Fetch the constellation name by using GetConstellationByIDorRegionID
-	constellation, err := GetConstellationByIDOrRegionID(constellationID)
+	constellation, err := GetConstellationByIDOrRegionID(ctx, constellationID)
 	if err != nil {
 		return "", nil, fmt.Errorf("failed to fetch constellation name: %w", err)
 	}
@@ -766,11 +1053,24 @@ func GetKillsByConstellationID(constellationID int, mode string) (string, []mode
 		COALESCE(SUM(k.dropped_value), 0) AS dropped_value
 		FROM killmails k
 		JOIN systems s ON k.solar_system_id = s.system_id
-		WHERE s.constellation_id = $1
+		WHERE s.constellation_id = $1` + killTimeRange("k.killmail_time", from, to, 3) + `
 		GROUP BY period
 		ORDER BY period DESC;`
+	// Read the pre-aggregated buckets instead when they are fine enough for the mode
+	if rollup := rollupTableForMode(mode, from, to); rollup != "" {
+		query = `SELECT DATE_TRUNC($2, r.bucket) AS period,
+			SUM(r.kills) AS count,
+			SUM(r.destroyed_value) AS destroyed_value,
+			SUM(r.dropped_value) AS dropped_value
+			FROM ` + rollup + ` r
+			JOIN systems s ON r.system_id = s.system_id
+			WHERE s.constellation_id = $1` + killTimeRange("r.bucket", from, to, 3) + `
+			GROUP BY period
+			ORDER BY period DESC`
+	}
 	// Check for errors
-	rows, err := db.Query(query, constellationID, mode)
+	rangeArgs := killTimeRangeArgs(from, to)
+	rows, err := db.QueryContext(ctx, query, append([]interface{}{constellationID, mode}, rangeArgs...)...)
 	if err != nil {
 		return constellationName, nil, fmt.Errorf("failed to query kills: %w", err)
 	}
@@ -794,10 +1094,11 @@ func GetKillsByConstellationID(constellationID int, mode string) (string, []mode
 }
 
 // GetKillsByRegionID fetches kill counts grouped by time periods
-func GetKillsByRegionID(regionID int, mode string) (string, []models.PeriodCount, error) {
-	db := GetDB()
+func GetKillsByRegionID(ctx context.Context, regionID int, mode string, from time.Time, to time.Time) (string, []models.PeriodCount, error) {
+	ctx, db, done := startQuery(ctx, "GetKillsByRegionID")
+	defer done()
 	// Fetch the region name using GetRegionNameByID
-	region, err := GetRegionByID(regionID)
+	region, err := GetRegionByID(ctx, regionID)
 	if err != nil {
 		return "", nil, fmt.Errorf("failed to fetch region name: %w", err)
 	}
@@ -815,11 +1116,25 @@ func GetKillsByRegionID(regionID int, mode string) (string, []models.PeriodCount
 		FROM killmails k
 		JOIN systems s ON k.solar_system_id = s.system_id
 		JOIN constellations c ON s.constellation_id = c.constellation_id
-		WHERE c.region_id = $1
+		WHERE c.region_id = $1` + killTimeRange("k.killmail_time", from, to, 3) + `
 		GROUP BY period
 		ORDER BY period DESC;`
+	// Read the pre-aggregated buckets instead when they are fine enough for the mode
+	if rollup := rollupTableForMode(mode, from, to); rollup != "" {
+		query = `SELECT DATE_TRUNC($2, r.bucket) AS period,
+			SUM(r.kills) AS count,
+			SUM(r.destroyed_value) AS destroyed_value,
+			SUM(r.dropped_value) AS dropped_value
+			FROM ` + rollup + ` r
+			JOIN systems s ON r.system_id = s.system_id
+			JOIN constellations c ON s.constellation_id = c.constellation_id
+			WHERE c.region_id = $1` + killTimeRange("r.bucket", from, to, 3) + `
+			GROUP BY period
+			ORDER BY period DESC`
+	}
 	// Check for errors
-	rows, err := db.Query(query, regionID, mode)
+	rangeArgs := killTimeRangeArgs(from, to)
+	rows, err := db.QueryContext(ctx, query, append([]interface{}{regionID, mode}, rangeArgs...)...)
 	if err != nil {
 		return regionName, nil, fmt.Errorf("failed to query kills: %w", err)
 	}
@@ -842,6 +1157,33 @@ func GetKillsByRegionID(regionID int, mode string) (string, []models.PeriodCount
 	return regionName, buckets, nil
 }
 
+// killTimeRange returns the conditions limiting column to from <= column < to, numbering the
+// placeholders from first. A zero from or to leaves that side open. Bounding the kill time lets
+// Postgres skip the killmail partitions outside the range.
+func killTimeRange(column string, from time.Time, to time.Time, first int) string {
+	filter := ""
+	if !from.IsZero() {
+		filter += fmt.Sprintf(" AND %s >= $%d", column, first)
+		first++
+	}
+	if !to.IsZero() {
+		filter += fmt.Sprintf(" AND %s < $%d", column, first)
+	}
+	return filter
+}
+
+// killTimeRangeArgs returns the arguments for the placeholders of killTimeRange, in UTC like killmail_time.
+func killTimeRangeArgs(from time.Time, to time.Time) []interface{} {
+	var args []interface{}
+	if !from.IsZero() {
+		args = append(args, from.UTC().Format("2006-01-02 15:04:05"))
+	}
+	if !to.IsZero() {
+		args = append(args, to.UTC().Format("2006-01-02 15:04:05"))
+	}
+	return args
+}
+
 // Get time slice window
 func GetModeInterval(mode string) (string, error) {
 	switch mode {
@@ -859,8 +1201,9 @@ func GetModeInterval(mode string) (string, error) {
 }
 
 // Get top regions by kill count
-func GetTopRegionsByKills(mode string) ([]models.RegionKillCount, error) {
-	db := GetDB()
+func GetTopRegionsByKills(ctx context.Context, mode string) ([]models.RegionKillCount, error) {
+	ctx, db, done := startQuery(ctx, "GetTopRegionsByKills")
+	defer done()
 	// Get the interval
 	interval, err := GetModeInterval(mode)
 	if err != nil {
@@ -876,8 +1219,20 @@ func GetTopRegionsByKills(mode string) ([]models.RegionKillCount, error) {
 		GROUP BY r.region_id, r.region_name
 		ORDER BY kill_count DESC
 		LIMIT 10`, interval)
+	// Long windows can be answered from the hourly rollups
+	if rollup := rollupTableForWindow(mode); rollup != "" {
+		query = fmt.Sprintf(`SELECT r.region_id, r.region_name, SUM(h.kills) as kill_count
+			FROM %s h
+			JOIN systems s ON h.system_id = s.system_id
+			JOIN constellations c ON s.constellation_id = c.constellation_id
+			JOIN regions r ON c.region_id = r.region_id
+			WHERE h.bucket >= DATE_TRUNC('hour', NOW() AT TIME ZONE 'UTC' - INTERVAL '%s')
+			GROUP BY r.region_id, r.region_name
+			ORDER BY kill_count DESC
+			LIMIT 10`, rollup, interval)
+	}
 	// Check for errors
-	rows, err := db.Query(query)
+	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query top regions: %w", err)
 	}
@@ -898,8 +1253,9 @@ func GetTopRegionsByKills(mode string) ([]models.RegionKillCount, error) {
 }
 
 // Get top constellations by kill count
-func GetTopConstellationsByKills(mode string) ([]models.ConstellationKillCount, error) {
-	db := GetDB()
+func GetTopConstellationsByKills(ctx context.Context, mode string) ([]models.ConstellationKillCount, error) {
+	ctx, db, done := startQuery(ctx, "GetTopConstellationsByKills")
+	defer done()
 	// Get time interval
 	interval, err := GetModeInterval(mode)
 	if err != nil {
@@ -914,8 +1270,19 @@ func GetTopConstellationsByKills(mode string) ([]models.ConstellationKillCount,
 		GROUP BY c.constellation_id, c.constellation_name
 		ORDER BY kill_count DESC
 		LIMIT 10`, interval)
+	// Long windows can be answered from the hourly rollups
+	if rollup := rollupTableForWindow(mode); rollup != "" {
+		query = fmt.Sprintf(`SELECT c.constellation_id, c.constellation_name, SUM(h.kills) as kill_count
+			FROM %s h
+			JOIN systems s ON h.system_id = s.system_id
+			JOIN constellations c ON s.constellation_id = c.constellation_id
+			WHERE h.bucket >= DATE_TRUNC('hour', NOW() AT TIME ZONE 'UTC' - INTERVAL '%s')
+			GROUP BY c.constellation_id, c.constellation_name
+			ORDER BY kill_count DESC
+			LIMIT 10`, rollup, interval)
+	}
 	// Chjeck for errors
-	rows, err := db.Query(query)
+	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query top constellations: %w", err)
 	}
@@ -936,8 +1303,9 @@ func GetTopConstellationsByKills(mode string) ([]models.ConstellationKillCount,
 }
 
 // Get top systems by kill count
-func GetTopSystemsByKills(mode string) ([]models.SystemKillCount, error) {
-	db := GetDB()
+func GetTopSystemsByKills(ctx context.Context, mode string) ([]models.SystemKillCount, error) {
+	ctx, db, done := startQuery(ctx, "GetTopSystemsByKills")
+	defer done()
 	// Get interval
 	interval, err := GetModeInterval(mode)
 	if err != nil {
@@ -951,8 +1319,18 @@ func GetTopSystemsByKills(mode string) ([]models.SystemKillCount, error) {
 		GROUP BY s.system_id, s.system_name
 		ORDER BY kill_count DESC
 		LIMIT 10`, interval)
+	// Long windows can be answered from the hourly rollups
+	if rollup := rollupTableForWindow(mode); rollup != "" {
+		query = fmt.Sprintf(`SELECT s.system_id, s.system_name, SUM(h.kills) as kill_count
+			FROM %s h
+			JOIN systems s ON h.system_id = s.system_id
+			WHERE h.bucket >= DATE_TRUNC('hour', NOW() AT TIME ZONE 'UTC' - INTERVAL '%s')
+			GROUP BY s.system_id, s.system_name
+			ORDER BY kill_count DESC
+			LIMIT 10`, rollup, interval)
+	}
 	// Check for errors
-	rows, err := db.Query(query)
+	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query top systems: %w", err)
 	}
@@ -972,3 +1350,401 @@ func GetTopSystemsByKills(mode string) ([]models.SystemKillCount, error) {
 	return results, nil
 }
 
+// scopeFilter returns the WHERE condition restricting killmails (k), joined to
+// systems (s) and constellations (c), to a single system, constellation or region.
+// The scope ID is always bound to $1.
+func scopeFilter(scope string) (string, error) {
+	switch scope {
+		case "system":
+			return "k.solar_system_id = $1", nil
+		case "constellation":
+			return "s.constellation_id = $1", nil
+		case "region":
+			return "c.region_id = $1", nil
+		default:
+			return "", fmt.Errorf("invalid scope: %s", scope)
+	}
+}
+
+// scopeWindowFilter returns the WHERE condition and arguments restricting killmails (k), joined to
+// systems (s) and constellations (c), to a scope and a sliding window ending now.
+// An empty scope matches every system. Further arguments can be appended to the returned slice.
+func scopeWindowFilter(scope string, id int, interval string) (string, []interface{}, error) {
+	filter := "TRUE"
+	args := []interface{}{}
+	// Scope ID is bound to $1 when present
+	if scope != "" {
+		var err error
+		filter, err = scopeFilter(scope)
+		if err != nil {
+			return "", nil, err
+		}
+		args = append(args, id)
+	}
+	args = append(args, interval)
+	filter += fmt.Sprintf(" AND k.killmail_time >= (NOW() AT TIME ZONE 'UTC' - $%d::interval)", len(args))
+	return filter, args, nil
+}
+
+// GetScopeName fetches the name of a system, constellation or region by ID.
+func GetScopeName(ctx context.Context, scope string, id int) (string, error) {
+	ctx, db, done := startQuery(ctx, "GetScopeName")
+	defer done()
+	var query string
+	switch scope {
+		case "system":
+			query = "SELECT system_name FROM systems WHERE system_id = $1"
+		case "constellation":
+			query = "SELECT constellation_name FROM constellations WHERE constellation_id = $1"
+		case "region":
+			query = "SELECT region_name FROM regions WHERE region_id = $1"
+		default:
+			return "", fmt.Errorf("invalid scope: %s", scope)
+	}
+	var name string
+	err := db.QueryRowContext(ctx, query, id).Scan(&name)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("%s with ID %d not found", scope, id)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch %s name: %w", scope, err)
+	}
+	return name, nil
+}
+
+// GetActivityProfile counts kills and ISK per day-of-week and hour-of-day for a scope
+// over the sliding window of the given mode. Times are bucketed in the given timezone.
+// Returns the cells that have kills, plus the window start and end.
+func GetActivityProfile(ctx context.Context, scope string, id int, mode string, tz string) ([]models.ActivityCell, string, string, error) {
+	ctx, db, done := startQuery(ctx, "GetActivityProfile")
+	defer done()
+	// Get interval
+	interval, err := GetModeInterval(mode)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("invalid mode: %w", err)
+	}
+	filter, err := scopeFilter(scope)
+	if err != nil {
+		return nil, "", "", err
+	}
+	// Get window
+	var windowStart time.Time
+	if err := db.QueryRowContext(ctx, "SELECT (NOW() AT TIME ZONE 'UTC' - $1::interval)", interval).Scan(&windowStart); err != nil {
+		return nil, "", "", fmt.Errorf("get window bounds: %w", err)
+	}
+	windowStartStr := windowStart.Format(time.RFC3339)
+	windowEndStr := time.Now().UTC().Format(time.RFC3339)
+	// killmail_time is stored as UTC, so shift it into the requested timezone before bucketing
+	query := `SELECT EXTRACT(ISODOW FROM t.local_time)::int - 1 AS day_of_week,
+		EXTRACT(HOUR FROM t.local_time)::int AS hour,
+		COUNT(*) AS kills,
+		COALESCE(SUM(t.total_value), 0) AS value
+		FROM (
+			SELECT (k.killmail_time AT TIME ZONE 'UTC') AT TIME ZONE $2 AS local_time, k.total_value
+			FROM killmails k
+			JOIN systems s ON k.solar_system_id = s.system_id
+			JOIN constellations c ON s.constellation_id = c.constellation_id
+			WHERE ` + filter + `
+			AND k.killmail_time >= (NOW() AT TIME ZONE 'UTC' - $3::interval)
+		) t
+		GROUP BY day_of_week, hour
+		ORDER BY day_of_week, hour`
+	rows, err := db.QueryContext(ctx, query, id, tz, interval)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("failed to query activity profile: %w", err)
+	}
+	defer rows.Close()
+	// Iterate over rows
+	cells := make([]models.ActivityCell, 0, 7*24)
+	for rows.Next() {
+		var cell models.ActivityCell
+		if err := rows.Scan(&cell.DayOfWeek, &cell.Hour, &cell.Kills, &cell.Value); err != nil {
+			return nil, "", "", fmt.Errorf("failed to scan activity row: %w", err)
+		}
+		cells = append(cells, cell)
+	}
+	// Check for errors
+	if err := rows.Err(); err != nil {
+		return nil, "", "", fmt.Errorf("row iteration error: %w", err)
+	}
+	return cells, windowStartStr, windowEndStr, nil
+}
+
+// GetTopKillmails returns the highest total_value killmails within the sliding window of the given mode.
+// An empty scope ranks killmails across the whole universe.
+func GetTopKillmails(ctx context.Context, scope string, id int, mode string, limit int) ([]models.TopKillmail, error) {
+	ctx, db, done := startQuery(ctx, "GetTopKillmails")
+	defer done()
+	// Get interval
+	interval, err := GetModeInterval(mode)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mode: %w", err)
+	}
+	filter, args, err := scopeWindowFilter(scope, id, interval)
+	if err != nil {
+		return nil, err
+	}
+	args = append(args, limit)
+	query := fmt.Sprintf(`SELECT
+		k.killmail_id,
+		COALESCE(k.solar_system_id, 0) AS solar_system_id,
+		k.killmail_time,
+		COALESCE(k.destroyed_value, 0) AS destroyed_value,
+		COALESCE(k.dropped_value, 0) AS dropped_value,
+		COALESCE(k.killmail_hash, '') AS killmail_hash,
+		COALESCE(k.total_value, 0) AS total_value,
+		COALESCE(k.fitted_value, 0) AS fitted_value,
+		COALESCE(k.victim_ship, 0) AS victim_ship,
+		COALESCE(k.kill_ship, 0) AS kill_ship,
+		s.system_name,
+		r.region_id,
+		r.region_name
+		FROM killmails k
+		JOIN systems s ON k.solar_system_id = s.system_id
+		JOIN constellations c ON s.constellation_id = c.constellation_id
+		JOIN regions r ON c.region_id = r.region_id
+		WHERE %s
+		ORDER BY k.total_value DESC NULLS LAST, k.killmail_id DESC
+		LIMIT $%d`, filter, len(args))
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query top killmails: %w", err)
+	}
+	defer rows.Close()
+	// Iterate over rows
+	var kills = make([]models.TopKillmail, 0, limit)
+	for rows.Next() {
+		var k models.TopKillmail
+		if err := rows.Scan(&k.KillmailID, &k.SolarSystemID, &k.KillmailTime, &k.DestroyedValue, &k.DroppedValue, &k.KillmailHash, &k.TotalValue, &k.FittedValue, &k.VictimShip, &k.KillShip, &k.SystemName, &k.RegionID, &k.RegionName); err != nil {
+			return nil, fmt.Errorf("failed to scan killmail row: %w", err)
+		}
+		kills = append(kills, k)
+	}
+	// Check for errors
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return kills, nil
+}
+
+// GetKillmailsBetween returns every killmail with from <= killmail_time < to, oldest first.
+// Times are UTC.
+func GetKillmailsBetween(ctx context.Context, from time.Time, to time.Time) ([]models.TopKillmail, error) {
+	ctx, db, done := startQuery(ctx, "GetKillmailsBetween")
+	defer done()
+	query := `SELECT
+		k.killmail_id,
+		COALESCE(k.solar_system_id, 0) AS solar_system_id,
+		k.killmail_time,
+		COALESCE(k.destroyed_value, 0) AS destroyed_value,
+		COALESCE(k.dropped_value, 0) AS dropped_value,
+		COALESCE(k.killmail_hash, '') AS killmail_hash,
+		COALESCE(k.total_value, 0) AS total_value,
+		COALESCE(k.fitted_value, 0) AS fitted_value,
+		COALESCE(k.victim_ship, 0) AS victim_ship,
+		COALESCE(k.kill_ship, 0) AS kill_ship,
+		s.system_name,
+		r.region_id,
+		r.region_name
+		FROM killmails k
+		JOIN systems s ON k.solar_system_id = s.system_id
+		JOIN constellations c ON s.constellation_id = c.constellation_id
+		JOIN regions r ON c.region_id = r.region_id
+		WHERE k.killmail_time >= $1
+		AND k.killmail_time < $2
+		ORDER BY k.killmail_time, k.killmail_id`
+	rows, err := db.QueryContext(ctx, query, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query killmails: %w", err)
+	}
+	defer rows.Close()
+	// Iterate over rows
+	var kills = make([]models.TopKillmail, 0)
+	for rows.Next() {
+		var k models.TopKillmail
+		if err := rows.Scan(&k.KillmailID, &k.SolarSystemID, &k.KillmailTime, &k.DestroyedValue, &k.DroppedValue, &k.KillmailHash, &k.TotalValue, &k.FittedValue, &k.VictimShip, &k.KillShip, &k.SystemName, &k.RegionID, &k.RegionName); err != nil {
+			return nil, fmt.Errorf("failed to scan killmail row: %w", err)
+		}
+		kills = append(kills, k)
+	}
+	// Check for errors
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return kills, nil
+}
+
+// GetKillmailLocation returns the time and region of a single killmail.
+// Returns a zero time and region 0 if the killmail does not exist.
+func GetKillmailLocation(ctx context.Context, killmailID int64) (time.Time, int, error) {
+	ctx, db, done := startQuery(ctx, "GetKillmailLocation")
+	defer done()
+	var killTime time.Time
+	var regionID int
+	err := db.QueryRowContext(ctx, `SELECT k.killmail_time, c.region_id
+		FROM killmails k
+		JOIN systems s ON k.solar_system_id = s.system_id
+		JOIN constellations c ON s.constellation_id = c.constellation_id
+		WHERE k.killmail_id = $1`, killmailID).Scan(&killTime, &regionID)
+	if err == sql.ErrNoRows {
+		return time.Time{}, 0, nil // Not found
+	}
+	if err != nil {
+		return time.Time{}, 0, fmt.Errorf("failed to query killmail location: %w", err)
+	}
+	return killTime, regionID, nil
+}
+
+// GetGateSystemKillmailsSince returns killmails since the given time in systems that have stargates,
+// oldest first. A regionID of 0 covers every region.
+func GetGateSystemKillmailsSince(ctx context.Context, since time.Time, regionID int) ([]models.TopKillmail, error) {
+	ctx, db, done := startQuery(ctx, "GetGateSystemKillmailsSince")
+	defer done()
+	query := `SELECT
+		k.killmail_id,
+		COALESCE(k.solar_system_id, 0) AS solar_system_id,
+		k.killmail_time,
+		COALESCE(k.destroyed_value, 0) AS destroyed_value,
+		COALESCE(k.dropped_value, 0) AS dropped_value,
+		COALESCE(k.killmail_hash, '') AS killmail_hash,
+		COALESCE(k.total_value, 0) AS total_value,
+		COALESCE(k.fitted_value, 0) AS fitted_value,
+		COALESCE(k.victim_ship, 0) AS victim_ship,
+		COALESCE(k.kill_ship, 0) AS kill_ship,
+		s.system_name,
+		r.region_id,
+		r.region_name
+		FROM killmails k
+		JOIN systems s ON k.solar_system_id = s.system_id
+		JOIN constellations c ON s.constellation_id = c.constellation_id
+		JOIN regions r ON c.region_id = r.region_id
+		WHERE k.killmail_time >= $1
+		AND ($2 = 0 OR c.region_id = $2)
+		AND EXISTS (SELECT 1 FROM stargates st WHERE st.system_id = k.solar_system_id)
+		ORDER BY k.killmail_time, k.killmail_id`
+	rows, err := db.QueryContext(ctx, query, since.UTC(), regionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query gate system killmails: %w", err)
+	}
+	defer rows.Close()
+	// Iterate over rows
+	var kills = make([]models.TopKillmail, 0)
+	for rows.Next() {
+		var k models.TopKillmail
+		if err := rows.Scan(&k.KillmailID, &k.SolarSystemID, &k.KillmailTime, &k.DestroyedValue, &k.DroppedValue, &k.KillmailHash, &k.TotalValue, &k.FittedValue, &k.VictimShip, &k.KillShip, &k.SystemName, &k.RegionID, &k.RegionName); err != nil {
+			return nil, fmt.Errorf("failed to scan killmail row: %w", err)
+		}
+		kills = append(kills, k)
+	}
+	// Check for errors
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return kills, nil
+}
+
+// valueColumns lists the killmail value columns that distributions can be computed for.
+var valueColumns = map[string]bool{
+	"total_value": true,
+	"destroyed_value": true,
+	"fitted_value": true,
+}
+
+// GetKillmailValueStats computes count, mean, min, max and the median, p90 and p99 of a
+// killmail value column for a scope and window. Bins are left empty.
+func GetKillmailValueStats(ctx context.Context, scope string, id int, mode string, column string) (models.ValueDistribution, error) {
+	ctx, db, done := startQuery(ctx, "GetKillmailValueStats")
+	defer done()
+	var dist models.ValueDistribution
+	if !valueColumns[column] {
+		return dist, fmt.Errorf("invalid value column: %s", column)
+	}
+	// Get interval
+	interval, err := GetModeInterval(mode)
+	if err != nil {
+		return dist, fmt.Errorf("invalid mode: %w", err)
+	}
+	filter, args, err := scopeWindowFilter(scope, id, interval)
+	if err != nil {
+		return dist, err
+	}
+	query := fmt.Sprintf(`SELECT COUNT(v),
+		COALESCE(AVG(v), 0),
+		COALESCE(PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY v), 0),
+		COALESCE(PERCENTILE_CONT(0.9) WITHIN GROUP (ORDER BY v), 0),
+		COALESCE(PERCENTILE_CONT(0.99) WITHIN GROUP (ORDER BY v), 0),
+		COALESCE(MIN(v), 0),
+		COALESCE(MAX(v), 0)
+		FROM (
+			SELECT k.%s::double precision AS v
+			FROM killmails k
+			JOIN systems s ON k.solar_system_id = s.system_id
+			JOIN constellations c ON s.constellation_id = c.constellation_id
+			WHERE %s
+		) t`, column, filter)
+	err = db.QueryRowContext(ctx, query, args...).Scan(&dist.Count, &dist.Mean, &dist.Median, &dist.P90, &dist.P99, &dist.Min, &dist.Max)
+	if err != nil {
+		return dist, fmt.Errorf("failed to query %s stats: %w", column, err)
+	}
+	return dist, nil
+}
+
+// GetKillmailValueHistogram counts killmail values of a column into logarithmic bins spanning lo to hi.
+// Values below lo fall into the first bin, values of hi and above into the last.
+func GetKillmailValueHistogram(ctx context.Context, scope string, id int, mode string, column string, lo float64, hi float64, bins int) ([]models.ValueBin, error) {
+	ctx, db, done := startQuery(ctx, "GetKillmailValueHistogram")
+	defer done()
+	if !valueColumns[column] {
+		return nil, fmt.Errorf("invalid value column: %s", column)
+	}
+	if lo <= 0 || hi <= lo || bins < 1 {
+		return nil, fmt.Errorf("invalid histogram bounds: %v - %v, %d bins", lo, hi, bins)
+	}
+	// Get interval
+	interval, err := GetModeInterval(mode)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mode: %w", err)
+	}
+	filter, args, err := scopeWindowFilter(scope, id, interval)
+	if err != nil {
+		return nil, err
+	}
+	args = append(args, math.Log(lo), math.Log(hi), bins)
+	n := len(args)
+	query := fmt.Sprintf(`SELECT LEAST(GREATEST(WIDTH_BUCKET(LN(GREATEST(k.%s::double precision, $%d::double precision)), $%d, $%d, $%d), 1), $%d) AS bin,
+		COUNT(*)
+		FROM killmails k
+		JOIN systems s ON k.solar_system_id = s.system_id
+		JOIN constellations c ON s.constellation_id = c.constellation_id
+		WHERE %s
+		AND k.%s IS NOT NULL
+		GROUP BY bin
+		ORDER BY bin`, column, n+1, n-2, n-1, n, n, filter, column)
+	args = append(args, lo)
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s histogram: %w", column, err)
+	}
+	defer rows.Close()
+	// Build every bin so empty bins are reported too
+	step := (math.Log(hi) - math.Log(lo)) / float64(bins)
+	hist := make([]models.ValueBin, bins)
+	for i := range hist {
+		hist[i].Min = math.Exp(math.Log(lo) + float64(i)*step)
+		hist[i].Max = math.Exp(math.Log(lo) + float64(i+1)*step)
+	}
+	for rows.Next() {
+		var bin, count int
+		if err := rows.Scan(&bin, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan histogram row: %w", err)
+		}
+		if bin >= 1 && bin <= bins {
+			hist[bin-1].Count += count
+		}
+	}
+	// Check for errors
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return hist, nil
+}
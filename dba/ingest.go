@@ -0,0 +1,129 @@
+package dba
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/astrocartics-xyz/Astrocartics-API/models"
+	"github.com/lib/pq"
+)
+
+// UpsertKillmail stores a killmail. Seeing the same killmail_id again refreshes its hash and
+// values in place, so replaying a feed is harmless. Returns true if the row was newly inserted.
+// Both cases bump ingested_at so the kill rollups pick the change up.
+func UpsertKillmail(ctx context.Context, k models.Killmails) (bool, error) {
+	ctx, db, done := startQuery(ctx, "UpsertKillmail")
+	defer done()
+	// xmax is only zero for rows this statement inserted
+	query := `INSERT INTO killmails (
+		killmail_id,
+		killmail_hash,
+		solar_system_id,
+		killmail_time,
+		destroyed_value,
+		dropped_value,
+		total_value,
+		fitted_value,
+		victim_ship,
+		kill_ship
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (killmail_id, killmail_time) DO UPDATE SET
+		killmail_hash = EXCLUDED.killmail_hash,
+		destroyed_value = EXCLUDED.destroyed_value,
+		dropped_value = EXCLUDED.dropped_value,
+		total_value = EXCLUDED.total_value,
+		fitted_value = EXCLUDED.fitted_value,
+		ingested_at = NOW()
+		RETURNING (xmax = 0) AS inserted`
+	var inserted bool
+	err := db.QueryRowContext(ctx, query, k.KillmailID, k.KillmailHash, k.SolarSystemID, k.KillmailTime, k.DestroyedValue, k.DroppedValue, k.TotalValue, k.FittedValue, k.VictimShip, k.KillShip).Scan(&inserted)
+	if err != nil {
+		return false, fmt.Errorf("failed to upsert killmail %d: %w", k.KillmailID, err)
+	}
+	return inserted, nil
+}
+
+// killmailColumns are the killmails columns written by imports, in COPY order.
+var killmailColumns = []string{
+	"killmail_id",
+	"killmail_hash",
+	"solar_system_id",
+	"killmail_time",
+	"destroyed_value",
+	"dropped_value",
+	"total_value",
+	"fitted_value",
+	"victim_ship",
+	"kill_ship",
+}
+
+// CopyKillmails bulk loads killmails through COPY into a staging table, then inserts the ones
+// not already stored. Existing rows are left untouched. Returns the number of rows inserted.
+func CopyKillmails(ctx context.Context, kills []models.Killmails) (int64, error) {
+	ctx, db, done := startQuery(ctx, "CopyKillmails")
+	defer done()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin import transaction: %w", err)
+	}
+	defer tx.Rollback()
+	// Staging table without constraints, dropped with the transaction
+	if _, err := tx.ExecContext(ctx, "CREATE TEMP TABLE killmails_import (LIKE killmails INCLUDING DEFAULTS) ON COMMIT DROP"); err != nil {
+		return 0, fmt.Errorf("failed to create staging table: %w", err)
+	}
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("killmails_import", killmailColumns...))
+	if err != nil {
+		return 0, fmt.Errorf("failed to start COPY: %w", err)
+	}
+	for _, k := range kills {
+		if _, err := stmt.ExecContext(ctx, k.KillmailID, k.KillmailHash, k.SolarSystemID, k.KillmailTime, k.DestroyedValue, k.DroppedValue, k.TotalValue, k.FittedValue, k.VictimShip, k.KillShip); err != nil {
+			stmt.Close()
+			return 0, fmt.Errorf("failed to COPY killmail %d: %w", k.KillmailID, err)
+		}
+	}
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		stmt.Close()
+		return 0, fmt.Errorf("failed to finish COPY: %w", err)
+	}
+	if err := stmt.Close(); err != nil {
+		return 0, fmt.Errorf("failed to close COPY: %w", err)
+	}
+	columns := strings.Join(killmailColumns, ", ")
+	res, err := tx.ExecContext(ctx, fmt.Sprintf(`INSERT INTO killmails (%s)
+		SELECT %s FROM killmails_import
+		ON CONFLICT (killmail_id, killmail_time) DO NOTHING`, columns, columns))
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert imported killmails: %w", err)
+	}
+	inserted, _ := res.RowsAffected()
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit import: %w", err)
+	}
+	return inserted, nil
+}
+
+// GetLatestKillmailTime returns the killmail_time of the newest stored killmail, or the zero time if there are none.
+func GetLatestKillmailTime(ctx context.Context) (time.Time, error) {
+	ctx, db, done := startQuery(ctx, "GetLatestKillmailTime")
+	defer done()
+	var latest sql.NullTime
+	if err := db.QueryRowContext(ctx, "SELECT MAX(killmail_time) FROM killmails").Scan(&latest); err != nil {
+		return time.Time{}, fmt.Errorf("failed to query newest killmail time: %w", err)
+	}
+	return latest.Time, nil
+}
+
+// GetLatestIngestTime returns when the most recently stored killmail was written, or the zero time if there are none.
+// Unlike the newest killmail_time, this keeps moving during quiet hours and while old kills are backfilled.
+func GetLatestIngestTime(ctx context.Context) (time.Time, error) {
+	ctx, db, done := startQuery(ctx, "GetLatestIngestTime")
+	defer done()
+	var latest sql.NullTime
+	if err := db.QueryRowContext(ctx, "SELECT MAX(ingested_at) FROM killmails").Scan(&latest); err != nil {
+		return time.Time{}, fmt.Errorf("failed to query latest ingest time: %w", err)
+	}
+	return latest.Time, nil
+}
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS stations;
+DROP TABLE IF EXISTS planets;
+DROP TABLE IF EXISTS stargates;
+DROP TABLE IF EXISTS systems;
+DROP TABLE IF EXISTS constellations;
+DROP TABLE IF EXISTS regions;
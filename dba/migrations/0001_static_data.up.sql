@@ -0,0 +1,62 @@
+-- Static map data, loaded from the Static Data Export by cmd/sdeimport.
+-- IF NOT EXISTS lets databases created before migrations existed adopt this schema as is.
+
+CREATE TABLE IF NOT EXISTS regions (
+	region_id   INTEGER PRIMARY KEY,
+	region_name TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS constellations (
+	constellation_id   INTEGER PRIMARY KEY,
+	constellation_name TEXT NOT NULL,
+	region_id          INTEGER NOT NULL REFERENCES regions (region_id)
+);
+
+CREATE TABLE IF NOT EXISTS systems (
+	system_id        INTEGER PRIMARY KEY,
+	system_name      TEXT NOT NULL,
+	security_status  DOUBLE PRECISION NOT NULL DEFAULT 0,
+	security_class   TEXT,
+	x_pos            DOUBLE PRECISION NOT NULL DEFAULT 0,
+	y_pos            DOUBLE PRECISION NOT NULL DEFAULT 0,
+	z_pos            DOUBLE PRECISION NOT NULL DEFAULT 0,
+	constellation_id INTEGER NOT NULL REFERENCES constellations (constellation_id),
+	spectral_class   TEXT
+);
+
+CREATE TABLE IF NOT EXISTS stargates (
+	stargate_id             INTEGER PRIMARY KEY,
+	stargate_name           TEXT NOT NULL,
+	system_id               INTEGER NOT NULL REFERENCES systems (system_id),
+	destination_stargate_id INTEGER NOT NULL,
+	destination_system_id   INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS planets (
+	planet_id           INTEGER PRIMARY KEY,
+	planet_name         TEXT NOT NULL,
+	system_id           INTEGER NOT NULL REFERENCES systems (system_id),
+	type                TEXT,
+	moon_count          INTEGER NOT NULL DEFAULT 0,
+	asteroid_belt_count INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS stations (
+	station_id   INTEGER PRIMARY KEY,
+	station_name TEXT NOT NULL,
+	system_id    INTEGER NOT NULL REFERENCES systems (system_id)
+);
+
+-- Parent lookups used by the region/constellation/system endpoints
+CREATE INDEX IF NOT EXISTS constellations_region_id_idx ON constellations (region_id);
+CREATE INDEX IF NOT EXISTS systems_constellation_id_idx ON systems (constellation_id);
+CREATE INDEX IF NOT EXISTS stargates_system_id_idx ON stargates (system_id);
+CREATE INDEX IF NOT EXISTS planets_system_id_idx ON planets (system_id);
+CREATE INDEX IF NOT EXISTS stations_system_id_idx ON stations (system_id);
+
+-- Exact name lookups (?name=)
+CREATE INDEX IF NOT EXISTS regions_region_name_idx ON regions (region_name);
+CREATE INDEX IF NOT EXISTS constellations_constellation_name_idx ON constellations (constellation_name);
+CREATE INDEX IF NOT EXISTS systems_system_name_idx ON systems (system_name);
+CREATE INDEX IF NOT EXISTS planets_planet_name_idx ON planets (planet_name);
+CREATE INDEX IF NOT EXISTS stations_station_name_idx ON stations (station_name);
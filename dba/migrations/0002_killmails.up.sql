@@ -0,0 +1,21 @@
+-- Killmails, written by cmd/ingest. killmail_time is stored in UTC without a time zone.
+-- There is deliberately no foreign key to systems: the feed can reference systems before
+-- the static data has been refreshed.
+
+CREATE TABLE IF NOT EXISTS killmails (
+	killmail_id     BIGINT PRIMARY KEY,
+	killmail_hash   TEXT,
+	solar_system_id INTEGER,
+	killmail_time   TIMESTAMP NOT NULL,
+	destroyed_value DOUBLE PRECISION,
+	dropped_value   DOUBLE PRECISION,
+	total_value     DOUBLE PRECISION,
+	fitted_value    DOUBLE PRECISION,
+	victim_ship     BIGINT,
+	kill_ship       BIGINT
+);
+
+-- Per-system kill summaries, recent killmails and windowed scans of a scope
+CREATE INDEX IF NOT EXISTS killmails_solar_system_id_killmail_time_idx ON killmails (solar_system_id, killmail_time);
+-- Universe-wide sliding windows (rankings, top killmails, camp alerts)
+CREATE INDEX IF NOT EXISTS killmails_killmail_time_idx ON killmails (killmail_time);
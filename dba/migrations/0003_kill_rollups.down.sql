@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS kill_rollup_state;
+DROP TABLE IF EXISTS kill_rollups_daily;
+DROP TABLE IF EXISTS kill_rollups_hourly;
+DROP INDEX IF EXISTS killmails_ingested_at_idx;
+ALTER TABLE killmails DROP COLUMN IF EXISTS ingested_at;
@@ -0,0 +1,35 @@
+-- Hourly and daily per-system kill rollups, refreshed incrementally from killmails.
+-- ingested_at tells the refresh which killmails arrived (or were repriced) since its last run.
+
+ALTER TABLE killmails ADD COLUMN IF NOT EXISTS ingested_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+CREATE INDEX IF NOT EXISTS killmails_ingested_at_idx ON killmails (ingested_at);
+
+CREATE TABLE IF NOT EXISTS kill_rollups_hourly (
+	system_id       INTEGER NOT NULL,
+	bucket          TIMESTAMP NOT NULL, -- UTC hour
+	kills           INTEGER NOT NULL,
+	destroyed_value DOUBLE PRECISION NOT NULL,
+	dropped_value   DOUBLE PRECISION NOT NULL,
+	total_value     DOUBLE PRECISION NOT NULL,
+	PRIMARY KEY (system_id, bucket)
+);
+CREATE INDEX IF NOT EXISTS kill_rollups_hourly_bucket_idx ON kill_rollups_hourly (bucket);
+
+CREATE TABLE IF NOT EXISTS kill_rollups_daily (
+	system_id       INTEGER NOT NULL,
+	bucket          TIMESTAMP NOT NULL, -- UTC day
+	kills           INTEGER NOT NULL,
+	destroyed_value DOUBLE PRECISION NOT NULL,
+	dropped_value   DOUBLE PRECISION NOT NULL,
+	total_value     DOUBLE PRECISION NOT NULL,
+	PRIMARY KEY (system_id, bucket)
+);
+CREATE INDEX IF NOT EXISTS kill_rollups_daily_bucket_idx ON kill_rollups_daily (bucket);
+
+-- Single row holding the ingested_at watermark of the last refresh.
+-- Starting at -infinity makes the first refresh build the rollups from every killmail.
+CREATE TABLE IF NOT EXISTS kill_rollup_state (
+	id        BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+	watermark TIMESTAMPTZ NOT NULL
+);
+INSERT INTO kill_rollup_state (id, watermark) VALUES (TRUE, '-infinity') ON CONFLICT DO NOTHING;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS killmails_killmail_id_killmail_time_key;
@@ -0,0 +1,4 @@
+-- A partitioned killmails table can only enforce uniqueness on keys that include killmail_time,
+-- so writes resolve conflicts on (killmail_id, killmail_time). A killmail's time never changes,
+-- which keeps this as strict as the killmail_id primary key.
+CREATE UNIQUE INDEX IF NOT EXISTS killmails_killmail_id_killmail_time_key ON killmails (killmail_id, killmail_time);
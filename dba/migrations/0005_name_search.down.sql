@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS stations_station_name_trgm_idx;
+DROP INDEX IF EXISTS planets_planet_name_trgm_idx;
+DROP INDEX IF EXISTS systems_system_name_trgm_idx;
+DROP INDEX IF EXISTS constellations_constellation_name_trgm_idx;
+DROP INDEX IF EXISTS regions_region_name_trgm_idx;
+-- pg_trgm is left installed, other objects may depend on it
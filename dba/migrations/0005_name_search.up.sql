@@ -0,0 +1,10 @@
+-- Trigram indexes behind /search. They serve both the case-insensitive prefix match
+-- (LOWER(name) LIKE 'q%') and the fuzzy word similarity match (q <% LOWER(name)).
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS regions_region_name_trgm_idx ON regions USING GIN (LOWER(region_name) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS constellations_constellation_name_trgm_idx ON constellations USING GIN (LOWER(constellation_name) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS systems_system_name_trgm_idx ON systems USING GIN (LOWER(system_name) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS planets_planet_name_trgm_idx ON planets USING GIN (LOWER(planet_name) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS stations_station_name_trgm_idx ON stations USING GIN (LOWER(station_name) gin_trgm_ops);
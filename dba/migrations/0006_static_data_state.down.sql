@@ -0,0 +1 @@
+DROP TABLE IF EXISTS static_data_state;
@@ -0,0 +1,6 @@
+-- Single row recording when the static data last changed, used as Last-Modified by the API.
+CREATE TABLE IF NOT EXISTS static_data_state (
+	id         BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+	updated_at TIMESTAMPTZ NOT NULL
+);
+INSERT INTO static_data_state (id, updated_at) VALUES (TRUE, NOW()) ON CONFLICT DO NOTHING;
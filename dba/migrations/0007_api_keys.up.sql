@@ -0,0 +1,11 @@
+-- API keys issued through /admin/keys. Only a SHA-256 hash of each key is stored;
+-- prefix keeps the first characters so a key can be recognised in listings.
+CREATE TABLE IF NOT EXISTS api_keys (
+	api_key_id SERIAL PRIMARY KEY,
+	key_hash   TEXT NOT NULL UNIQUE,
+	prefix     TEXT NOT NULL,
+	name       TEXT NOT NULL,
+	tier       TEXT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	revoked_at TIMESTAMPTZ
+);
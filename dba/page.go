@@ -0,0 +1,91 @@
+package dba
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/astrocartics-xyz/Astrocartics-API/models"
+)
+
+// listOrder describes how a list query can be paged: the SQL expression each sortable JSON field
+// is ordered by, and the unique column that breaks ties and anchors the keyset cursor.
+type listOrder struct {
+	columns map[string]string
+	def     string // Field sorted by when the request names none
+	id      string
+}
+
+// pageQuery builds the query for one page of a list. Every row selects the columns in selectList
+// followed by its cursor key and ID, which scanPage reads back. Nullable sort columns have to be
+// wrapped in COALESCE, as the keyset comparison never matches NULLs.
+func (o listOrder) pageQuery(selectList string, from string, conditions []string, args []interface{}, page models.PageRequest) (string, []interface{}, error) {
+	field := page.Sort
+	if field == "" {
+		field = o.def
+	}
+	key, ok := o.columns[field]
+	if !ok {
+		return "", nil, fmt.Errorf("invalid sort field: %s", field)
+	}
+	dir, cmp := "ASC", ">"
+	if page.Desc {
+		dir, cmp = "DESC", "<"
+	}
+	// Appending must not write into the caller's slices, which still build the count query
+	conditions = conditions[:len(conditions):len(conditions)]
+	args = args[:len(args):len(args)]
+	if page.After != nil {
+		args = append(args, page.After.Key, page.After.ID)
+		conditions = append(conditions, fmt.Sprintf("(%s, %s) %s ($%d, $%d)", key, o.id, cmp, len(args)-1, len(args)))
+	}
+	query := fmt.Sprintf("SELECT %s, (%s)::text, %s FROM %s%s ORDER BY %s %s, %s %s",
+		selectList, key, o.id, from, whereClause(conditions), key, dir, o.id, dir)
+	if page.Limit > 0 {
+		// One row past the limit tells whether there is a next page
+		args = append(args, page.Limit+1)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	return query, args, nil
+}
+
+// whereClause joins conditions into a WHERE clause, or returns "" without conditions.
+func whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conditions, " AND ")
+}
+
+// countRows counts the rows of a list across all of its pages.
+func countRows(ctx context.Context, db *queryDB, from string, conditions []string, args []interface{}) (int, error) {
+	var total int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+from+whereClause(conditions), args...).Scan(&total); err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// scanPage reads the rows of a pageQuery, scanning each into a T with scan, which is handed the
+// destinations of the row's columns. Returns the rows of the page and the cursor of its last row
+// if there is a next page.
+func scanPage[T any](rows *queryRows, limit int, scan func(item *T) []interface{}) ([]T, *models.PageCursor, error) {
+	items := []T{}
+	var keys []models.PageCursor
+	for rows.Next() {
+		var item T
+		var key models.PageCursor
+		if err := rows.Scan(append(scan(&item), &key.Key, &key.ID)...); err != nil {
+			return nil, nil, err
+		}
+		items = append(items, item)
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	if limit <= 0 || len(items) <= limit {
+		return items, nil, nil
+	}
+	return items[:limit], &keys[limit-1], nil
+}
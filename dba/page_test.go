@@ -0,0 +1,62 @@
+package dba
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/astrocartics-xyz/Astrocartics-API/models"
+)
+
+var testOrder = listOrder{
+	columns: map[string]string{
+		"system_id":      "s.system_id",
+		"system_name":    "s.system_name",
+		"security_class": "COALESCE(s.security_class, '')",
+	},
+	def: "system_name",
+	id:  "s.system_id",
+}
+
+func TestPageQuery(t *testing.T) {
+	conditions := make([]string, 1, 4)
+	conditions[0] = "c.region_id = $1"
+	args := make([]interface{}, 1, 4)
+	args[0] = 10000002
+
+	query, queryArgs, err := testOrder.pageQuery("s.system_id, s.system_name", "systems s", conditions, args, models.PageRequest{
+		Limit: 50,
+		Sort:  "security_class",
+		Desc:  true,
+		After: &models.PageCursor{Key: "B", ID: 30000142},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "SELECT s.system_id, s.system_name, (COALESCE(s.security_class, ''))::text, s.system_id FROM systems s" +
+		" WHERE c.region_id = $1 AND (COALESCE(s.security_class, ''), s.system_id) < ($2, $3)" +
+		" ORDER BY COALESCE(s.security_class, '') DESC, s.system_id DESC LIMIT $4"
+	if query != want {
+		t.Errorf("query =\n%s\nwant\n%s", query, want)
+	}
+	if wantArgs := []interface{}{10000002, "B", 30000142, 51}; !reflect.DeepEqual(queryArgs, wantArgs) {
+		t.Errorf("args = %v, want %v", queryArgs, wantArgs)
+	}
+	// The count query still sees only the filter
+	if len(conditions) != 1 || len(args) != 1 || conditions[:2][1] != "" || args[:2][1] != nil {
+		t.Errorf("pageQuery wrote into the caller's slices: %v %v", conditions[:cap(conditions)], args[:cap(args)])
+	}
+}
+
+func TestPageQueryDefaults(t *testing.T) {
+	query, args, err := testOrder.pageQuery("s.system_id", "systems s", nil, nil, models.PageRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "SELECT s.system_id, (s.system_name)::text, s.system_id FROM systems s ORDER BY s.system_name ASC, s.system_id ASC"
+	if query != want || len(args) != 0 {
+		t.Errorf("query = %s %v, want %s", query, args, want)
+	}
+	if _, _, err := testOrder.pageQuery("s.system_id", "systems s", nil, nil, models.PageRequest{Sort: "x_pos"}); err == nil {
+		t.Error("expected an error for an unknown sort field")
+	}
+}
@@ -0,0 +1,317 @@
+package dba
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/astrocartics-xyz/Astrocartics-API/models"
+)
+
+// killmailsDefaultPartition catches killmails outside every monthly partition until maintenance moves them.
+const killmailsDefaultPartition = "killmails_default"
+
+// partitionNamePattern matches the monthly partitions, named killmails_YYYY_MM.
+var partitionNamePattern = regexp.MustCompile(`^killmails_(\d{4})_(\d{2})$`)
+
+// KillmailPartition is one monthly partition of killmails, covering [From, To).
+type KillmailPartition struct {
+	Name string
+	From time.Time
+	To   time.Time
+}
+
+// partitionName returns the name of the partition holding the month of t.
+func partitionName(month time.Time) string {
+	return fmt.Sprintf("killmails_%04d_%02d", month.Year(), int(month.Month()))
+}
+
+// monthStart truncates t to the first instant of its UTC month.
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// partitionBound formats a month boundary as a literal for FOR VALUES clauses.
+func partitionBound(t time.Time) string {
+	return "'" + t.Format("2006-01-02 15:04:05") + "'"
+}
+
+// IsKillmailsPartitioned reports whether killmails is a partitioned table.
+func IsKillmailsPartitioned(ctx context.Context) (bool, error) {
+	ctx, db, done := startQuery(ctx, "IsKillmailsPartitioned")
+	defer done()
+	var kind string
+	err := db.QueryRowContext(ctx, "SELECT relkind::text FROM pg_class WHERE oid = 'killmails'::regclass").Scan(&kind)
+	if err != nil {
+		return false, fmt.Errorf("failed to inspect killmails table: %w", err)
+	}
+	return kind == "p", nil
+}
+
+// PartitionKillmails converts killmails into a table range partitioned by month on killmail_time.
+// Existing rows are copied into a partition per month they cover, plus a default partition for
+// rows that later fall outside every partition. The table is locked for the whole conversion.
+func PartitionKillmails(ctx context.Context) error {
+	ctx, db, done := startQuery(ctx, "PartitionKillmails")
+	defer done()
+	partitioned, err := IsKillmailsPartitioned(ctx)
+	if err != nil {
+		return err
+	}
+	if partitioned {
+		return fmt.Errorf("killmails is already partitioned")
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin partitioning: %w", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, "LOCK TABLE killmails IN ACCESS EXCLUSIVE MODE"); err != nil {
+		return fmt.Errorf("failed to lock killmails: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "ALTER TABLE killmails RENAME TO killmails_unpartitioned"); err != nil {
+		return fmt.Errorf("failed to rename killmails: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, `CREATE TABLE killmails (LIKE killmails_unpartitioned INCLUDING DEFAULTS)
+		PARTITION BY RANGE (killmail_time)`)
+	if err != nil {
+		return fmt.Errorf("failed to create partitioned killmails: %w", err)
+	}
+	// One partition per month that already has killmails
+	var first, last *time.Time
+	if err := tx.QueryRowContext(ctx, "SELECT MIN(killmail_time), MAX(killmail_time) FROM killmails_unpartitioned").Scan(&first, &last); err != nil {
+		return fmt.Errorf("failed to read killmail time range: %w", err)
+	}
+	if first != nil {
+		for month := monthStart(*first); !month.After(*last); month = month.AddDate(0, 1, 0) {
+			_, err := tx.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s PARTITION OF killmails FOR VALUES FROM (%s) TO (%s)",
+				partitionName(month), partitionBound(month), partitionBound(month.AddDate(0, 1, 0))))
+			if err != nil {
+				return fmt.Errorf("failed to create partition %s: %w", partitionName(month), err)
+			}
+		}
+	}
+	if _, err := tx.ExecContext(ctx, "CREATE TABLE " + killmailsDefaultPartition + " PARTITION OF killmails DEFAULT"); err != nil {
+		return fmt.Errorf("failed to create default partition: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO killmails SELECT * FROM killmails_unpartitioned"); err != nil {
+		return fmt.Errorf("failed to copy killmails into partitions: %w", err)
+	}
+	// Dropping the old table frees its index names for the partitioned ones
+	if _, err := tx.ExecContext(ctx, "DROP TABLE killmails_unpartitioned"); err != nil {
+		return fmt.Errorf("failed to drop unpartitioned killmails: %w", err)
+	}
+	indexes := []string{
+		"ALTER TABLE killmails ADD CONSTRAINT killmails_pkey PRIMARY KEY (killmail_id, killmail_time)",
+		"CREATE INDEX killmails_solar_system_id_killmail_time_idx ON killmails (solar_system_id, killmail_time)",
+		"CREATE INDEX killmails_killmail_time_idx ON killmails (killmail_time)",
+		"CREATE INDEX killmails_ingested_at_idx ON killmails (ingested_at)",
+	}
+	for _, stmt := range indexes {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to index partitioned killmails: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit partitioning: %w", err)
+	}
+	return nil
+}
+
+// GetKillmailPartitions lists the monthly partitions attached to killmails, oldest first.
+func GetKillmailPartitions(ctx context.Context) ([]KillmailPartition, error) {
+	ctx, db, done := startQuery(ctx, "GetKillmailPartitions")
+	defer done()
+	rows, err := db.QueryContext(ctx, `SELECT c.relname
+		FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		WHERE i.inhparent = 'killmails'::regclass`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query killmail partitions: %w", err)
+	}
+	defer rows.Close()
+	var partitions []KillmailPartition
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan killmail partition: %w", err)
+		}
+		m := partitionNamePattern.FindStringSubmatch(name)
+		if m == nil {
+			continue
+		}
+		from, err := time.Parse("2006-01", m[1]+"-"+m[2])
+		if err != nil {
+			continue
+		}
+		partitions = append(partitions, KillmailPartition{Name: name, From: from, To: from.AddDate(0, 1, 0)})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	sort.Slice(partitions, func(i, j int) bool { return partitions[i].From.Before(partitions[j].From) })
+	return partitions, nil
+}
+
+// EnsureKillmailPartitions creates the partitions for the current month and the given number
+// of months ahead, plus one for every month that has rows parked in the default partition.
+// Returns the names of the partitions it created.
+func EnsureKillmailPartitions(ctx context.Context, ahead int) ([]string, error) {
+	ctx, db, done := startQuery(ctx, "EnsureKillmailPartitions")
+	defer done()
+	existing, err := GetKillmailPartitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	have := map[string]bool{}
+	for _, p := range existing {
+		have[p.Name] = true
+	}
+	var months []time.Time
+	now := monthStart(time.Now())
+	for i := 0; i <= ahead; i++ {
+		months = append(months, now.AddDate(0, i, 0))
+	}
+	// Months that only the default partition covers so far
+	rows, err := db.QueryContext(ctx, "SELECT DISTINCT DATE_TRUNC('month', killmail_time) FROM " + killmailsDefaultPartition)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan default partition: %w", err)
+	}
+	for rows.Next() {
+		var month time.Time
+		if err := rows.Scan(&month); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan default partition month: %w", err)
+		}
+		months = append(months, monthStart(month))
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	var created []string
+	for _, month := range months {
+		name := partitionName(month)
+		if have[name] {
+			continue
+		}
+		if err := createKillmailPartition(ctx, db, month); err != nil {
+			return created, err
+		}
+		have[name] = true
+		created = append(created, name)
+	}
+	return created, nil
+}
+
+// createKillmailPartition attaches the partition for a month, moving any of its rows out of the
+// default partition first, as Postgres refuses to add a partition the default already has rows for.
+func createKillmailPartition(ctx context.Context, db *queryDB, month time.Time) error {
+	name := partitionName(month)
+	from, to := partitionBound(month), partitionBound(month.AddDate(0, 1, 0))
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin creating partition %s: %w", name, err)
+	}
+	defer tx.Rollback()
+	_, err = tx.ExecContext(ctx, fmt.Sprintf(`CREATE TEMP TABLE killmails_moving ON COMMIT DROP AS
+		WITH moved AS (
+			DELETE FROM %s WHERE killmail_time >= %s AND killmail_time < %s RETURNING *
+		) SELECT * FROM moved`, killmailsDefaultPartition, from, to))
+	if err != nil {
+		return fmt.Errorf("failed to move rows out of the default partition: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s PARTITION OF killmails FOR VALUES FROM (%s) TO (%s)", name, from, to)); err != nil {
+		return fmt.Errorf("failed to create partition %s: %w", name, err)
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO killmails SELECT * FROM killmails_moving"); err != nil {
+		return fmt.Errorf("failed to move rows into partition %s: %w", name, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit partition %s: %w", name, err)
+	}
+	return nil
+}
+
+// DetachKillmailPartition detaches a partition, keeping its rows as a standalone table.
+func DetachKillmailPartition(ctx context.Context, name string) error {
+	ctx, db, done := startQuery(ctx, "DetachKillmailPartition")
+	defer done()
+	if !partitionNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid partition name: %s", name)
+	}
+	if _, err := db.ExecContext(ctx, "ALTER TABLE killmails DETACH PARTITION " + name); err != nil {
+		return fmt.Errorf("failed to detach partition %s: %w", name, err)
+	}
+	return nil
+}
+
+// DropKillmailPartition removes a partition and its rows.
+func DropKillmailPartition(ctx context.Context, name string) error {
+	ctx, db, done := startQuery(ctx, "DropKillmailPartition")
+	defer done()
+	if !partitionNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid partition name: %s", name)
+	}
+	if _, err := db.ExecContext(ctx, "DROP TABLE " + name); err != nil {
+		return fmt.Errorf("failed to drop partition %s: %w", name, err)
+	}
+	return nil
+}
+
+// ExportKillmailPartition streams every row of a partition to fn, in killmail_time order.
+func ExportKillmailPartition(ctx context.Context, name string, fn func(models.Killmails) error) (int64, error) {
+	ctx, db, done := startQuery(ctx, "ExportKillmailPartition")
+	defer done()
+	if !partitionNamePattern.MatchString(name) {
+		return 0, fmt.Errorf("invalid partition name: %s", name)
+	}
+	rows, err := db.QueryContext(ctx, `SELECT
+		killmail_id,
+		COALESCE(killmail_hash, '') AS killmail_hash,
+		COALESCE(solar_system_id, 0) AS solar_system_id,
+		killmail_time,
+		COALESCE(destroyed_value, 0) AS destroyed_value,
+		COALESCE(dropped_value, 0) AS dropped_value,
+		COALESCE(total_value, 0) AS total_value,
+		COALESCE(fitted_value, 0) AS fitted_value,
+		COALESCE(victim_ship, 0) AS victim_ship,
+		COALESCE(kill_ship, 0) AS kill_ship
+		FROM ` + name + `
+		ORDER BY killmail_time, killmail_id`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query partition %s: %w", name, err)
+	}
+	defer rows.Close()
+	var n int64
+	for rows.Next() {
+		var k models.Killmails
+		if err := rows.Scan(&k.KillmailID, &k.KillmailHash, &k.SolarSystemID, &k.KillmailTime, &k.DestroyedValue, &k.DroppedValue, &k.TotalValue, &k.FittedValue, &k.VictimShip, &k.KillShip); err != nil {
+			return n, fmt.Errorf("failed to scan killmail row: %w", err)
+		}
+		if err := fn(k); err != nil {
+			return n, err
+		}
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		return n, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return n, nil
+}
+
+// DeleteUnpartitionedKillmailsBefore removes rows older than cutoff from the default partition,
+// so late killmails for months already past retention do not resurrect their partitions.
+func DeleteUnpartitionedKillmailsBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	ctx, db, done := startQuery(ctx, "DeleteUnpartitionedKillmailsBefore")
+	defer done()
+	res, err := db.ExecContext(ctx, "DELETE FROM "+killmailsDefaultPartition+" WHERE killmail_time < $1", cutoff.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge default partition: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n, nil
+}
@@ -0,0 +1,176 @@
+package dba
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/astrocartics-xyz/Astrocartics-API/metrics"
+	"github.com/astrocartics-xyz/Astrocartics-API/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// queryDB runs the statements of one dba function inside a span named after it,
+// counting the rows they return or affect.
+type queryDB struct {
+	*sql.DB
+	ctx  context.Context
+	span trace.Span
+	rows int64
+}
+
+// startQuery starts the span and timer for the dba function name. Statements must go through
+// the returned queryDB, and the returned function must be called once the function is done.
+func startQuery(ctx context.Context, name string) (context.Context, *queryDB, func()) {
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, "dba."+name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("db.system.name", "postgresql"),
+		attribute.String("db.operation.name", name),
+	))
+	q := &queryDB{DB: GetDB(), ctx: ctx, span: span}
+	return ctx, q, func() {
+		metrics.ObserveQuery(name, start)
+		span.SetAttributes(attribute.Int64("db.response.returned_rows", q.rows))
+		span.End()
+	}
+}
+
+// fail records err on the span. When the context is done, err is wrapped with the context's
+// error, so callers can tell a cancelled or timed out query apart with errors.Is.
+func (q *queryDB) fail(err error) error {
+	if err == nil {
+		return nil
+	}
+	if ctxErr := q.ctx.Err(); ctxErr != nil && !errors.Is(err, ctxErr) {
+		err = fmt.Errorf("%w: %w", ctxErr, err)
+	}
+	tracing.RecordError(q.span, err)
+	return err
+}
+
+func (q *queryDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*queryRows, error) {
+	rows, err := q.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, q.fail(err)
+	}
+	return &queryRows{Rows: rows, q: q}, nil
+}
+
+func (q *queryDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *queryRow {
+	return &queryRow{Row: q.DB.QueryRowContext(ctx, query, args...), q: q}
+}
+
+func (q *queryDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	res, err := q.DB.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, q.fail(err)
+	}
+	if n, err := res.RowsAffected(); err == nil {
+		q.rows += n
+	}
+	return res, nil
+}
+
+func (q *queryDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*queryTx, error) {
+	tx, err := q.DB.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, q.fail(err)
+	}
+	return &queryTx{Tx: tx, q: q}, nil
+}
+
+// queryTx counts the rows of the statements run in the transaction, and records their errors
+// on the span of the dba function like queryDB.
+type queryTx struct {
+	*sql.Tx
+	q *queryDB
+}
+
+func (t *queryTx) QueryContext(ctx context.Context, query string, args ...interface{}) (*queryRows, error) {
+	rows, err := t.Tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, t.q.fail(err)
+	}
+	return &queryRows{Rows: rows, q: t.q}, nil
+}
+
+func (t *queryTx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *queryRow {
+	return &queryRow{Row: t.Tx.QueryRowContext(ctx, query, args...), q: t.q}
+}
+
+func (t *queryTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	res, err := t.Tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, t.q.fail(err)
+	}
+	if n, err := res.RowsAffected(); err == nil {
+		t.q.rows += n
+	}
+	return res, nil
+}
+
+func (t *queryTx) PrepareContext(ctx context.Context, query string) (*queryStmt, error) {
+	stmt, err := t.Tx.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, t.q.fail(err)
+	}
+	return &queryStmt{Stmt: stmt, q: t.q}, nil
+}
+
+func (t *queryTx) Commit() error {
+	return t.q.fail(t.Tx.Commit())
+}
+
+// queryStmt records the errors of a prepared statement, such as a COPY, on the span. Its rows
+// are counted by the statement that moves them out of the staging table.
+type queryStmt struct {
+	*sql.Stmt
+	q *queryDB
+}
+
+func (s *queryStmt) ExecContext(ctx context.Context, args ...interface{}) (sql.Result, error) {
+	res, err := s.Stmt.ExecContext(ctx, args...)
+	return res, s.q.fail(err)
+}
+
+func (s *queryStmt) Close() error {
+	return s.q.fail(s.Stmt.Close())
+}
+
+// queryRows counts the rows read through it.
+type queryRows struct {
+	*sql.Rows
+	q *queryDB
+}
+
+func (r *queryRows) Next() bool {
+	if r.Rows.Next() {
+		r.q.rows++
+		return true
+	}
+	return false
+}
+
+func (r *queryRows) Err() error {
+	return r.q.fail(r.Rows.Err())
+}
+
+// queryRow counts its row once it is scanned.
+type queryRow struct {
+	*sql.Row
+	q *queryDB
+}
+
+func (r *queryRow) Scan(dest ...interface{}) error {
+	err := r.Row.Scan(dest...)
+	switch {
+		case err == nil:
+			r.q.rows++
+		case !errors.Is(err, sql.ErrNoRows):
+			err = r.q.fail(err)
+	}
+	return err
+}
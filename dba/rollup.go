@@ -0,0 +1,149 @@
+package dba
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// rollupsEnabled switches the kill summaries and rankings over to the rollup tables.
+var rollupsEnabled atomic.Bool
+
+// SetKillRollups enables or disables reading kill aggregates from the rollup tables.
+// Only enable it while something keeps the rollups refreshed.
+func SetKillRollups(enabled bool) {
+	rollupsEnabled.Store(enabled)
+}
+
+// rollupTableForMode returns the rollup table fine enough to bucket by the given DATE_TRUNC unit
+// whose buckets line up with the from and to bounds, or "" if the raw killmails have to be used.
+// A bound inside a bucket would drop or add part of that bucket, so a range not aligned to whole
+// days falls back to the hourly rollups, and one not aligned to whole hours to the raw killmails.
+func rollupTableForMode(mode string, from time.Time, to time.Time) string {
+	if !rollupsEnabled.Load() {
+		return ""
+	}
+	switch mode {
+		case "hour":
+			if alignedTo(time.Hour, from, to) {
+				return "kill_rollups_hourly"
+			}
+		case "day", "week", "month":
+			if alignedTo(24*time.Hour, from, to) {
+				return "kill_rollups_daily"
+			}
+			if alignedTo(time.Hour, from, to) {
+				return "kill_rollups_hourly"
+			}
+	}
+	return ""
+}
+
+// alignedTo reports whether every non-zero bound falls on a UTC bucket boundary of size d.
+func alignedTo(d time.Duration, bounds ...time.Time) bool {
+	for _, t := range bounds {
+		if !t.IsZero() && !t.UTC().Truncate(d).Equal(t) {
+			return false
+		}
+	}
+	return true
+}
+
+// rollupTableForWindow returns the rollup table to answer a sliding window of the given mode with,
+// or "" if the raw killmails have to be used. Windows are answered from whole hours, so only windows
+// of a week or more use the rollups, where being up to an hour early is noise.
+func rollupTableForWindow(mode string) string {
+	if !rollupsEnabled.Load() {
+		return ""
+	}
+	switch mode {
+		case "week", "month":
+			return "kill_rollups_hourly"
+		default:
+			return ""
+	}
+}
+
+// rollupOverlap re-reads killmails ingested shortly before the watermark, covering rows
+// whose inserting transaction committed after the previous refresh had started.
+const rollupOverlap = "5 minutes"
+
+// RefreshKillRollups recomputes every hourly and daily bucket that received killmails since the
+// last refresh. Buckets are rebuilt from scratch, so running it repeatedly is safe.
+// Returns the number of hourly buckets rebuilt.
+func RefreshKillRollups(ctx context.Context) (int64, error) {
+	ctx, db, done := startQuery(ctx, "RefreshKillRollups")
+	defer done()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin rollup refresh: %w", err)
+	}
+	defer tx.Rollback()
+	// Lock the state row so concurrent workers take turns
+	var watermark string
+	if err := tx.QueryRowContext(ctx, "SELECT watermark::text FROM kill_rollup_state WHERE id FOR UPDATE").Scan(&watermark); err != nil {
+		return 0, fmt.Errorf("failed to read rollup watermark: %w", err)
+	}
+	// Hours touched by new or updated killmails
+	_, err = tx.ExecContext(ctx, `CREATE TEMP TABLE touched_hours ON COMMIT DROP AS
+		SELECT DISTINCT solar_system_id AS system_id, DATE_TRUNC('hour', killmail_time) AS bucket
+		FROM killmails
+		WHERE ingested_at > $1::timestamptz - $2::interval
+		AND solar_system_id IS NOT NULL`, watermark, rollupOverlap)
+	if err != nil {
+		return 0, fmt.Errorf("failed to collect touched hours: %w", err)
+	}
+	// Rebuild the touched hours from the raw killmails
+	if _, err := tx.ExecContext(ctx, `DELETE FROM kill_rollups_hourly h USING touched_hours t
+		WHERE h.system_id = t.system_id AND h.bucket = t.bucket`); err != nil {
+		return 0, fmt.Errorf("failed to clear hourly rollups: %w", err)
+	}
+	res, err := tx.ExecContext(ctx, `INSERT INTO kill_rollups_hourly (system_id, bucket, kills, destroyed_value, dropped_value, total_value)
+		SELECT t.system_id, t.bucket,
+		COUNT(*),
+		COALESCE(SUM(k.destroyed_value), 0),
+		COALESCE(SUM(k.dropped_value), 0),
+		COALESCE(SUM(k.total_value), 0)
+		FROM touched_hours t
+		JOIN killmails k ON k.solar_system_id = t.system_id
+		AND k.killmail_time >= t.bucket
+		AND k.killmail_time < t.bucket + INTERVAL '1 hour'
+		GROUP BY t.system_id, t.bucket`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to rebuild hourly rollups: %w", err)
+	}
+	rebuilt, _ := res.RowsAffected()
+	// Rebuild the days containing those hours from the hourly rollups
+	_, err = tx.ExecContext(ctx, `CREATE TEMP TABLE touched_days ON COMMIT DROP AS
+		SELECT DISTINCT system_id, DATE_TRUNC('day', bucket) AS bucket FROM touched_hours`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to collect touched days: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM kill_rollups_daily d USING touched_days t
+		WHERE d.system_id = t.system_id AND d.bucket = t.bucket`); err != nil {
+		return 0, fmt.Errorf("failed to clear daily rollups: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO kill_rollups_daily (system_id, bucket, kills, destroyed_value, dropped_value, total_value)
+		SELECT t.system_id, t.bucket,
+		SUM(h.kills),
+		SUM(h.destroyed_value),
+		SUM(h.dropped_value),
+		SUM(h.total_value)
+		FROM touched_days t
+		JOIN kill_rollups_hourly h ON h.system_id = t.system_id
+		AND h.bucket >= t.bucket
+		AND h.bucket < t.bucket + INTERVAL '1 day'
+		GROUP BY t.system_id, t.bucket`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to rebuild daily rollups: %w", err)
+	}
+	// NOW() is the transaction start, so nothing ingested after it is skipped next time
+	if _, err := tx.ExecContext(ctx, "UPDATE kill_rollup_state SET watermark = NOW() WHERE id"); err != nil {
+		return 0, fmt.Errorf("failed to advance rollup watermark: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit rollup refresh: %w", err)
+	}
+	return rebuilt, nil
+}
@@ -0,0 +1,42 @@
+package dba
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRollupTableForMode(t *testing.T) {
+	defer SetKillRollups(rollupsEnabled.Load())
+	SetKillRollups(true)
+
+	day := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	hour := day.Add(12 * time.Hour)
+	minute := hour.Add(30 * time.Minute)
+	tests := []struct {
+		mode     string
+		from, to time.Time
+		want     string
+	}{
+		{"hour", time.Time{}, time.Time{}, "kill_rollups_hourly"},
+		{"hour", hour, time.Time{}, "kill_rollups_hourly"},
+		{"hour", minute, time.Time{}, ""},
+		{"day", time.Time{}, time.Time{}, "kill_rollups_daily"},
+		{"day", day, day.Add(48 * time.Hour), "kill_rollups_daily"},
+		{"week", hour, time.Time{}, "kill_rollups_hourly"},
+		{"month", day, hour, "kill_rollups_hourly"},
+		{"day", minute, day.Add(24 * time.Hour), ""},
+		{"day", day, minute, ""},
+		// Midnight in another time zone is not a UTC day boundary
+		{"day", time.Date(2026, 10, 18, 0, 0, 0, 0, time.FixedZone("CEST", 2*60*60)), time.Time{}, "kill_rollups_hourly"},
+	}
+	for _, tt := range tests {
+		if got := rollupTableForMode(tt.mode, tt.from, tt.to); got != tt.want {
+			t.Errorf("rollupTableForMode(%q, %v, %v) = %q, want %q", tt.mode, tt.from, tt.to, got, tt.want)
+		}
+	}
+
+	SetKillRollups(false)
+	if got := rollupTableForMode("day", time.Time{}, time.Time{}); got != "" {
+		t.Errorf("rollups disabled, got %q", got)
+	}
+}
@@ -0,0 +1,101 @@
+package dba
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// StaticTable is the full new content of one static data table. The first column is the primary key.
+type StaticTable struct {
+	Name    string
+	Columns []string
+	Rows    [][]interface{}
+}
+
+// ReplaceStaticData loads new static data in a single transaction: every table is upserted in the
+// given order (parents first) and, when prune is set, rows missing from the new data are deleted
+// in reverse order. Either all tables are replaced or none are.
+func ReplaceStaticData(ctx context.Context, tables []StaticTable, prune bool) error {
+	ctx, db, done := startQuery(ctx, "ReplaceStaticData")
+	defer done()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin static data transaction: %w", err)
+	}
+	defer tx.Rollback()
+	for _, t := range tables {
+		if err := upsertStaticTable(ctx, tx, t); err != nil {
+			return err
+		}
+	}
+	if prune {
+		for i := len(tables) - 1; i >= 0; i-- {
+			t := tables[i]
+			key := t.Columns[0]
+			query := fmt.Sprintf("DELETE FROM %s WHERE %s NOT IN (SELECT %s FROM %s_import)", t.Name, key, key, t.Name)
+			if _, err := tx.ExecContext(ctx, query); err != nil {
+				return fmt.Errorf("failed to prune %s: %w", t.Name, err)
+			}
+		}
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE static_data_state SET updated_at = NOW() WHERE id"); err != nil {
+		return fmt.Errorf("failed to record static data update: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit static data: %w", err)
+	}
+	return nil
+}
+
+// GetStaticDataUpdatedAt returns when the static data last changed.
+func GetStaticDataUpdatedAt(ctx context.Context) (time.Time, error) {
+	ctx, db, done := startQuery(ctx, "GetStaticDataUpdatedAt")
+	defer done()
+	var updatedAt time.Time
+	if err := db.QueryRowContext(ctx, "SELECT updated_at FROM static_data_state WHERE id").Scan(&updatedAt); err != nil {
+		return time.Time{}, fmt.Errorf("failed to read static data update time: %w", err)
+	}
+	return updatedAt, nil
+}
+
+// upsertStaticTable copies a table's rows into a staging table and merges them into the real one.
+func upsertStaticTable(ctx context.Context, tx *queryTx, t StaticTable) error {
+	staging := t.Name + "_import"
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("CREATE TEMP TABLE %s (LIKE %s INCLUDING DEFAULTS) ON COMMIT DROP", staging, t.Name)); err != nil {
+		return fmt.Errorf("failed to create staging table for %s: %w", t.Name, err)
+	}
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn(staging, t.Columns...))
+	if err != nil {
+		return fmt.Errorf("failed to start COPY into %s: %w", staging, err)
+	}
+	for _, row := range t.Rows {
+		if _, err := stmt.ExecContext(ctx, row...); err != nil {
+			stmt.Close()
+			return fmt.Errorf("failed to COPY %s row %v: %w", t.Name, row[0], err)
+		}
+	}
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		stmt.Close()
+		return fmt.Errorf("failed to finish COPY into %s: %w", staging, err)
+	}
+	if err := stmt.Close(); err != nil {
+		return fmt.Errorf("failed to close COPY into %s: %w", staging, err)
+	}
+	// Update every column but the key
+	columns := strings.Join(t.Columns, ", ")
+	updates := make([]string, 0, len(t.Columns)-1)
+	for _, c := range t.Columns[1:] {
+		updates = append(updates, fmt.Sprintf("%s = EXCLUDED.%s", c, c))
+	}
+	query := fmt.Sprintf(`INSERT INTO %s (%s)
+		SELECT %s FROM %s
+		ON CONFLICT (%s) DO UPDATE SET %s`, t.Name, columns, columns, staging, t.Columns[0], strings.Join(updates, ", "))
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to upsert %s: %w", t.Name, err)
+	}
+	return nil
+}
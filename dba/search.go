@@ -0,0 +1,74 @@
+package dba
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/astrocartics-xyz/Astrocartics-API/models"
+)
+
+// searchTables maps each searchable type to its table, ID and name columns.
+var searchTables = map[string][3]string{
+	"region":        {"regions", "region_id", "region_name"},
+	"constellation": {"constellations", "constellation_id", "constellation_name"},
+	"system":        {"systems", "system_id", "system_name"},
+	"planet":        {"planets", "planet_id", "planet_name"},
+	"station":       {"stations", "station_id", "station_name"},
+}
+
+// SearchTypes lists the types SearchNames accepts, in their default order.
+var SearchTypes = []string{"region", "constellation", "system", "planet", "station"}
+
+// escapeLike escapes the LIKE wildcards in s.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// SearchNames matches q case-insensitively against the names of the given types. Exact matches
+// score 1, prefix matches 0.6-1 and fuzzy (trigram word similarity) matches below 0.6.
+// Returns the best limit results, ties broken by shorter names first.
+func SearchNames(ctx context.Context, q string, types []string, limit int) ([]models.SearchResult, error) {
+	ctx, db, done := startQuery(ctx, "SearchNames")
+	defer done()
+	var parts []string
+	for _, t := range types {
+		table, ok := searchTables[t]
+		if !ok {
+			return nil, fmt.Errorf("invalid search type: %s", t)
+		}
+		name := "LOWER(" + table[2] + ")"
+		parts = append(parts, fmt.Sprintf(`SELECT '%s' AS type, %s AS id, %s AS name,
+			CASE
+				WHEN %s = $1 THEN 1
+				WHEN %s LIKE $2 THEN 0.6 + 0.4 * similarity($1, %s)
+				ELSE 0.6 * word_similarity($1, %s)
+			END AS score
+			FROM %s
+			WHERE %s LIKE $2 OR $1 <%% %s`, t, table[1], table[2], name, name, name, name, table[0], name, name))
+	}
+	if len(parts) == 0 {
+		return []models.SearchResult{}, nil
+	}
+	// ORDER BY of a UNION only accepts output column names, so order the union as a subquery
+	query := "SELECT type, id, name, score FROM (\n" + strings.Join(parts, "\nUNION ALL\n") +
+		"\n) u\nORDER BY u.score DESC, LENGTH(u.name), u.name\nLIMIT $3"
+	lower := strings.ToLower(q)
+	rows, err := db.QueryContext(ctx, query, lower, escapeLike(lower)+"%", limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search names: %w", err)
+	}
+	defer rows.Close()
+	results := []models.SearchResult{}
+	for rows.Next() {
+		var r models.SearchResult
+		if err := rows.Scan(&r.Type, &r.ID, &r.Name, &r.Score); err != nil {
+			return nil, fmt.Errorf("failed to scan search result: %w", err)
+		}
+		results = append(results, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return results, nil
+}
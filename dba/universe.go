@@ -0,0 +1,71 @@
+package dba
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/astrocartics-xyz/Astrocartics-API/models"
+	"github.com/lib/pq"
+)
+
+// universeTables lists each category with its table, ID and name columns.
+var universeTables = [][4]string{
+	{"region", "regions", "region_id", "region_name"},
+	{"constellation", "constellations", "constellation_id", "constellation_name"},
+	{"system", "systems", "system_id", "system_name"},
+	{"planet", "planets", "planet_id", "planet_name"},
+	{"station", "stations", "station_id", "station_name"},
+	{"stargate", "stargates", "stargate_id", "stargate_name"},
+}
+
+// queryUniverseNames runs one SELECT per category, each filtered by the given condition on
+// its ID column (%[1]s) or name column (%[2]s), and collects the rows.
+func queryUniverseNames(ctx context.Context, db *queryDB, condition string, arg interface{}) ([]models.UniverseName, error) {
+	parts := make([]string, 0, len(universeTables))
+	for _, t := range universeTables {
+		parts = append(parts, fmt.Sprintf("SELECT %s AS id, '%s' AS category, %s AS name FROM %s WHERE ",
+			t[2], t[0], t[3], t[1])+fmt.Sprintf(condition, t[2], t[3]))
+	}
+	rows, err := db.QueryContext(ctx, strings.Join(parts, "\nUNION ALL\n"), arg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve universe names: %w", err)
+	}
+	defer rows.Close()
+	names := []models.UniverseName{}
+	for rows.Next() {
+		var n models.UniverseName
+		if err := rows.Scan(&n.ID, &n.Category, &n.Name); err != nil {
+			return nil, fmt.Errorf("failed to scan universe name: %w", err)
+		}
+		names = append(names, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return names, nil
+}
+
+// GetUniverseNames resolves IDs of any static category to their category and name.
+// IDs that match nothing are left out.
+func GetUniverseNames(ctx context.Context, ids []int) ([]models.UniverseName, error) {
+	ctx, db, done := startQuery(ctx, "GetUniverseNames")
+	defer done()
+	ids64 := make([]int64, len(ids))
+	for i, id := range ids {
+		ids64[i] = int64(id)
+	}
+	return queryUniverseNames(ctx, db, "%[1]s = ANY($1)", pq.Array(ids64))
+}
+
+// GetUniverseIDs resolves exact names, ignoring case, to the IDs and categories carrying them.
+// A name shared by several entities resolves to all of them; names that match nothing are left out.
+func GetUniverseIDs(ctx context.Context, names []string) ([]models.UniverseName, error) {
+	ctx, db, done := startQuery(ctx, "GetUniverseIDs")
+	defer done()
+	lower := make([]string, len(names))
+	for i, n := range names {
+		lower[i] = strings.ToLower(n)
+	}
+	return queryUniverseNames(ctx, db, "LOWER(%[2]s) = ANY($1)", pq.Array(lower))
+}
@@ -15,9 +15,14 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/constellations": {
+        "/admin/keys": {
             "get": {
-                "description": "Get all constellations, or search for a constellation by name",
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "List every issued API key, without the keys themselves",
                 "consumes": [
                     "application/json"
                 ],
@@ -25,33 +30,28 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "constellations"
-                ],
-                "summary": "Get constellations",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Constellation name to search for",
-                        "name": "name",
-                        "in": "query"
-                    }
+                    "admin"
                 ],
+                "summary": "List API keys",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.Constellation"
+                                "$ref": "#/definitions/models.APIKey"
                             }
                         }
                     }
                 }
-            }
-        },
-        "/constellations/{constellationID}": {
-            "get": {
-                "description": "Get a single constellation by its unique ID",
+            },
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Issue an API key for a usage tier. The key is only returned by this call",
                 "consumes": [
                     "application/json"
                 ],
@@ -59,34 +59,38 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "constellations"
+                    "admin"
                 ],
-                "summary": "Get a constellation by ID",
+                "summary": "Issue an API key",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Constellation ID",
-                        "name": "constellationID",
-                        "in": "path",
-                        "required": true
+                        "description": "Key name and tier (free, pro, internal)",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.NewAPIKeyRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Constellation"
-                            }
+                            "$ref": "#/definitions/models.NewAPIKey"
                         }
                     }
                 }
             }
         },
-        "/constellations/{constellationID}/kills/summary": {
-            "get": {
-                "description": "Get all kills for a specific constellation",
+        "/admin/keys/{keyID}": {
+            "delete": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Revoke an API key. Replicas may keep accepting it for up to a minute",
                 "consumes": [
                     "application/json"
                 ],
@@ -94,46 +98,28 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "reports"
+                    "admin"
                 ],
-                "summary": "Get kill count by constellation ID",
+                "summary": "Revoke an API key",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Constellation ID",
-                        "name": "constellationID",
+                        "description": "API key ID",
+                        "name": "keyID",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "enum": [
-                            "hour",
-                            "day",
-                            "week",
-                            "month"
-                        ],
-                        "type": "string",
-                        "description": "Mode for aggregating kills (hour, day, week, month)",
-                        "name": "mode",
-                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.ConstellationKills"
-                            }
-                        }
+                    "204": {
+                        "description": "No Content"
                     }
                 }
             }
         },
-        "/constellations/{constellationID}/stargates": {
+        "/alerts/camps": {
             "get": {
-                "description": "Get all stargates for a specific constellation",
+                "description": "Flag gate systems with repeated kills in short succession by the same ship types. Kills in stargate-adjacent systems within minutes raise the score, so pipe systems camped in a row reinforce each other. Each system gets an active-camp score from 0 to 100.",
                 "consumes": [
                     "application/json"
                 ],
@@ -141,16 +127,31 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "stargates"
+                    "alerts"
                 ],
-                "summary": "Get stargates by constellation ID",
+                "summary": "Get likely gate camps",
                 "parameters": [
+                    {
+                        "enum": [
+                            "hour",
+                            "day"
+                        ],
+                        "type": "string",
+                        "description": "Window to look for camps in (hour, day)",
+                        "name": "mode",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
-                        "description": "Constellation ID",
-                        "name": "constellationID",
-                        "in": "path",
-                        "required": true
+                        "description": "Only report systems in this region",
+                        "name": "region_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Minimum camp score to report (0-100, default 50)",
+                        "name": "min_score",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -159,16 +160,16 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.Stargate"
+                                "$ref": "#/definitions/models.CampAlert"
                             }
                         }
                     }
                 }
             }
         },
-        "/constellations/{constellationID}/systems": {
+        "/battles/{battleID}": {
             "get": {
-                "description": "Get all systems for a specific constellation",
+                "description": "Get a single battle, including its killmails. The battle ID is the ID of its first killmail.",
                 "consumes": [
                     "application/json"
                 ],
@@ -176,34 +177,37 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "systems"
+                    "battles"
                 ],
-                "summary": "Get systems by constellation ID",
+                "summary": "Get a battle by ID",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Constellation ID",
-                        "name": "constellationID",
+                        "description": "Battle ID",
+                        "name": "battleID",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum minutes between kills of the same battle (1-120, default 15)",
+                        "name": "gap",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.System"
-                            }
+                            "$ref": "#/definitions/models.Battle"
                         }
                     }
                 }
             }
         },
-        "/planets": {
+        "/constellations": {
             "get": {
-                "description": "Get all planets, or search for a planet by name",
+                "description": "Get all constellations, or search for a constellation by name",
                 "consumes": [
                     "application/json"
                 ],
@@ -211,15 +215,39 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "planets"
+                    "constellations"
                 ],
-                "summary": "Get planets",
+                "summary": "Get constellations",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Planet name to search for",
+                        "description": "Constellation name to search for",
                         "name": "name",
                         "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (1-1000); returns a models.Page envelope instead of an array",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from the next link of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field to sort by, prefixed with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated fields to return",
+                        "name": "fields",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -228,16 +256,16 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.Planet"
+                                "$ref": "#/definitions/models.Constellation"
                             }
                         }
                     }
                 }
             }
         },
-        "/planets/{planetID}": {
+        "/constellations/{constellationID}": {
             "get": {
-                "description": "Get a single planet by its unique ID",
+                "description": "Get a single constellation by its unique ID",
                 "consumes": [
                     "application/json"
                 ],
@@ -245,14 +273,14 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "planets"
+                    "constellations"
                 ],
-                "summary": "Get a planet by ID",
+                "summary": "Get a constellation by ID",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Planet ID",
-                        "name": "planetID",
+                        "description": "Constellation ID",
+                        "name": "constellationID",
                         "in": "path",
                         "required": true
                     }
@@ -261,15 +289,18 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Planet"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Constellation"
+                            }
                         }
                     }
                 }
             }
         },
-        "/rankings/constellations/top": {
+        "/constellations/{constellationID}/activity-profile": {
             "get": {
-                "description": "Get the top 10 constellations ranked by kill count for a specific time window",
+                "description": "Get a 7x24 day-of-week by hour-of-day matrix of kills and ISK for a constellation",
                 "consumes": [
                     "application/json"
                 ],
@@ -277,10 +308,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "rankings"
+                    "reports"
                 ],
-                "summary": "Get top 10 constellations by kills",
+                "summary": "Get activity profile by constellation ID",
                 "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Constellation ID",
+                        "name": "constellationID",
+                        "in": "path",
+                        "required": true
+                    },
                     {
                         "enum": [
                             "hour",
@@ -289,27 +327,30 @@ const docTemplate = `{
                             "month"
                         ],
                         "type": "string",
-                        "description": "Mode for most violence (hour, day, week, month)",
+                        "description": "Lookback window (hour, day, week, month)",
                         "name": "mode",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "IANA timezone used for bucketing, e.g. Europe/London (default UTC)",
+                        "name": "tz",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.ConstellationKillCount"
-                            }
+                            "$ref": "#/definitions/models.ActivityProfile"
                         }
                     }
                 }
             }
         },
-        "/rankings/regions/top": {
+        "/constellations/{constellationID}/kills/summary": {
             "get": {
-                "description": "Get the top 10 regions ranked by kill count for a specific time window",
+                "description": "Get all kills for a specific constellation",
                 "consumes": [
                     "application/json"
                 ],
@@ -317,10 +358,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "rankings"
+                    "reports"
                 ],
-                "summary": "Get top 10 regions by kills",
+                "summary": "Get kill count by constellation ID",
                 "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Constellation ID",
+                        "name": "constellationID",
+                        "in": "path",
+                        "required": true
+                    },
                     {
                         "enum": [
                             "hour",
@@ -329,9 +377,21 @@ const docTemplate = `{
                             "month"
                         ],
                         "type": "string",
-                        "description": "Mode for most violence (hour, day, week, month)",
+                        "description": "Mode for aggregating kills (hour, day, week, month)",
                         "name": "mode",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only count kills at or after this time (RFC 3339 or YYYY-MM-DD, UTC)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only count kills before this time (RFC 3339 or YYYY-MM-DD, UTC)",
+                        "name": "to",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -340,16 +400,16 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.RegionKillCount"
+                                "$ref": "#/definitions/models.ConstellationKills"
                             }
                         }
                     }
                 }
             }
         },
-        "/rankings/systems/top": {
+        "/constellations/{constellationID}/stargates": {
             "get": {
-                "description": "Get the top 10 systems ranked by kill count for a specific time window",
+                "description": "Get all stargates for a specific constellation",
                 "consumes": [
                     "application/json"
                 ],
@@ -357,20 +417,39 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "rankings"
+                    "stargates"
                 ],
-                "summary": "Get top 10 systems by kills",
+                "summary": "Get stargates by constellation ID",
                 "parameters": [
                     {
-                        "enum": [
-                            "hour",
-                            "day",
-                            "week",
-                            "month"
-                        ],
+                        "type": "integer",
+                        "description": "Constellation ID",
+                        "name": "constellationID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (1-1000); returns a models.Page envelope instead of an array",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
                         "type": "string",
-                        "description": "Mode for most violence (hour, day, week, month)",
-                        "name": "mode",
+                        "description": "Cursor from the next link of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field to sort by, prefixed with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated fields to return",
+                        "name": "fields",
                         "in": "query"
                     }
                 ],
@@ -380,16 +459,16 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.SystemKillCount"
+                                "$ref": "#/definitions/models.Stargate"
                             }
                         }
                     }
                 }
             }
         },
-        "/regions": {
+        "/constellations/{constellationID}/systems": {
             "get": {
-                "description": "Get all regions, or search for a region by name",
+                "description": "Get all systems for a specific constellation",
                 "consumes": [
                     "application/json"
                 ],
@@ -397,16 +476,41 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "regions"
+                    "systems"
                 ],
-                "summary": "Get regions",
+                "summary": "Get systems by constellation ID",
                 "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Constellation ID",
+                        "name": "constellationID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (1-1000); returns a models.Page envelope instead of an array",
+                        "name": "limit",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
-                        "description": "Region name to search for",
-                        "name": "name",
+                        "description": "Cursor from the next link of the previous page",
+                        "name": "cursor",
                         "in": "query"
-                    }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field to sort by, prefixed with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated fields to return",
+                        "name": "fields",
+                        "in": "query"
+                    }
                 ],
                 "responses": {
                     "200": {
@@ -414,16 +518,16 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.Region"
+                                "$ref": "#/definitions/models.System"
                             }
                         }
                     }
                 }
             }
         },
-        "/regions/{regionID}": {
+        "/killmails/distribution": {
             "get": {
-                "description": "Get a single region by its unique ID",
+                "description": "Get histogram bins, mean, median, p90 and p99 of total, destroyed and fitted value for a system, constellation or region (or universe-wide when no scope is given) within a time window",
                 "consumes": [
                     "application/json"
                 ],
@@ -431,66 +535,59 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "regions"
+                    "killmails"
                 ],
-                "summary": "Get a region by ID",
+                "summary": "Get killmail value distribution",
                 "parameters": [
+                    {
+                        "enum": [
+                            "system",
+                            "constellation",
+                            "region"
+                        ],
+                        "type": "string",
+                        "description": "Scope to compute the distribution for (system, constellation, region)",
+                        "name": "scope",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
-                        "description": "Region ID",
-                        "name": "regionID",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/models.Region"
-                        }
-                    }
-                }
-            }
-        },
-        "/regions/{regionID}/constellations": {
-            "get": {
-                "description": "Get all constellations for a specific region",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "constellations"
-                ],
-                "summary": "Get constellations by region ID",
-                "parameters": [
+                        "description": "ID of the system, constellation or region (required with scope)",
+                        "name": "id",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "hour",
+                            "day",
+                            "week",
+                            "month"
+                        ],
+                        "type": "string",
+                        "description": "Time window (hour, day, week, month)",
+                        "name": "window",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
-                        "description": "Region ID",
-                        "name": "regionID",
-                        "in": "path",
-                        "required": true
+                        "description": "Number of logarithmic histogram bins (1-100, default 20)",
+                        "name": "bins",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Constellation"
-                            }
+                            "$ref": "#/definitions/models.KillmailValueDistribution"
                         }
                     }
                 }
             }
         },
-        "/regions/{regionID}/heatmap": {
+        "/killmails/top": {
             "get": {
-                "description": "Get per-period per-system metrics (kills, destroyed_value, dropped_value) for a region",
+                "description": "Get the highest total_value killmails for a system, constellation or region (or universe-wide when no scope is given) within a time window",
                 "consumes": [
                     "application/json"
                 ],
@@ -498,16 +595,26 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "reports"
+                    "killmails"
                 ],
-                "summary": "Get system heatmap by region",
+                "summary": "Get most valuable killmails",
                 "parameters": [
+                    {
+                        "enum": [
+                            "system",
+                            "constellation",
+                            "region"
+                        ],
+                        "type": "string",
+                        "description": "Scope to rank killmails in (system, constellation, region)",
+                        "name": "scope",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
-                        "description": "Region ID",
-                        "name": "regionID",
-                        "in": "path",
-                        "required": true
+                        "description": "ID of the system, constellation or region (required with scope)",
+                        "name": "id",
+                        "in": "query"
                     },
                     {
                         "enum": [
@@ -517,8 +624,14 @@ const docTemplate = `{
                             "month"
                         ],
                         "type": "string",
-                        "description": "Mode for aggregating kills (hour, day, week, month)",
-                        "name": "mode",
+                        "description": "Time window (hour, day, week, month)",
+                        "name": "window",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of killmails to return (1-100, default 10)",
+                        "name": "limit",
                         "in": "query"
                     }
                 ],
@@ -526,16 +639,18 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.TopKillmail"
+                            }
                         }
                     }
                 }
             }
         },
-        "/regions/{regionID}/kills/summary": {
+        "/planets": {
             "get": {
-                "description": "Get all kills for a specific region",
+                "description": "Get all planets, or search for a planet by name",
                 "consumes": [
                     "application/json"
                 ],
@@ -543,27 +658,38 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "reports"
+                    "planets"
                 ],
-                "summary": "Get kill count by region ID",
+                "summary": "Get planets",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Planet name to search for",
+                        "name": "name",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
-                        "description": "Region ID",
-                        "name": "regionID",
-                        "in": "path",
-                        "required": true
+                        "description": "Page size (1-1000); returns a models.Page envelope instead of an array",
+                        "name": "limit",
+                        "in": "query"
                     },
                     {
-                        "enum": [
-                            "hour",
-                            "day",
-                            "week",
-                            "month"
-                        ],
                         "type": "string",
-                        "description": "Mode for aggregating kills (hour, day, week, month)",
-                        "name": "mode",
+                        "description": "Cursor from the next link of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field to sort by, prefixed with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated fields to return",
+                        "name": "fields",
                         "in": "query"
                     }
                 ],
@@ -573,16 +699,16 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.RegionKills"
+                                "$ref": "#/definitions/models.Planet"
                             }
                         }
                     }
                 }
             }
         },
-        "/regions/{regionID}/stargates": {
+        "/planets/{planetID}": {
             "get": {
-                "description": "Get all stargates for a specific region",
+                "description": "Get a single planet by its unique ID",
                 "consumes": [
                     "application/json"
                 ],
@@ -590,14 +716,14 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "stargates"
+                    "planets"
                 ],
-                "summary": "Get stargates by region ID",
+                "summary": "Get a planet by ID",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Region ID",
-                        "name": "regionID",
+                        "description": "Planet ID",
+                        "name": "planetID",
                         "in": "path",
                         "required": true
                     }
@@ -606,18 +732,15 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Stargate"
-                            }
+                            "$ref": "#/definitions/models.Planet"
                         }
                     }
                 }
             }
         },
-        "/regions/{regionID}/systems": {
+        "/rankings/constellations/top": {
             "get": {
-                "description": "Get all systems for a specific region",
+                "description": "Get the top 10 constellations ranked by kill count for a specific time window",
                 "consumes": [
                     "application/json"
                 ],
@@ -625,16 +748,21 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "systems"
+                    "rankings"
                 ],
-                "summary": "Get systems by region ID",
+                "summary": "Get top 10 constellations by kills",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Region ID",
-                        "name": "regionID",
-                        "in": "path",
-                        "required": true
+                        "enum": [
+                            "hour",
+                            "day",
+                            "week",
+                            "month"
+                        ],
+                        "type": "string",
+                        "description": "Mode for most violence (hour, day, week, month)",
+                        "name": "mode",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -643,16 +771,16 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.System"
+                                "$ref": "#/definitions/models.ConstellationKillCount"
                             }
                         }
                     }
                 }
             }
         },
-        "/reports/spectral-class-counts": {
+        "/rankings/regions/top": {
             "get": {
-                "description": "Get a report of system counts by spectral class",
+                "description": "Get the top 10 regions ranked by kill count for a specific time window",
                 "consumes": [
                     "application/json"
                 ],
@@ -660,25 +788,39 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "reports"
+                    "rankings"
+                ],
+                "summary": "Get top 10 regions by kills",
+                "parameters": [
+                    {
+                        "enum": [
+                            "hour",
+                            "day",
+                            "week",
+                            "month"
+                        ],
+                        "type": "string",
+                        "description": "Mode for most violence (hour, day, week, month)",
+                        "name": "mode",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get spectral class counts",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.SpectralClassCount"
+                                "$ref": "#/definitions/models.RegionKillCount"
                             }
                         }
                     }
                 }
             }
         },
-        "/stargates": {
+        "/rankings/systems/top": {
             "get": {
-                "description": "Get all stargates",
+                "description": "Get the top 10 systems ranked by kill count for a specific time window",
                 "consumes": [
                     "application/json"
                 ],
@@ -686,25 +828,39 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "stargates"
+                    "rankings"
+                ],
+                "summary": "Get top 10 systems by kills",
+                "parameters": [
+                    {
+                        "enum": [
+                            "hour",
+                            "day",
+                            "week",
+                            "month"
+                        ],
+                        "type": "string",
+                        "description": "Mode for most violence (hour, day, week, month)",
+                        "name": "mode",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get stargates",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.Stargate"
+                                "$ref": "#/definitions/models.SystemKillCount"
                             }
                         }
                     }
                 }
             }
         },
-        "/stations": {
+        "/regions": {
             "get": {
-                "description": "Get all stations, or search for a station by name",
+                "description": "Get all regions, or search for a region by name",
                 "consumes": [
                     "application/json"
                 ],
@@ -712,15 +868,39 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "stations"
+                    "regions"
                 ],
-                "summary": "Get stations",
+                "summary": "Get regions",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Station name to search for",
+                        "description": "Region name to search for",
                         "name": "name",
                         "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (1-1000); returns a models.Page envelope instead of an array",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from the next link of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field to sort by, prefixed with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated fields to return",
+                        "name": "fields",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -729,16 +909,16 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.Station"
+                                "$ref": "#/definitions/models.Region"
                             }
                         }
                     }
                 }
             }
         },
-        "/stations/{stationID}": {
+        "/regions/{regionID}": {
             "get": {
-                "description": "Get a single station by its unique ID",
+                "description": "Get a single region by its unique ID",
                 "consumes": [
                     "application/json"
                 ],
@@ -746,14 +926,14 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "stations"
+                    "regions"
                 ],
-                "summary": "Get a station by ID",
+                "summary": "Get a region by ID",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Station ID",
-                        "name": "stationID",
+                        "description": "Region ID",
+                        "name": "regionID",
                         "in": "path",
                         "required": true
                     }
@@ -762,15 +942,15 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Station"
+                            "$ref": "#/definitions/models.Region"
                         }
                     }
                 }
             }
         },
-        "/systems": {
+        "/regions/{regionID}/activity-profile": {
             "get": {
-                "description": "Get all systems, or search for a system by name",
+                "description": "Get a 7x24 day-of-week by hour-of-day matrix of kills and ISK for a region",
                 "consumes": [
                     "application/json"
                 ],
@@ -778,14 +958,33 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "systems"
+                    "reports"
                 ],
-                "summary": "Get systems",
+                "summary": "Get activity profile by region ID",
                 "parameters": [
                     {
+                        "type": "integer",
+                        "description": "Region ID",
+                        "name": "regionID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "hour",
+                            "day",
+                            "week",
+                            "month"
+                        ],
                         "type": "string",
-                        "description": "System name to search for",
-                        "name": "name",
+                        "description": "Lookback window (hour, day, week, month)",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "IANA timezone used for bucketing, e.g. Europe/London (default UTC)",
+                        "name": "tz",
                         "in": "query"
                     }
                 ],
@@ -793,18 +992,15 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.System"
-                            }
+                            "$ref": "#/definitions/models.ActivityProfile"
                         }
                     }
                 }
             }
         },
-        "/systems/{systemID}": {
+        "/regions/{regionID}/battles": {
             "get": {
-                "description": "Get a single system by its unique ID",
+                "description": "Get battles with kills in a region, built by grouping killmails in the same or stargate-adjacent systems, across region borders, that happened less than gap minutes apart. Kills are grouped per UTC day, so a battle running past midnight UTC is split there.",
                 "consumes": [
                     "application/json"
                 ],
@@ -812,16 +1008,40 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "systems"
+                    "battles"
                 ],
-                "summary": "Get a system by ID",
+                "summary": "Get recent battles by region ID",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "System ID",
-                        "name": "systemID",
+                        "description": "Region ID",
+                        "name": "regionID",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "enum": [
+                            "hour",
+                            "day",
+                            "week",
+                            "month"
+                        ],
+                        "type": "string",
+                        "description": "Window to list battles for (hour, day, week, month)",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum minutes between kills of the same battle (1-120, default 15)",
+                        "name": "gap",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimum kills for a battle to be listed (default 5)",
+                        "name": "min_kills",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -830,16 +1050,16 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.System"
+                                "$ref": "#/definitions/models.Battle"
                             }
                         }
                     }
                 }
             }
         },
-        "/systems/{systemID}/killmails": {
+        "/regions/{regionID}/constellations": {
             "get": {
-                "description": "Get the most recent 15 killmails for a given system.",
+                "description": "Get all constellations for a specific region",
                 "consumes": [
                     "application/json"
                 ],
@@ -847,16 +1067,40 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "killmails"
+                    "constellations"
                 ],
-                "summary": "Get 15 most recent killmails for a system",
+                "summary": "Get constellations by region ID",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "System ID",
-                        "name": "systemID",
+                        "description": "Region ID",
+                        "name": "regionID",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (1-1000); returns a models.Page envelope instead of an array",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from the next link of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field to sort by, prefixed with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated fields to return",
+                        "name": "fields",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -865,16 +1109,16 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.Killmails"
+                                "$ref": "#/definitions/models.Constellation"
                             }
                         }
                     }
                 }
             }
         },
-        "/systems/{systemID}/kills/summary": {
+        "/regions/{regionID}/heatmap": {
             "get": {
-                "description": "Get all kills for a specific system",
+                "description": "Get per-period per-system metrics (kills, destroyed_value, dropped_value) for a region",
                 "consumes": [
                     "application/json"
                 ],
@@ -884,12 +1128,57 @@ const docTemplate = `{
                 "tags": [
                     "reports"
                 ],
-                "summary": "Get kill count by system ID",
+                "summary": "Get system heatmap by region",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "System ID",
-                        "name": "systemID",
+                        "description": "Region ID",
+                        "name": "regionID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "hour",
+                            "day",
+                            "week",
+                            "month"
+                        ],
+                        "type": "string",
+                        "description": "Mode for aggregating kills (hour, day, week, month)",
+                        "name": "mode",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/regions/{regionID}/kills/summary": {
+            "get": {
+                "description": "Get all kills for a specific region",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reports"
+                ],
+                "summary": "Get kill count by region ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Region ID",
+                        "name": "regionID",
                         "in": "path",
                         "required": true
                     },
@@ -904,6 +1193,18 @@ const docTemplate = `{
                         "description": "Mode for aggregating kills (hour, day, week, month)",
                         "name": "mode",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only count kills at or after this time (RFC 3339 or YYYY-MM-DD, UTC)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only count kills before this time (RFC 3339 or YYYY-MM-DD, UTC)",
+                        "name": "to",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -912,16 +1213,16 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.SystemKills"
+                                "$ref": "#/definitions/models.RegionKills"
                             }
                         }
                     }
                 }
             }
         },
-        "/systems/{systemID}/planets": {
+        "/regions/{regionID}/stargates": {
             "get": {
-                "description": "Get all planets for a specific system",
+                "description": "Get all stargates for a specific region",
                 "consumes": [
                     "application/json"
                 ],
@@ -929,16 +1230,40 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "planets"
+                    "stargates"
                 ],
-                "summary": "Get planets by system ID",
+                "summary": "Get stargates by region ID",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "System ID",
-                        "name": "systemID",
+                        "description": "Region ID",
+                        "name": "regionID",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (1-1000); returns a models.Page envelope instead of an array",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from the next link of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field to sort by, prefixed with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated fields to return",
+                        "name": "fields",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -947,16 +1272,16 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.Planet"
+                                "$ref": "#/definitions/models.Stargate"
                             }
                         }
                     }
                 }
             }
         },
-        "/systems/{systemID}/stargates": {
+        "/regions/{regionID}/systems": {
             "get": {
-                "description": "Get all stargates for a specific system",
+                "description": "Get all systems for a specific region",
                 "consumes": [
                     "application/json"
                 ],
@@ -964,16 +1289,40 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "stargates"
+                    "systems"
                 ],
-                "summary": "Get stargates by system ID",
+                "summary": "Get systems by region ID",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "System ID",
-                        "name": "systemID",
+                        "description": "Region ID",
+                        "name": "regionID",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (1-1000); returns a models.Page envelope instead of an array",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from the next link of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field to sort by, prefixed with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated fields to return",
+                        "name": "fields",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -982,50 +1331,960 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.Stargate"
+                                "$ref": "#/definitions/models.System"
                             }
                         }
                     }
                 }
             }
         },
-        "/systems/{systemID}/stations": {
-            "get": {
-                "description": "Get all stations for a specific system",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "stations"
-                ],
-                "summary": "Get stations by system ID",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "System ID",
-                        "name": "systemID",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Station"
-                            }
-                        }
-                    }
+        "/reports/spectral-class-counts": {
+            "get": {
+                "description": "Get a report of system counts by spectral class",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reports"
+                ],
+                "summary": "Get spectral class counts",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.SpectralClassCount"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/search": {
+            "get": {
+                "description": "Case-insensitive prefix and fuzzy search over region, constellation, system, planet and station names, best matches first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "search"
+                ],
+                "summary": "Search names",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search text (at least 2 characters)",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated types to search (region, constellation, system, planet, station), default all",
+                        "name": "types",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of results to return (1-100, default 20)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.SearchResult"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/stargates": {
+            "get": {
+                "description": "Get all stargates",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stargates"
+                ],
+                "summary": "Get stargates",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page size (1-1000); returns a models.Page envelope instead of an array",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from the next link of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field to sort by, prefixed with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated fields to return",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Stargate"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/stations": {
+            "get": {
+                "description": "Get all stations, or search for a station by name",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stations"
+                ],
+                "summary": "Get stations",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Station name to search for",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (1-1000); returns a models.Page envelope instead of an array",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from the next link of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field to sort by, prefixed with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated fields to return",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Station"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/stations/{stationID}": {
+            "get": {
+                "description": "Get a single station by its unique ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stations"
+                ],
+                "summary": "Get a station by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Station ID",
+                        "name": "stationID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Station"
+                        }
+                    }
+                }
+            }
+        },
+        "/systems": {
+            "get": {
+                "description": "Get all systems, search for a system by name, or list the systems matching a combination of filters",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "systems"
+                ],
+                "summary": "Get systems",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "System name to search for",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Minimum security status",
+                        "name": "min_security",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Maximum security status",
+                        "name": "max_security",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated security classes",
+                        "name": "security_class",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated spectral classes",
+                        "name": "spectral_class",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only systems in this region",
+                        "name": "region_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only systems in this constellation",
+                        "name": "constellation_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only systems with (true) or without (false) NPC stations",
+                        "name": "has_station",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimum number of planets",
+                        "name": "min_planets",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bounding box minX,minY,minZ,maxX,maxY,maxZ in meters",
+                        "name": "bbox",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (1-1000); returns a models.Page envelope instead of an array",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from the next link of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field to sort by, prefixed with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated fields to return",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.System"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/systems/{systemID}": {
+            "get": {
+                "description": "Get a single system by its unique ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "systems"
+                ],
+                "summary": "Get a system by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "System ID",
+                        "name": "systemID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.System"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/systems/{systemID}/activity-profile": {
+            "get": {
+                "description": "Get a 7x24 day-of-week by hour-of-day matrix of kills and ISK for a system",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reports"
+                ],
+                "summary": "Get activity profile by system ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "System ID",
+                        "name": "systemID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "hour",
+                            "day",
+                            "week",
+                            "month"
+                        ],
+                        "type": "string",
+                        "description": "Lookback window (hour, day, week, month)",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "IANA timezone used for bucketing, e.g. Europe/London (default UTC)",
+                        "name": "tz",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ActivityProfile"
+                        }
+                    }
+                }
+            }
+        },
+        "/systems/{systemID}/killmails": {
+            "get": {
+                "description": "Get the most recent 15 killmails for a given system.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "killmails"
+                ],
+                "summary": "Get 15 most recent killmails for a system",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "System ID",
+                        "name": "systemID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Killmails"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/systems/{systemID}/kills/summary": {
+            "get": {
+                "description": "Get all kills for a specific system",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reports"
+                ],
+                "summary": "Get kill count by system ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "System ID",
+                        "name": "systemID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "hour",
+                            "day",
+                            "week",
+                            "month"
+                        ],
+                        "type": "string",
+                        "description": "Mode for aggregating kills (hour, day, week, month)",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only count kills at or after this time (RFC 3339 or YYYY-MM-DD, UTC)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only count kills before this time (RFC 3339 or YYYY-MM-DD, UTC)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.SystemKills"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/systems/{systemID}/planets": {
+            "get": {
+                "description": "Get all planets for a specific system",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "planets"
+                ],
+                "summary": "Get planets by system ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "System ID",
+                        "name": "systemID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (1-1000); returns a models.Page envelope instead of an array",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from the next link of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field to sort by, prefixed with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated fields to return",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Planet"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/systems/{systemID}/stargates": {
+            "get": {
+                "description": "Get all stargates for a specific system",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stargates"
+                ],
+                "summary": "Get stargates by system ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "System ID",
+                        "name": "systemID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (1-1000); returns a models.Page envelope instead of an array",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from the next link of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field to sort by, prefixed with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated fields to return",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Stargate"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/systems/{systemID}/stations": {
+            "get": {
+                "description": "Get all stations for a specific system",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stations"
+                ],
+                "summary": "Get stations by system ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "System ID",
+                        "name": "systemID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (1-1000); returns a models.Page envelope instead of an array",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from the next link of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field to sort by, prefixed with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated fields to return",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Station"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/universe/ids": {
+            "post": {
+                "description": "Resolve up to 1000 exact names (ignoring case) to the IDs of the regions, constellations, systems, planets, stations and stargates carrying them. Unknown names are left out of the response",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "universe"
+                ],
+                "summary": "Resolve names to IDs",
+                "parameters": [
+                    {
+                        "description": "Names to resolve",
+                        "name": "names",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UniverseIDs"
+                        }
+                    }
+                }
+            }
+        },
+        "/universe/names": {
+            "post": {
+                "description": "Resolve up to 1000 region, constellation, system, planet, station or stargate IDs to their category and name. Unknown IDs are left out of the response",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "universe"
+                ],
+                "summary": "Resolve IDs to names",
+                "parameters": [
+                    {
+                        "description": "IDs to resolve",
+                        "name": "ids",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "integer"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.UniverseName"
+                            }
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "models.APIKey": {
+            "type": "object",
+            "properties": {
+                "api_key_id": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "description": "First characters of the key",
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "tier": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ActivityProfile": {
+            "type": "object",
+            "properties": {
+                "days": {
+                    "description": "Row labels, Monday first",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "kills": {
+                    "description": "7x24 matrix of kill counts, indexed [day][hour]",
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "type": "integer"
+                        }
+                    }
+                },
+                "mode": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "scope": {
+                    "description": "system, constellation or region",
+                    "type": "string"
+                },
+                "timezone": {
+                    "type": "string"
+                },
+                "total_kills": {
+                    "type": "integer"
+                },
+                "total_value": {
+                    "type": "number"
+                },
+                "value": {
+                    "description": "7x24 matrix of ISK destroyed, indexed [day][hour]",
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "type": "number"
+                        }
+                    }
+                },
+                "window_end": {
+                    "type": "string"
+                },
+                "window_start": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Battle": {
+            "type": "object",
+            "properties": {
+                "battle_id": {
+                    "description": "ID of the first killmail in the battle",
+                    "type": "integer"
+                },
+                "end_time": {
+                    "type": "string"
+                },
+                "kill_count": {
+                    "type": "integer"
+                },
+                "killmails": {
+                    "description": "Only included when fetching a single battle",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TopKillmail"
+                    }
+                },
+                "region_id": {
+                    "type": "integer"
+                },
+                "region_name": {
+                    "type": "string"
+                },
+                "start_time": {
+                    "type": "string"
+                },
+                "systems": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.BattleSystem"
+                    }
+                },
+                "total_value": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.BattleSystem": {
+            "type": "object",
+            "properties": {
+                "kills": {
+                    "type": "integer"
+                },
+                "system_id": {
+                    "type": "integer"
+                },
+                "system_name": {
+                    "type": "string"
+                },
+                "total_value": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.CampAlert": {
+            "type": "object",
+            "properties": {
+                "distinct_kill_ships": {
+                    "type": "integer"
+                },
+                "first_kill": {
+                    "type": "string"
+                },
+                "kills": {
+                    "type": "integer"
+                },
+                "last_kill": {
+                    "type": "string"
+                },
+                "path_kills": {
+                    "description": "Kills next door on the route within minutes of this system's",
+                    "type": "integer"
+                },
+                "region_id": {
+                    "type": "integer"
+                },
+                "region_name": {
+                    "type": "string"
+                },
+                "score": {
+                    "description": "0 - 100, higher means more likely an active camp",
+                    "type": "number"
+                },
+                "system_id": {
+                    "type": "integer"
+                },
+                "system_name": {
+                    "type": "string"
+                },
+                "top_kill_ship": {
+                    "description": "Most repeated final-blow ship type",
+                    "type": "integer"
+                },
+                "top_kill_ship_kills": {
+                    "type": "integer"
                 }
             }
-        }
-    },
-    "definitions": {
+        },
         "models.Constellation": {
             "type": "object",
             "properties": {
@@ -1077,6 +2336,35 @@ const docTemplate = `{
                 }
             }
         },
+        "models.KillmailValueDistribution": {
+            "type": "object",
+            "properties": {
+                "destroyed_value": {
+                    "$ref": "#/definitions/models.ValueDistribution"
+                },
+                "fitted_value": {
+                    "$ref": "#/definitions/models.ValueDistribution"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "mode": {
+                    "type": "string"
+                },
+                "scope": {
+                    "type": "string"
+                },
+                "total_value": {
+                    "$ref": "#/definitions/models.ValueDistribution"
+                },
+                "window_end": {
+                    "type": "string"
+                },
+                "window_start": {
+                    "type": "string"
+                }
+            }
+        },
         "models.Killmails": {
             "type": "object",
             "properties": {
@@ -1112,6 +2400,44 @@ const docTemplate = `{
                 }
             }
         },
+        "models.NewAPIKey": {
+            "type": "object",
+            "properties": {
+                "api_key_id": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "description": "First characters of the key",
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "tier": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.NewAPIKeyRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "tier": {
+                    "type": "string"
+                }
+            }
+        },
         "models.PeriodCount": {
             "type": "object",
             "properties": {
@@ -1201,6 +2527,25 @@ const docTemplate = `{
                 }
             }
         },
+        "models.SearchResult": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "score": {
+                    "description": "1 for an exact match, lower for weaker matches",
+                    "type": "number"
+                },
+                "type": {
+                    "description": "region, constellation, system, planet or station",
+                    "type": "string"
+                }
+            }
+        },
         "models.SpectralClassCount": {
             "type": "object",
             "properties": {
@@ -1319,6 +2664,176 @@ const docTemplate = `{
                     "type": "integer"
                 }
             }
+        },
+        "models.TopKillmail": {
+            "type": "object",
+            "properties": {
+                "destroyed_value": {
+                    "type": "number"
+                },
+                "dropped_value": {
+                    "type": "number"
+                },
+                "fitted_value": {
+                    "type": "number"
+                },
+                "kill_ship": {
+                    "type": "integer"
+                },
+                "killmail_hash": {
+                    "type": "string"
+                },
+                "killmail_id": {
+                    "type": "integer"
+                },
+                "killmail_time": {
+                    "type": "string"
+                },
+                "region_id": {
+                    "type": "integer"
+                },
+                "region_name": {
+                    "type": "string"
+                },
+                "system_id": {
+                    "type": "integer"
+                },
+                "system_name": {
+                    "type": "string"
+                },
+                "total_value": {
+                    "type": "number"
+                },
+                "victim_ship": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.UniverseEntity": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.UniverseIDs": {
+            "type": "object",
+            "properties": {
+                "constellations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.UniverseEntity"
+                    }
+                },
+                "planets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.UniverseEntity"
+                    }
+                },
+                "regions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.UniverseEntity"
+                    }
+                },
+                "stargates": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.UniverseEntity"
+                    }
+                },
+                "stations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.UniverseEntity"
+                    }
+                },
+                "systems": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.UniverseEntity"
+                    }
+                }
+            }
+        },
+        "models.UniverseName": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "description": "region, constellation, system, planet, station or stargate",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ValueBin": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "max": {
+                    "type": "number"
+                },
+                "min": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.ValueDistribution": {
+            "type": "object",
+            "properties": {
+                "bins": {
+                    "description": "Logarithmic bins between Min and Max",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ValueBin"
+                    }
+                },
+                "count": {
+                    "type": "integer"
+                },
+                "max": {
+                    "type": "number"
+                },
+                "mean": {
+                    "type": "number"
+                },
+                "median": {
+                    "type": "number"
+                },
+                "min": {
+                    "type": "number"
+                },
+                "p90": {
+                    "type": "number"
+                },
+                "p99": {
+                    "type": "number"
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "APIKey": {
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
+        },
+        "AdminToken": {
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
         }
     }
 }`
@@ -0,0 +1,480 @@
+package ingest
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/bzip2"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/astrocartics-xyz/Astrocartics-API/dba"
+	"github.com/astrocartics-xyz/Astrocartics-API/models"
+)
+
+// archiveKillmail is an ESI killmail as found in dumps and archives, optionally carrying zKillboard data.
+type archiveKillmail struct {
+	ESIKillmail
+	Hash string `json:"killmail_hash"`
+	ZKB  *ZKB   `json:"zkb"`
+}
+
+func (a *archiveKillmail) toKillmail() (models.Killmails, error) {
+	zkb := ZKB{}
+	if a.ZKB != nil {
+		zkb = *a.ZKB
+	}
+	hash := a.Hash
+	if hash == "" {
+		hash = zkb.Hash
+	}
+	return ToKillmail(&a.ESIKillmail, hash, zkb)
+}
+
+// BackfillOptions configures a historical import.
+type BackfillOptions struct {
+	BatchSize   int    // Killmails per COPY batch
+	StateFile   string // Progress file that makes an interrupted import resumable, empty disables it
+	ESIURL      string // ESI base URL used to expand killmail_id -> hash dumps
+	Concurrency int    // Parallel ESI requests when expanding hash dumps
+	UserAgent   string
+}
+
+// backfillState records how far each file got. A position of -1 marks a finished file,
+// otherwise it is the number of records already committed. Failed lists the killmail IDs of
+// each hash dump that ESI could not deliver, which the next run fetches again.
+type backfillState struct {
+	Files  map[string]int64   `json:"files"`
+	Failed map[string][]int64 `json:"failed,omitempty"`
+}
+
+// Backfill imports killmails from local historical dumps: zKillboard daily killmail_id -> hash
+// JSON files, ESI killmail JSON files (single or arrays) and tar.bz2 archives of ESI killmails.
+type Backfill struct {
+	Options BackfillOptions
+	Client  *http.Client
+
+	state    backfillState
+	batch    []models.Killmails
+	read     int64
+	inserted int64
+	failed   int64 // Killmails ESI could not deliver
+	started  time.Time
+}
+
+// Run imports every file in paths, descending into directories. Files already finished according
+// to the state file are skipped, partially imported files resume after their last committed batch.
+func (b *Backfill) Run(ctx context.Context, paths []string) error {
+	if b.Options.BatchSize <= 0 {
+		b.Options.BatchSize = 5000
+	}
+	if b.Options.Concurrency <= 0 {
+		b.Options.Concurrency = 8
+	}
+	if err := b.loadState(); err != nil {
+		return err
+	}
+	files, err := collectFiles(paths)
+	if err != nil {
+		return err
+	}
+	b.started = time.Now()
+	for i, path := range files {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if b.state.Files[path] < 0 && len(b.state.Failed[path]) == 0 {
+			log.Printf("Backfill: skipping %s, already imported", path)
+			continue
+		}
+		log.Printf("Backfill: importing %s (%d/%d)", path, i+1, len(files))
+		if err := b.importFile(ctx, path); err != nil {
+			return fmt.Errorf("failed to import %s: %w", path, err)
+		}
+		b.state.Files[path] = -1
+		if err := b.saveState(); err != nil {
+			return err
+		}
+	}
+	log.Printf("Backfill finished: %d killmails read, %d inserted, %d failed to fetch in %v", b.read, b.inserted, b.failed, time.Since(b.started).Round(time.Second))
+	if b.failed > 0 && b.Options.StateFile != "" {
+		log.Printf("Backfill: killmails that failed to fetch are kept in %s; run again to retry them", b.Options.StateFile)
+	}
+	return nil
+}
+
+// collectFiles expands directories into the importable files below them, in a stable order.
+func collectFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, filepath.Clean(p))
+			continue
+		}
+		err = filepath.WalkDir(p, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && (strings.HasSuffix(path, ".json") || isTarBz2(path)) {
+				files = append(files, filepath.Clean(path))
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func isTarBz2(path string) bool {
+	return strings.HasSuffix(path, ".tar.bz2") || strings.HasSuffix(path, ".tbz2")
+}
+
+// importFile imports one file, resuming from its saved position.
+func (b *Backfill) importFile(ctx context.Context, path string) error {
+	skip := b.state.Files[path]
+	if isTarBz2(path) {
+		if skip < 0 {
+			return nil
+		}
+		return b.importTarBz2(ctx, path, skip)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil
+	}
+	// A JSON array is a list of ESI killmails
+	if data[0] == '[' {
+		if skip < 0 {
+			return nil
+		}
+		var kills []archiveKillmail
+		if err := json.Unmarshal(data, &kills); err != nil {
+			return fmt.Errorf("failed to decode killmail array: %w", err)
+		}
+		for i := int64(0); i < int64(len(kills)); i++ {
+			if i < skip {
+				continue
+			}
+			if err := b.add(ctx, &kills[i], path, i+1); err != nil {
+				return err
+			}
+		}
+		return b.flush(ctx, path, int64(len(kills)))
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("failed to decode JSON: %w", err)
+	}
+	// A single ESI killmail
+	if _, ok := fields["killmail_id"]; ok {
+		if skip != 0 {
+			return nil
+		}
+		var k archiveKillmail
+		if err := json.Unmarshal(data, &k); err != nil {
+			return fmt.Errorf("failed to decode killmail: %w", err)
+		}
+		if err := b.add(ctx, &k, path, 1); err != nil {
+			return err
+		}
+		return b.flush(ctx, path, 1)
+	}
+	// Otherwise a zKillboard daily dump of killmail_id -> hash
+	hashes := make(map[int64]string, len(fields))
+	for key, raw := range fields {
+		id, err := strconv.ParseInt(key, 10, 64)
+		if err != nil {
+			return fmt.Errorf("unrecognised JSON file: key %q is not a killmail ID", key)
+		}
+		var hash string
+		if err := json.Unmarshal(raw, &hash); err != nil {
+			return fmt.Errorf("unrecognised JSON file: value of %s is not a hash", key)
+		}
+		hashes[id] = hash
+	}
+	return b.importHashes(ctx, path, hashes, skip)
+}
+
+// importTarBz2 streams the JSON killmails out of a tar.bz2 archive.
+func (b *Backfill) importTarBz2(ctx context.Context, path string, skip int64) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	tr := tar.NewReader(bzip2.NewReader(f))
+	var pos int64
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg || !strings.HasSuffix(hdr.Name, ".json") {
+			continue
+		}
+		pos++
+		if pos <= skip {
+			continue
+		}
+		var k archiveKillmail
+		if err := json.NewDecoder(tr).Decode(&k); err != nil {
+			return fmt.Errorf("failed to decode %s: %w", hdr.Name, err)
+		}
+		if err := b.add(ctx, &k, path, pos); err != nil {
+			return err
+		}
+	}
+	return b.flush(ctx, path, pos)
+}
+
+// importHashes expands killmail_id -> hash pairs through ESI, one batch at a time, after retrying
+// the killmails earlier runs failed to fetch. Killmails that fail again are recorded in the state
+// with the batch they belong to, so the checkpoint never moves past one without remembering it.
+// Hash dumps carry no ISK values, so those columns stay zero.
+func (b *Backfill) importHashes(ctx context.Context, path string, hashes map[int64]string, skip int64) error {
+	if retry := b.state.Failed[path]; len(retry) > 0 {
+		log.Printf("Backfill: retrying %d killmails of %s that failed to fetch", len(retry), path)
+		for start := 0; start < len(retry); start += b.Options.BatchSize {
+			end := start + b.Options.BatchSize
+			if end > len(retry) {
+				end = len(retry)
+			}
+			// The saved state keeps listing this batch until it is committed
+			b.state.Failed[path] = append([]int64(nil), retry[end:]...)
+			if err := b.expandBatch(ctx, path, retry[start:end], hashes, b.state.Files[path]); err != nil {
+				return err
+			}
+		}
+		if len(b.state.Failed[path]) == 0 {
+			delete(b.state.Failed, path)
+		}
+	}
+	if skip < 0 {
+		return nil
+	}
+	ids := make([]int64, 0, len(hashes))
+	for id := range hashes {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for start := skip; start < int64(len(ids)); start += int64(b.Options.BatchSize) {
+		end := start + int64(b.Options.BatchSize)
+		if end > int64(len(ids)) {
+			end = int64(len(ids))
+		}
+		if err := b.expandBatch(ctx, path, ids[start:end], hashes, end); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expandBatch fetches ids from ESI, records the ones that failed and commits the rest,
+// checkpointing pos for path.
+func (b *Backfill) expandBatch(ctx context.Context, path string, ids []int64, hashes map[int64]string, pos int64) error {
+	kills, failed, err := b.fetchESI(ctx, ids, hashes)
+	if err != nil {
+		return err
+	}
+	for _, k := range kills {
+		b.read++
+		b.batch = append(b.batch, k)
+	}
+	if len(failed) > 0 {
+		if b.state.Failed == nil {
+			b.state.Failed = map[string][]int64{}
+		}
+		b.state.Failed[path] = append(b.state.Failed[path], failed...)
+	}
+	return b.flush(ctx, path, pos)
+}
+
+// ESI rate limit (420, 429) and server error responses are retried up to esiRetries times,
+// waiting esiRetryDelay and doubling it after each attempt.
+var (
+	esiRetries    = 5
+	esiRetryDelay = 2 * time.Second
+)
+
+// retryableStatus reports whether an ESI response status is worth retrying.
+func retryableStatus(status int) bool {
+	return status == 420 || status == http.StatusTooManyRequests || status >= 500
+}
+
+// fetchKillmail downloads one killmail from ESI, retrying rate limited and failed requests.
+func (b *Backfill) fetchKillmail(ctx context.Context, id int64, hash string) (*ESIKillmail, error) {
+	url := fmt.Sprintf("%s/killmails/%d/%s/", strings.TrimRight(b.Options.ESIURL, "/"), id, hash)
+	delay := esiRetryDelay
+	for attempt := 0; ; attempt++ {
+		esi := &ESIKillmail{}
+		status, err := getJSON(ctx, b.Client, b.Options.UserAgent, url, esi)
+		if err == nil {
+			return esi, nil
+		}
+		// Network errors leave the status at 0 and are retried like server errors
+		if ctx.Err() != nil || attempt >= esiRetries || (status != 0 && !retryableStatus(status)) {
+			return nil, err
+		}
+		select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// fetchESI downloads killmails from ESI in parallel, keeping the input order. Killmails that
+// cannot be fetched are logged and returned as failed; only a cancelled ctx fails the batch.
+func (b *Backfill) fetchESI(ctx context.Context, ids []int64, hashes map[int64]string) ([]models.Killmails, []int64, error) {
+	results := make([]*models.Killmails, len(ids))
+	errs := make([]error, len(ids))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < b.Options.Concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				id := ids[i]
+				esi, err := b.fetchKillmail(ctx, id, hashes[id])
+				if err != nil {
+					errs[i] = err
+					continue
+				}
+				k, err := ToKillmail(esi, hashes[id], ZKB{})
+				if err != nil {
+					log.Printf("Backfill: skipping killmail %d: %v", id, err)
+					continue
+				}
+				results[i] = &k
+			}
+		}()
+	}
+	for i := range ids {
+		if ctx.Err() != nil {
+			break
+		}
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	if ctx.Err() != nil {
+		return nil, nil, ctx.Err()
+	}
+	kills := make([]models.Killmails, 0, len(ids))
+	var failed []int64
+	for i, k := range results {
+		if errs[i] != nil {
+			log.Printf("Backfill: deferring killmail %d, ESI fetch failed: %v", ids[i], errs[i])
+			b.failed++
+			failed = append(failed, ids[i])
+			continue
+		}
+		if k != nil {
+			kills = append(kills, *k)
+		}
+	}
+	return kills, failed, nil
+}
+
+// copyKillmails bulk inserts killmail rows; tests replace it.
+var copyKillmails = dba.CopyKillmails
+
+// add queues a killmail and flushes once the batch is full. pos is the position to resume
+// after in path once this killmail is committed.
+func (b *Backfill) add(ctx context.Context, a *archiveKillmail, path string, pos int64) error {
+	b.read++
+	k, err := a.toKillmail()
+	if err != nil {
+		log.Printf("Backfill: skipping killmail %d in %s: %v", a.KillmailID, path, err)
+	} else {
+		b.batch = append(b.batch, k)
+	}
+	if len(b.batch) >= b.Options.BatchSize {
+		return b.flush(ctx, path, pos)
+	}
+	return nil
+}
+
+// flush copies the pending batch into the database and checkpoints pos for path.
+func (b *Backfill) flush(ctx context.Context, path string, pos int64) error {
+	if len(b.batch) > 0 {
+		inserted, err := copyKillmails(ctx, b.batch)
+		if err != nil {
+			return err
+		}
+		b.inserted += inserted
+		b.batch = b.batch[:0]
+	}
+	if pos > b.state.Files[path] {
+		b.state.Files[path] = pos
+	}
+	elapsed := time.Since(b.started).Seconds()
+	log.Printf("Backfill progress: %s at %d, %d killmails read, %d inserted (%.0f/s)", filepath.Base(path), pos, b.read, b.inserted, float64(b.read)/elapsed)
+	return b.saveState()
+}
+
+func (b *Backfill) loadState() error {
+	b.state = backfillState{Files: map[string]int64{}}
+	if b.Options.StateFile == "" {
+		return nil
+	}
+	data, err := os.ReadFile(b.Options.StateFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read state file: %w", err)
+	}
+	if err := json.Unmarshal(data, &b.state); err != nil {
+		return fmt.Errorf("failed to decode state file: %w", err)
+	}
+	if b.state.Files == nil {
+		b.state.Files = map[string]int64{}
+	}
+	return nil
+}
+
+// saveState writes the state file atomically so a crash never leaves it half written.
+func (b *Backfill) saveState() error {
+	if b.Options.StateFile == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(b.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := b.Options.StateFile + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	return os.Rename(tmp, b.Options.StateFile)
+}
@@ -0,0 +1,157 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/astrocartics-xyz/Astrocartics-API/models"
+)
+
+func TestFetchESISkipsFailures(t *testing.T) {
+	defer func(d time.Duration) { esiRetryDelay = d }(esiRetryDelay)
+	esiRetryDelay = time.Millisecond
+
+	var limited atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+			case strings.HasPrefix(r.URL.Path, "/killmails/1/"):
+				// Rate limited twice before it is served
+				if limited.Add(1) <= 2 {
+					http.Error(w, "error limited", 420)
+					return
+				}
+				w.Write([]byte(`{"killmail_id": 1, "killmail_time": "2026-10-18T00:00:00Z", "solar_system_id": 30000142}`))
+			case strings.HasPrefix(r.URL.Path, "/killmails/2/"):
+				http.NotFound(w, r)
+			case strings.HasPrefix(r.URL.Path, "/killmails/3/"):
+				http.Error(w, "down", http.StatusBadGateway)
+			default:
+				w.Write([]byte(`{"killmail_id": 4, "killmail_time": "2026-10-18T00:00:01Z", "solar_system_id": 30000144}`))
+		}
+	}))
+	defer srv.Close()
+
+	b := &Backfill{Options: BackfillOptions{ESIURL: srv.URL, Concurrency: 2}, Client: srv.Client()}
+	ids := []int64{1, 2, 3, 4}
+	hashes := map[int64]string{1: "a", 2: "b", 3: "c", 4: "d"}
+	kills, failed, err := b.fetchESI(context.Background(), ids, hashes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failed) != 2 || failed[0] != 2 || failed[1] != 3 {
+		t.Errorf("failed = %v, want [2 3]", failed)
+	}
+	if len(kills) != 2 || kills[0].KillmailID != 1 || kills[1].KillmailID != 4 {
+		t.Fatalf("unexpected killmails: %+v", kills)
+	}
+	if kills[0].KillmailHash != "a" {
+		t.Errorf("KillmailHash = %q, want a", kills[0].KillmailHash)
+	}
+	if b.failed != 2 {
+		t.Errorf("failed = %d, want 2", b.failed)
+	}
+	if got := limited.Load(); got != 3 {
+		t.Errorf("rate limited killmail fetched %d times, want 3", got)
+	}
+}
+
+func TestFetchESICancelled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "error limited", 420)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	b := &Backfill{Options: BackfillOptions{ESIURL: srv.URL, Concurrency: 1}, Client: srv.Client()}
+	if _, _, err := b.fetchESI(ctx, []int64{1}, map[int64]string{1: "a"}); err == nil {
+		t.Fatal("expected the cancellation to fail the batch")
+	}
+}
+
+func TestBackfillRetriesFailedFetchesOnResume(t *testing.T) {
+	defer func(n int, d time.Duration) { esiRetries, esiRetryDelay = n, d }(esiRetries, esiRetryDelay)
+	esiRetries, esiRetryDelay = 0, time.Millisecond
+	defer func(c func(context.Context, []models.Killmails) (int64, error)) { copyKillmails = c }(copyKillmails)
+	var copied []int64
+	copyKillmails = func(ctx context.Context, kills []models.Killmails) (int64, error) {
+		for _, k := range kills {
+			copied = append(copied, k.KillmailID)
+		}
+		return int64(len(kills)), nil
+	}
+
+	var esiDown atomic.Bool
+	esiDown.Store(true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Killmail 2 is unavailable for the whole first run
+		if strings.HasPrefix(r.URL.Path, "/killmails/2/") && esiDown.Load() {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		id := strings.Split(r.URL.Path, "/")[2]
+		w.Write([]byte(`{"killmail_id": ` + id + `, "killmail_time": "2026-10-18T00:00:00Z", "solar_system_id": 30000142}`))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	dump := filepath.Join(dir, "20261018.json")
+	if err := os.WriteFile(dump, []byte(`{"1": "a", "2": "b", "3": "c"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	opts := BackfillOptions{ESIURL: srv.URL, BatchSize: 2, Concurrency: 1, StateFile: filepath.Join(dir, "state.json")}
+
+	b := &Backfill{Options: opts, Client: srv.Client()}
+	if err := b.Run(context.Background(), []string{dump}); err != nil {
+		t.Fatal(err)
+	}
+	if len(copied) != 2 || copied[0] != 1 || copied[1] != 3 {
+		t.Fatalf("first run copied %v, want [1 3]", copied)
+	}
+	data, err := os.ReadFile(opts.StateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var state backfillState
+	if err := json.Unmarshal(data, &state); err != nil {
+		t.Fatal(err)
+	}
+	if state.Files[dump] != -1 || len(state.Failed[dump]) != 1 || state.Failed[dump][0] != 2 {
+		t.Fatalf("state after the first run = %+v, want the file finished with killmail 2 failed", state)
+	}
+
+	// The resumed run fetches only the failed killmail, then forgets it
+	esiDown.Store(false)
+	copied = nil
+	b = &Backfill{Options: opts, Client: srv.Client()}
+	if err := b.Run(context.Background(), []string{dump}); err != nil {
+		t.Fatal(err)
+	}
+	if len(copied) != 1 || copied[0] != 2 {
+		t.Fatalf("resumed run copied %v, want [2]", copied)
+	}
+	if err := b.loadState(); err != nil {
+		t.Fatal(err)
+	}
+	if b.state.Files[dump] != -1 || len(b.state.Failed) != 0 {
+		t.Errorf("state after the resumed run = %+v, want the file finished with nothing failed", b.state)
+	}
+
+	// A third run has nothing left to do
+	copied = nil
+	b = &Backfill{Options: opts, Client: srv.Client()}
+	if err := b.Run(context.Background(), []string{dump}); err != nil {
+		t.Fatal(err)
+	}
+	if len(copied) != 0 {
+		t.Errorf("finished file copied %v again", copied)
+	}
+}
@@ -0,0 +1,192 @@
+// Package ingest reads killmails from a zKillboard-style feed and stores them in the killmails table.
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ESIKillmail is the subset of an ESI killmail the API stores.
+type ESIKillmail struct {
+	KillmailID    int64  `json:"killmail_id"`
+	KillmailTime  string `json:"killmail_time"`
+	SolarSystemID int    `json:"solar_system_id"`
+	Victim        struct {
+		ShipTypeID int64 `json:"ship_type_id"`
+	} `json:"victim"`
+	Attackers []struct {
+		FinalBlow  bool  `json:"final_blow"`
+		ShipTypeID int64 `json:"ship_type_id"`
+	} `json:"attackers"`
+}
+
+// ZKB is the zKillboard metadata attached to a killmail.
+type ZKB struct {
+	Hash           string  `json:"hash"`
+	FittedValue    float64 `json:"fittedValue"`
+	DroppedValue   float64 `json:"droppedValue"`
+	DestroyedValue float64 `json:"destroyedValue"`
+	TotalValue     float64 `json:"totalValue"`
+	Href           string  `json:"href"` // ESI URL of the killmail, used when the feed omits it
+}
+
+// Package is one feed entry. RedisQ wraps it as {"package": {...}} with the killmail under
+// "killmail"; R2Z2 serves it flat with the killmail under "esi".
+type Package struct {
+	KillID     int64        `json:"killID"`
+	KillmailID int64        `json:"killmail_id"`
+	Hash       string       `json:"hash"`
+	Killmail   *ESIKillmail `json:"killmail"`
+	ESI        *ESIKillmail `json:"esi"`
+	ZKB        ZKB          `json:"zkb"`
+}
+
+// ID returns the killmail ID of the package, whichever field the feed filled in.
+func (p *Package) ID() int64 {
+	switch {
+		case p.KillID != 0:
+			return p.KillID
+		case p.KillmailID != 0:
+			return p.KillmailID
+		case p.Killmail != nil:
+			return p.Killmail.KillmailID
+		case p.ESI != nil:
+			return p.ESI.KillmailID
+	}
+	return 0
+}
+
+// Feed is a source of killmail packages.
+type Feed interface {
+	// Next blocks until the next package is available. It returns nil, nil when the feed
+	// had nothing to deliver within its wait time.
+	Next(ctx context.Context) (*Package, error)
+}
+
+// Feed formats understood by NewFeed.
+const (
+	FormatRedisQ = "redisq"
+	FormatR2Z2   = "r2z2"
+)
+
+// FeedConfig configures a feed client.
+type FeedConfig struct {
+	Format    string // redisq or r2z2
+	URL       string // RedisQ listen URL, or R2Z2 base URL serving sequence.json and <sequence>.json
+	QueueID   string // RedisQ queue identifier
+	TTW       int    // RedisQ seconds to wait for a killmail before returning an empty package
+	StartSeq  int64  // R2Z2 sequence to start at, 0 starts at the current sequence
+	UserAgent string
+}
+
+// NewFeed builds the feed client for the configured format.
+func NewFeed(cfg FeedConfig, client *http.Client) (Feed, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("feed URL is required")
+	}
+	switch cfg.Format {
+		case FormatRedisQ, "":
+			return &redisQFeed{cfg: cfg, client: client}, nil
+		case FormatR2Z2:
+			return &r2z2Feed{cfg: cfg, client: client, seq: cfg.StartSeq}, nil
+		default:
+			return nil, fmt.Errorf("invalid feed format: %s; supported: 'redisq', 'r2z2'", cfg.Format)
+	}
+}
+
+// getJSON fetches url and decodes the body into v. It returns the HTTP status code.
+func getJSON(ctx context.Context, client *http.Client, userAgent string, url string, v interface{}) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to build request: %w", err)
+	}
+	if userAgent != "" {
+		req.Header.Set("User-Agent", userAgent)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return resp.StatusCode, fmt.Errorf("unexpected status from %s: %s", url, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return resp.StatusCode, fmt.Errorf("failed to decode %s: %w", url, err)
+	}
+	return resp.StatusCode, nil
+}
+
+// redisQFeed long-polls a RedisQ listen endpoint.
+type redisQFeed struct {
+	cfg    FeedConfig
+	client *http.Client
+}
+
+func (f *redisQFeed) Next(ctx context.Context) (*Package, error) {
+	u, err := url.Parse(f.cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid feed URL: %w", err)
+	}
+	q := u.Query()
+	if f.cfg.QueueID != "" {
+		q.Set("queueID", f.cfg.QueueID)
+	}
+	if f.cfg.TTW > 0 {
+		q.Set("ttw", strconv.Itoa(f.cfg.TTW))
+	}
+	u.RawQuery = q.Encode()
+	var body struct {
+		Package *Package `json:"package"`
+	}
+	if _, err := getJSON(ctx, f.client, f.cfg.UserAgent, u.String(), &body); err != nil {
+		return nil, err
+	}
+	return body.Package, nil
+}
+
+// r2z2Feed walks the numbered killmail files of an R2Z2 style feed.
+type r2z2Feed struct {
+	cfg    FeedConfig
+	client *http.Client
+	seq    int64
+}
+
+// r2z2PollDelay is how long to wait before asking for a sequence that was not published yet.
+var r2z2PollDelay = 5 * time.Second
+
+func (f *r2z2Feed) Next(ctx context.Context) (*Package, error) {
+	// Start from the newest published sequence
+	if f.seq == 0 {
+		var current struct {
+			Sequence int64 `json:"sequence"`
+		}
+		if _, err := getJSON(ctx, f.client, f.cfg.UserAgent, f.cfg.URL+"/sequence.json", &current); err != nil {
+			return nil, err
+		}
+		f.seq = current.Sequence
+	}
+	var pkg Package
+	status, err := getJSON(ctx, f.client, f.cfg.UserAgent, fmt.Sprintf("%s/%d.json", f.cfg.URL, f.seq), &pkg)
+	if status == http.StatusNotFound {
+		// Not published yet, wait for it
+		select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(r2z2PollDelay):
+		}
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	f.seq++
+	return &pkg, nil
+}
@@ -0,0 +1,179 @@
+package ingest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testKillmail = `{
+	"killmail_id": 123456789,
+	"killmail_time": "2026-10-18T12:34:56Z",
+	"solar_system_id": 30000142,
+	"victim": {"ship_type_id": 587},
+	"attackers": [
+		{"final_blow": false, "ship_type_id": 11198},
+		{"final_blow": true, "ship_type_id": 17738}
+	]
+}`
+
+const testZKB = `{"hash": "abc123", "fittedValue": 1.5, "droppedValue": 2.5, "destroyedValue": 3.5, "totalValue": 6}`
+
+func TestRedisQFeed(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("queueID"); got != "astro" {
+			t.Errorf("queueID = %q, want astro", got)
+		}
+		if got := r.URL.Query().Get("ttw"); got != "3" {
+			t.Errorf("ttw = %q, want 3", got)
+		}
+		if got := r.UserAgent(); got != "astro-test" {
+			t.Errorf("User-Agent = %q, want astro-test", got)
+		}
+		// The first poll delivers a killmail, the second times out empty
+		if calls.Add(1) == 1 {
+			w.Write([]byte(`{"package": {"killID": 123456789, "killmail": ` + testKillmail + `, "zkb": ` + testZKB + `}}`))
+			return
+		}
+		w.Write([]byte(`{"package": null}`))
+	}))
+	defer srv.Close()
+
+	feed, err := NewFeed(FeedConfig{Format: FormatRedisQ, URL: srv.URL, QueueID: "astro", TTW: 3, UserAgent: "astro-test"}, srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := feed.Next(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkg == nil {
+		t.Fatal("expected a package")
+	}
+	if pkg.ID() != 123456789 {
+		t.Errorf("ID() = %d, want 123456789", pkg.ID())
+	}
+	if pkg.Killmail == nil || pkg.Killmail.SolarSystemID != 30000142 {
+		t.Errorf("killmail not decoded: %+v", pkg.Killmail)
+	}
+	if pkg.ZKB.Hash != "abc123" || pkg.ZKB.TotalValue != 6 {
+		t.Errorf("zkb not decoded: %+v", pkg.ZKB)
+	}
+
+	pkg, err = feed.Next(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkg != nil {
+		t.Errorf("expected no package from an empty poll, got %+v", pkg)
+	}
+}
+
+func TestRedisQFeedError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "slow down", http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	feed, err := NewFeed(FeedConfig{URL: srv.URL}, srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := feed.Next(context.Background()); err == nil {
+		t.Fatal("expected an error for a 429 response")
+	}
+}
+
+func TestR2Z2Feed(t *testing.T) {
+	defer func(d time.Duration) { r2z2PollDelay = d }(r2z2PollDelay)
+	r2z2PollDelay = time.Millisecond
+
+	var published atomic.Bool
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sequence.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"sequence": 41}`))
+	})
+	mux.HandleFunc("/41.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"killmail_id": 123456789, "hash": "abc123", "esi": ` + testKillmail + `, "zkb": ` + testZKB + `}`))
+	})
+	mux.HandleFunc("/42.json", func(w http.ResponseWriter, r *http.Request) {
+		if !published.Load() {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"killmail_id": 123456790, "hash": "def456", "esi": {"killmail_id": 123456790}, "zkb": {}}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	feed, err := NewFeed(FeedConfig{Format: FormatR2Z2, URL: srv.URL}, srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	// Starts at the current sequence
+	pkg, err := feed.Next(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkg == nil || pkg.ID() != 123456789 || pkg.ESI == nil || pkg.ESI.Victim.ShipTypeID != 587 {
+		t.Fatalf("unexpected package for sequence 41: %+v", pkg)
+	}
+
+	// The next sequence is not published yet
+	pkg, err = feed.Next(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkg != nil {
+		t.Fatalf("expected no package before sequence 42 is published, got %+v", pkg)
+	}
+
+	// Once published, the same sequence is asked for again
+	published.Store(true)
+	pkg, err = feed.Next(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkg == nil || pkg.ID() != 123456790 {
+		t.Fatalf("unexpected package for sequence 42: %+v", pkg)
+	}
+}
+
+func TestR2Z2FeedStartSeq(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sequence.json", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("sequence.json fetched despite a start sequence")
+	})
+	mux.HandleFunc("/7.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"killmail_id": 7}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	feed, err := NewFeed(FeedConfig{Format: FormatR2Z2, URL: srv.URL, StartSeq: 7}, srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := feed.Next(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkg == nil || pkg.ID() != 7 {
+		t.Fatalf("unexpected package: %+v", pkg)
+	}
+}
+
+func TestNewFeedInvalid(t *testing.T) {
+	if _, err := NewFeed(FeedConfig{Format: FormatRedisQ}, http.DefaultClient); err == nil {
+		t.Error("expected an error without a URL")
+	}
+	if _, err := NewFeed(FeedConfig{Format: "kafka", URL: "http://localhost"}, http.DefaultClient); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
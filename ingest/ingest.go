@@ -0,0 +1,191 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/astrocartics-xyz/Astrocartics-API/dba"
+	"github.com/astrocartics-xyz/Astrocartics-API/models"
+)
+
+// Stats counts what an ingester has done so far.
+type Stats struct {
+	Inserted int // New killmails
+	Updated  int // Duplicate killmail IDs, refreshed in place
+	Skipped  int // Packages that could not be turned into a killmail
+	Errors   int // Feed, ESI or database errors, each retried
+}
+
+// Ingester moves killmails from a feed into the database.
+type Ingester struct {
+	Feed      Feed
+	Client    *http.Client // Used to fetch killmails from ESI when the feed only links to them
+	UserAgent string
+
+	// pending is the package taken from the feed but not stored yet. The feed will not hand it
+	// over again, so it is retried until it is stored or turns out to be unusable.
+	pending *Package
+
+	mu    sync.Mutex
+	stats Stats
+}
+
+// Stats returns a snapshot of the ingester's counters.
+func (in *Ingester) Stats() Stats {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	return in.stats
+}
+
+// count applies f to the counters under the lock.
+func (in *Ingester) count(f func(s *Stats)) {
+	in.mu.Lock()
+	f(&in.stats)
+	in.mu.Unlock()
+}
+
+// storeKillmail writes a killmail row; tests replace it.
+var storeKillmail = dba.UpsertKillmail
+
+// errRetry marks resolve errors that may go away, such as ESI being down or rate limiting.
+var errRetry = errors.New("temporarily unavailable")
+
+// Retries after feed, ESI or database errors wait minBackoff, doubling up to maxBackoff.
+var (
+	minBackoff = time.Second
+	maxBackoff = time.Minute
+)
+
+// Run consumes the feed until ctx is cancelled. Errors are logged and retried with backoff.
+func (in *Ingester) Run(ctx context.Context) error {
+	backoff := minBackoff
+	for {
+		if ctx.Err() != nil {
+			return nil
+		}
+		err := in.step(ctx)
+		if err == nil {
+			backoff = minBackoff
+			continue
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		in.count(func(s *Stats) { s.Errors++ })
+		log.Printf("Ingest error: %v. Retrying in %v...", err, backoff)
+		select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// step stores the pending package, reading the next one from the feed if there is none.
+// The package stays pending while resolving or storing it fails for a reason that may pass.
+func (in *Ingester) step(ctx context.Context) error {
+	if in.pending == nil {
+		pkg, err := in.Feed.Next(ctx)
+		if err != nil {
+			return err
+		}
+		if pkg == nil {
+			return nil // Nothing new
+		}
+		in.pending = pkg
+	}
+	pkg := in.pending
+	km, err := in.resolve(ctx, pkg)
+	if errors.Is(err, errRetry) {
+		return fmt.Errorf("failed to resolve killmail %d: %w", pkg.ID(), err)
+	}
+	if err != nil {
+		in.pending = nil
+		in.count(func(s *Stats) { s.Skipped++ })
+		log.Printf("Skipping killmail %d: %v", pkg.ID(), err)
+		return nil
+	}
+	// The feed has already handed this killmail over, so store it even when shutting down
+	inserted, err := storeKillmail(context.WithoutCancel(ctx), km)
+	if err != nil {
+		return fmt.Errorf("failed to store killmail %d: %w", km.KillmailID, err)
+	}
+	in.pending = nil
+	in.count(func(s *Stats) {
+		if inserted {
+			s.Inserted++
+		} else {
+			s.Updated++
+		}
+	})
+	return nil
+}
+
+// resolve turns a package into a killmail row, fetching the ESI killmail if the feed did not embed it.
+// Fetch failures worth retrying (network errors, rate limits, server errors) wrap errRetry.
+func (in *Ingester) resolve(ctx context.Context, pkg *Package) (models.Killmails, error) {
+	esi := pkg.Killmail
+	if esi == nil {
+		esi = pkg.ESI
+	}
+	if esi == nil {
+		if pkg.ZKB.Href == "" {
+			return models.Killmails{}, fmt.Errorf("package has neither a killmail nor an ESI link")
+		}
+		esi = &ESIKillmail{}
+		status, err := getJSON(ctx, in.Client, in.UserAgent, pkg.ZKB.Href, esi)
+		if err != nil && (status == 0 || retryableStatus(status)) {
+			return models.Killmails{}, fmt.Errorf("%w: %v", errRetry, err)
+		}
+		if err != nil {
+			return models.Killmails{}, err
+		}
+	}
+	hash := pkg.ZKB.Hash
+	if hash == "" {
+		hash = pkg.Hash
+	}
+	return ToKillmail(esi, hash, pkg.ZKB)
+}
+
+// ToKillmail maps an ESI killmail and its zKillboard values onto a killmails row.
+// The killing ship is the ship of the attacker who landed the final blow.
+func ToKillmail(esi *ESIKillmail, hash string, zkb ZKB) (models.Killmails, error) {
+	if esi.KillmailID == 0 || esi.SolarSystemID == 0 {
+		return models.Killmails{}, fmt.Errorf("killmail is missing its ID or solar system")
+	}
+	killTime, err := time.Parse(time.RFC3339, esi.KillmailTime)
+	if err != nil {
+		return models.Killmails{}, fmt.Errorf("invalid killmail_time %q: %w", esi.KillmailTime, err)
+	}
+	var killShip int64
+	for i, a := range esi.Attackers {
+		if a.FinalBlow || (i == 0 && killShip == 0) {
+			killShip = a.ShipTypeID
+		}
+		if a.FinalBlow {
+			break
+		}
+	}
+	return models.Killmails{
+		KillmailID:     esi.KillmailID,
+		KillmailHash:   hash,
+		SolarSystemID:  esi.SolarSystemID,
+		KillmailTime:   killTime.UTC().Format(time.RFC3339),
+		DestroyedValue: zkb.DestroyedValue,
+		DroppedValue:   zkb.DroppedValue,
+		TotalValue:     zkb.TotalValue,
+		FittedValue:    zkb.FittedValue,
+		VictimShip:     esi.Victim.ShipTypeID,
+		KillShip:       killShip,
+	}, nil
+}
@@ -0,0 +1,272 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/astrocartics-xyz/Astrocartics-API/models"
+)
+
+func decodeTestKillmail(t *testing.T) (*ESIKillmail, ZKB) {
+	t.Helper()
+	var esi ESIKillmail
+	if err := json.Unmarshal([]byte(testKillmail), &esi); err != nil {
+		t.Fatal(err)
+	}
+	var zkb ZKB
+	if err := json.Unmarshal([]byte(testZKB), &zkb); err != nil {
+		t.Fatal(err)
+	}
+	return &esi, zkb
+}
+
+func TestToKillmail(t *testing.T) {
+	esi, zkb := decodeTestKillmail(t)
+	km, err := ToKillmail(esi, "abc123", zkb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if km.KillmailID != 123456789 || km.KillmailHash != "abc123" || km.SolarSystemID != 30000142 {
+		t.Errorf("unexpected identity: %+v", km)
+	}
+	if km.KillmailTime != "2026-10-18T12:34:56Z" {
+		t.Errorf("KillmailTime = %q", km.KillmailTime)
+	}
+	if km.VictimShip != 587 {
+		t.Errorf("VictimShip = %d, want 587", km.VictimShip)
+	}
+	// The final blow wins over the first attacker
+	if km.KillShip != 17738 {
+		t.Errorf("KillShip = %d, want 17738", km.KillShip)
+	}
+	if km.FittedValue != 1.5 || km.DroppedValue != 2.5 || km.DestroyedValue != 3.5 || km.TotalValue != 6 {
+		t.Errorf("unexpected values: %+v", km)
+	}
+}
+
+func TestToKillmailNormalisesTime(t *testing.T) {
+	esi, _ := decodeTestKillmail(t)
+	esi.KillmailTime = "2026-10-18T14:34:56+02:00"
+	km, err := ToKillmail(esi, "", ZKB{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if km.KillmailTime != "2026-10-18T12:34:56Z" {
+		t.Errorf("KillmailTime = %q, want UTC", km.KillmailTime)
+	}
+}
+
+func TestToKillmailWithoutFinalBlow(t *testing.T) {
+	esi, _ := decodeTestKillmail(t)
+	for i := range esi.Attackers {
+		esi.Attackers[i].FinalBlow = false
+	}
+	km, err := ToKillmail(esi, "", ZKB{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if km.KillShip != 11198 {
+		t.Errorf("KillShip = %d, want the first attacker's 11198", km.KillShip)
+	}
+}
+
+func TestToKillmailInvalid(t *testing.T) {
+	esi, _ := decodeTestKillmail(t)
+	esi.SolarSystemID = 0
+	if _, err := ToKillmail(esi, "", ZKB{}); err == nil {
+		t.Error("expected an error without a solar system")
+	}
+	esi, _ = decodeTestKillmail(t)
+	esi.KillmailTime = "yesterday"
+	if _, err := ToKillmail(esi, "", ZKB{}); err == nil {
+		t.Error("expected an error for an invalid time")
+	}
+}
+
+func TestResolveFetchesLinkedKillmail(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/killmails/123456789/abc123/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(testKillmail))
+	}))
+	defer srv.Close()
+
+	in := &Ingester{Client: srv.Client()}
+	_, zkb := decodeTestKillmail(t)
+	zkb.Href = srv.URL + "/killmails/123456789/abc123/"
+	km, err := in.resolve(context.Background(), &Package{KillID: 123456789, ZKB: zkb})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if km.KillmailID != 123456789 || km.KillmailHash != "abc123" || km.TotalValue != 6 {
+		t.Errorf("unexpected killmail: %+v", km)
+	}
+
+	if _, err := in.resolve(context.Background(), &Package{KillID: 1}); err == nil {
+		t.Error("expected an error for a package without a killmail or link")
+	}
+}
+
+// scriptedFeed replays a fixed list of results, then blocks until the context is cancelled.
+type scriptedFeed struct {
+	mu      sync.Mutex
+	results []feedResult
+	calls   []time.Time
+	done    chan struct{}
+}
+
+type feedResult struct {
+	pkg *Package
+	err error
+}
+
+func (f *scriptedFeed) Next(ctx context.Context) (*Package, error) {
+	f.mu.Lock()
+	f.calls = append(f.calls, time.Now())
+	if len(f.results) == 0 {
+		f.mu.Unlock()
+		close(f.done)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	r := f.results[0]
+	f.results = f.results[1:]
+	f.mu.Unlock()
+	return r.pkg, r.err
+}
+
+func TestRunBacksOff(t *testing.T) {
+	defer func(min, max time.Duration) { minBackoff, maxBackoff = min, max }(minBackoff, maxBackoff)
+	minBackoff, maxBackoff = 20*time.Millisecond, 40*time.Millisecond
+
+	feedErr := errors.New("feed down")
+	feed := &scriptedFeed{done: make(chan struct{}), results: []feedResult{
+		{err: feedErr},             // waits 20ms
+		{err: feedErr},             // waits 40ms
+		{err: feedErr},             // capped at 40ms
+		{pkg: &Package{KillID: 1}}, // unusable package, skipped without touching the database
+		{},                         // empty poll resets the backoff
+		{err: feedErr},             // waits 20ms again
+	}}
+	in := &Ingester{Feed: feed}
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { errc <- in.Run(ctx) }()
+
+	select {
+		case <-feed.done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("feed was not drained")
+	}
+	cancel()
+	if err := <-errc; err != nil {
+		t.Fatalf("Run returned %v after cancellation, want nil", err)
+	}
+
+	stats := in.Stats()
+	if stats.Errors != 4 || stats.Skipped != 1 || stats.Inserted != 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	feed.mu.Lock()
+	defer feed.mu.Unlock()
+	want := []time.Duration{20, 40, 40, 0, 0, 20}
+	for i, w := range want {
+		gap := feed.calls[i+1].Sub(feed.calls[i])
+		if min := w * time.Millisecond; gap < min {
+			t.Errorf("call %d came %v after the previous one, want at least %v", i+1, gap, min)
+		}
+	}
+	// The last error waited the minimum again, not the cap
+	if gap := feed.calls[6].Sub(feed.calls[5]); gap >= maxBackoff {
+		t.Errorf("backoff was not reset after a success, waited %v", gap)
+	}
+}
+
+func TestRunRetriesPendingKillmail(t *testing.T) {
+	defer func(min, max time.Duration) { minBackoff, maxBackoff = min, max }(minBackoff, maxBackoff)
+	minBackoff, maxBackoff = time.Millisecond, time.Millisecond
+	defer func(store func(context.Context, models.Killmails) (bool, error)) { storeKillmail = store }(storeKillmail)
+
+	var mu sync.Mutex
+	var stored []int64
+	storeFailed := false
+	storeKillmail = func(ctx context.Context, km models.Killmails) (bool, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		// The database is down for the first attempt at the second killmail
+		if km.KillmailID == 2 && !storeFailed {
+			storeFailed = true
+			return false, errors.New("connection refused")
+		}
+		stored = append(stored, km.KillmailID)
+		return true, nil
+	}
+
+	var fetches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+			case "/killmails/123456789/abc123/":
+				// ESI fails once before it serves the killmail
+				if fetches.Add(1) == 1 {
+					http.Error(w, "down", http.StatusBadGateway)
+					return
+				}
+				w.Write([]byte(testKillmail))
+			default:
+				http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	esi, zkb := decodeTestKillmail(t)
+	linked := zkb
+	linked.Href = srv.URL + "/killmails/123456789/abc123/"
+	gone := zkb
+	gone.Href = srv.URL + "/killmails/3/gone/"
+	second := *esi
+	second.KillmailID = 2
+	feed := &scriptedFeed{done: make(chan struct{}), results: []feedResult{
+		{pkg: &Package{KillID: 123456789, ZKB: linked}},
+		{pkg: &Package{KillID: 2, Killmail: &second, ZKB: zkb}},
+		{pkg: &Package{KillID: 3, ZKB: gone}}, // ESI does not know it, skipped for good
+	}}
+	in := &Ingester{Feed: feed, Client: srv.Client()}
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { errc <- in.Run(ctx) }()
+
+	select {
+		case <-feed.done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("feed was not drained")
+	}
+	cancel()
+	if err := <-errc; err != nil {
+		t.Fatalf("Run returned %v after cancellation, want nil", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(stored) != 2 || stored[0] != 123456789 || stored[1] != 2 {
+		t.Errorf("stored %v, want [123456789 2]", stored)
+	}
+	stats := in.Stats()
+	if stats.Inserted != 2 || stats.Errors != 2 || stats.Skipped != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	// Retries never polled the feed: one call per package and the final blocking one
+	feed.mu.Lock()
+	defer feed.mu.Unlock()
+	if len(feed.calls) != 4 {
+		t.Errorf("feed polled %d times, want 4", len(feed.calls))
+	}
+}
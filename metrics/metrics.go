@@ -0,0 +1,100 @@
+// Package metrics defines the Prometheus metrics the API server exposes on its metrics listener.
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "astrocartics"
+
+// UnmatchedRoute labels requests that matched no route, so unknown paths cannot inflate the label set.
+const UnmatchedRoute = "unmatched"
+
+var (
+	// HTTPRequests counts served requests by method, chi route pattern and status code.
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests served, by method, route pattern and status code.",
+	}, []string{"method", "route", "status"})
+
+	// HTTPRequestDuration observes request latency by method and chi route pattern.
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency, by method and route pattern.",
+		Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"method", "route"})
+
+	// DBQueryDuration observes how long each dba function spends in the database.
+	DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Database query latency, by dba function.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"query"})
+
+	// CacheLookups counts cache lookups by cache and result (hit, miss or error).
+	CacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_lookups_total",
+		Help:      "Cache lookups, by cache and result (hit, miss or error).",
+	}, []string{"cache", "result"})
+)
+
+// Cache lookup results.
+const (
+	CacheHit   = "hit"
+	CacheMiss  = "miss"
+	CacheError = "error"
+)
+
+// ObserveQuery records the time since start as a query of the named dba function.
+func ObserveQuery(name string, start time.Time) {
+	DBQueryDuration.WithLabelValues(name).Observe(time.Since(start).Seconds())
+}
+
+// RegisterDBStats exposes the connection pool statistics of db.
+func RegisterDBStats(db *sql.DB) {
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, namespace))
+}
+
+// ingestionLagTimeout bounds the query behind each ingestion lag reading.
+const ingestionLagTimeout = 5 * time.Second
+
+// RegisterIngestionLag exposes the age of the newest killmail, read through latest on every scrape.
+// latest returns the zero time when there are no killmails, which is reported as NaN.
+func RegisterIngestionLag(latest func(ctx context.Context) (time.Time, error)) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "ingestion_lag_seconds",
+		Help:      "Seconds since the killmail_time of the newest stored killmail.",
+	}, func() float64 {
+		ctx, cancel := context.WithTimeout(context.Background(), ingestionLagTimeout)
+		defer cancel()
+		t, err := latest(ctx)
+		if err != nil {
+			slog.Error("Error reading the newest killmail time", "err", err)
+			return math.NaN()
+		}
+		if t.IsZero() {
+			return math.NaN()
+		}
+		return time.Since(t).Seconds()
+	})
+}
+
+// Handler serves the registered metrics, along with the Go runtime and process metrics.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
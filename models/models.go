@@ -151,3 +151,224 @@ type SystemKillCount struct {
 	TotalKills int		`json:"total_kills"`
 }
 
+
+// ActivityCell is one day-of-week/hour-of-day bucket of kill activity.
+type ActivityCell struct {
+	DayOfWeek int     `json:"day_of_week"` // 0 = Monday ... 6 = Sunday
+	Hour      int     `json:"hour"`        // 0 - 23
+	Kills     int     `json:"kills"`
+	Value     float64 `json:"value"`
+}
+
+// swagger:model ActivityProfile
+type ActivityProfile struct {
+	Scope       string      `json:"scope"` // system, constellation or region
+	ID          int         `json:"id"`
+	Name        string      `json:"name"`
+	Mode        string      `json:"mode"`
+	Timezone    string      `json:"timezone"`
+	WindowStart string      `json:"window_start"`
+	WindowEnd   string      `json:"window_end"`
+	TotalKills  int         `json:"total_kills"`
+	TotalValue  float64     `json:"total_value"`
+	Days        []string    `json:"days"`  // Row labels, Monday first
+	Kills       [][]int     `json:"kills"` // 7x24 matrix of kill counts, indexed [day][hour]
+	Value       [][]float64 `json:"value"` // 7x24 matrix of ISK destroyed, indexed [day][hour]
+}
+
+// swagger:model TopKillmail
+type TopKillmail struct {
+	Killmails
+	SystemName string `json:"system_name"`
+	RegionID   int    `json:"region_id"`
+	RegionName string `json:"region_name"`
+}
+
+// BattleSystem summarises the kills in one system that took part in a battle.
+type BattleSystem struct {
+	SystemID   int     `json:"system_id"`
+	SystemName string  `json:"system_name"`
+	Kills      int     `json:"kills"`
+	TotalValue float64 `json:"total_value"`
+}
+
+// swagger:model Battle
+type Battle struct {
+	BattleID   int64          `json:"battle_id"` // ID of the first killmail in the battle
+	RegionID   int            `json:"region_id"`
+	RegionName string         `json:"region_name"`
+	StartTime  string         `json:"start_time"`
+	EndTime    string         `json:"end_time"`
+	KillCount  int            `json:"kill_count"`
+	TotalValue float64        `json:"total_value"`
+	Systems    []BattleSystem `json:"systems"`
+	Killmails  []TopKillmail  `json:"killmails,omitempty"` // Only included when fetching a single battle
+}
+
+// swagger:model CampAlert
+type CampAlert struct {
+	SystemID          int     `json:"system_id"`
+	SystemName        string  `json:"system_name"`
+	RegionID          int     `json:"region_id"`
+	RegionName        string  `json:"region_name"`
+	Score             float64 `json:"score"` // 0 - 100, higher means more likely an active camp
+	Kills             int     `json:"kills"`
+	DistinctKillShips int     `json:"distinct_kill_ships"`
+	TopKillShip       int64   `json:"top_kill_ship"`       // Most repeated final-blow ship type
+	TopKillShipKills  int     `json:"top_kill_ship_kills"`
+	PathKills         int     `json:"path_kills"`          // Kills next door on the route within minutes of this system's
+	FirstKill         string  `json:"first_kill"`
+	LastKill          string  `json:"last_kill"`
+}
+
+// ValueBin is one histogram bin of killmail values, covering Min <= value < Max.
+type ValueBin struct {
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Count int     `json:"count"`
+}
+
+// ValueDistribution summarises the distribution of one killmail value column.
+type ValueDistribution struct {
+	Count  int        `json:"count"`
+	Mean   float64    `json:"mean"`
+	Median float64    `json:"median"`
+	P90    float64    `json:"p90"`
+	P99    float64    `json:"p99"`
+	Min    float64    `json:"min"`
+	Max    float64    `json:"max"`
+	Bins   []ValueBin `json:"bins"` // Logarithmic bins between Min and Max
+}
+
+// swagger:model KillmailValueDistribution
+type KillmailValueDistribution struct {
+	Scope          string            `json:"scope,omitempty"`
+	ID             int               `json:"id,omitempty"`
+	Mode           string            `json:"mode"`
+	WindowStart    string            `json:"window_start"`
+	WindowEnd      string            `json:"window_end"`
+	TotalValue     ValueDistribution `json:"total_value"`
+	DestroyedValue ValueDistribution `json:"destroyed_value"`
+	FittedValue    ValueDistribution `json:"fitted_value"`
+}
+
+// Page wraps one page of a list endpoint, returned when limit or cursor is given.
+// swagger:model Page
+type Page struct {
+	Data  []map[string]interface{} `json:"data"`
+	Total int                      `json:"total"` // Items across all pages
+	Limit int                      `json:"limit"`
+	Next  *string                  `json:"next"` // Link to the next page, null on the last one
+}
+
+// PageRequest asks a list query for one page in the order of a field, continuing after a cursor.
+type PageRequest struct {
+	Limit int         // Rows per page, 0 returns every row
+	Sort  string      // JSON field to sort by, empty keeps the list's default order
+	Desc  bool
+	After *PageCursor // Last row of the previous page, nil starts at the first
+}
+
+// PageCursor is the position of a row in a sorted list: its sort value as text and its unique ID.
+type PageCursor struct {
+	Key string
+	ID  int
+}
+
+// SystemFilter narrows a systems listing. Nil and empty fields do not filter.
+type SystemFilter struct {
+	MinSecurity     *float64
+	MaxSecurity     *float64
+	SecurityClasses []string
+	SpectralClasses []string
+	RegionID        *int
+	ConstellationID *int
+	HasStation      *bool
+	MinPlanets      *int
+	BoundingBox     *BoundingBox
+}
+
+// BoundingBox is an axis-aligned box in universe coordinates (meters).
+type BoundingBox struct {
+	MinX float64
+	MinY float64
+	MinZ float64
+	MaxX float64
+	MaxY float64
+	MaxZ float64
+}
+
+// SearchResult is one match of a name search.
+// swagger:model SearchResult
+type SearchResult struct {
+	Type  string  `json:"type"` // region, constellation, system, planet or station
+	ID    int     `json:"id"`
+	Name  string  `json:"name"`
+	Score float64 `json:"score"` // 1 for an exact match, lower for weaker matches
+}
+
+// UniverseName is the category and name of an ID resolved by /universe/names.
+// swagger:model UniverseName
+type UniverseName struct {
+	ID       int    `json:"id"`
+	Category string `json:"category"` // region, constellation, system, planet, station or stargate
+	Name     string `json:"name"`
+}
+
+// UniverseEntity is an ID and its name.
+// swagger:model UniverseEntity
+type UniverseEntity struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// UniverseIDs groups the IDs resolved by /universe/ids by category.
+// swagger:model UniverseIDs
+type UniverseIDs struct {
+	Regions        []UniverseEntity `json:"regions,omitempty"`
+	Constellations []UniverseEntity `json:"constellations,omitempty"`
+	Systems        []UniverseEntity `json:"systems,omitempty"`
+	Planets        []UniverseEntity `json:"planets,omitempty"`
+	Stations       []UniverseEntity `json:"stations,omitempty"`
+	Stargates      []UniverseEntity `json:"stargates,omitempty"`
+}
+
+// APIKey describes an issued API key. The key itself is only shown once, when it is created.
+// swagger:model APIKey
+type APIKey struct {
+	APIKeyID  int     `json:"api_key_id"`
+	Prefix    string  `json:"prefix"` // First characters of the key
+	Name      string  `json:"name"`
+	Tier      string  `json:"tier"`
+	CreatedAt string  `json:"created_at"`
+	RevokedAt *string `json:"revoked_at"`
+}
+
+// NewAPIKey is a freshly issued API key, including the secret key.
+// swagger:model NewAPIKey
+type NewAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// NewAPIKeyRequest is the body of an API key request.
+// swagger:model NewAPIKeyRequest
+type NewAPIKeyRequest struct {
+	Name string `json:"name"`
+	Tier string `json:"tier"`
+}
+
+// ComponentStatus is the outcome of one readiness check.
+// swagger:model ComponentStatus
+type ComponentStatus struct {
+	Status    string  `json:"status"`           // "ok" or "fail"
+	Detail    string  `json:"detail,omitempty"` // Why the check failed, or what it found
+	LatencyMS float64 `json:"latency_ms"`
+}
+
+// Readiness reports whether the API can serve requests, with the status of each component it depends on.
+// swagger:model Readiness
+type Readiness struct {
+	Status     string                     `json:"status"` // "ok" or "unavailable"
+	Components map[string]ComponentStatus `json:"components"`
+}
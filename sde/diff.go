@@ -0,0 +1,139 @@
+package sde
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/astrocartics-xyz/Astrocartics-API/dba"
+	"github.com/astrocartics-xyz/Astrocartics-API/models"
+)
+
+// TableDiff lists the IDs that an import adds to, removes from or changes in one table.
+type TableDiff struct {
+	Table   string
+	Added   []int
+	Removed []int
+	Changed []int
+}
+
+// Empty reports whether the import leaves the table as it is.
+func (d TableDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// String summarises the diff, listing a few example IDs per kind of change.
+func (d TableDiff) String() string {
+	sample := func(ids []int) string {
+		const max = 5
+		parts := make([]string, 0, max)
+		for i, id := range ids {
+			if i == max {
+				parts = append(parts, "...")
+				break
+			}
+			parts = append(parts, fmt.Sprint(id))
+		}
+		return strings.Join(parts, ", ")
+	}
+	return fmt.Sprintf("%-15s +%d -%d ~%d  added [%s] removed [%s] changed [%s]",
+		d.Table, len(d.Added), len(d.Removed), len(d.Changed), sample(d.Added), sample(d.Removed), sample(d.Changed))
+}
+
+// diffRows compares two versions of a table by primary key.
+func diffRows[T any](table string, current []T, next []T, id func(T) int) TableDiff {
+	d := TableDiff{Table: table}
+	old := make(map[int]T, len(current))
+	for _, row := range current {
+		old[id(row)] = row
+	}
+	seen := make(map[int]bool, len(next))
+	for _, row := range next {
+		key := id(row)
+		seen[key] = true
+		prev, ok := old[key]
+		switch {
+			case !ok:
+				d.Added = append(d.Added, key)
+			case !reflect.DeepEqual(prev, row):
+				d.Changed = append(d.Changed, key)
+		}
+	}
+	for key := range old {
+		if !seen[key] {
+			d.Removed = append(d.Removed, key)
+		}
+	}
+	sort.Ints(d.Added)
+	sort.Ints(d.Removed)
+	sort.Ints(d.Changed)
+	return d
+}
+
+// Diff compares the current static data against an SDE dataset.
+func Diff(current *Dataset, next *Dataset) []TableDiff {
+	return []TableDiff{
+		diffRows("regions", current.Regions, next.Regions, func(r models.Region) int { return r.RegionID }),
+		diffRows("constellations", current.Constellations, next.Constellations, func(c models.Constellation) int { return c.ConstellationID }),
+		diffRows("systems", current.Systems, next.Systems, func(s models.System) int { return s.SystemID }),
+		diffRows("stargates", current.Stargates, next.Stargates, func(s models.Stargate) int { return s.StargateID }),
+		diffRows("planets", current.Planets, next.Planets, func(p models.Planet) int { return p.PlanetID }),
+		diffRows("stations", current.Stations, next.Stations, func(s models.Station) int { return s.StationID }),
+	}
+}
+
+// Current reads the static data currently in the database.
+func Current(ctx context.Context) (*Dataset, error) {
+	ds := &Dataset{}
+	var err error
+	if ds.Regions, err = dba.GetAllRegions(ctx); err != nil {
+		return nil, err
+	}
+	if ds.Constellations, err = dba.GetAllConstellations(ctx); err != nil {
+		return nil, err
+	}
+	if ds.Systems, err = dba.GetAllSystems(ctx); err != nil {
+		return nil, err
+	}
+	if ds.Stargates, err = dba.GetAllStargates(ctx); err != nil {
+		return nil, err
+	}
+	if ds.Planets, err = dba.GetAllPlanets(ctx); err != nil {
+		return nil, err
+	}
+	if ds.Stations, err = dba.GetAllStations(ctx); err != nil {
+		return nil, err
+	}
+	return ds, nil
+}
+
+// Tables converts the dataset into rows for dba.ReplaceStaticData, parents before children.
+func (ds *Dataset) Tables() []dba.StaticTable {
+	regions := dba.StaticTable{Name: "regions", Columns: []string{"region_id", "region_name"}}
+	for _, r := range ds.Regions {
+		regions.Rows = append(regions.Rows, []interface{}{r.RegionID, r.RegionName})
+	}
+	constellations := dba.StaticTable{Name: "constellations", Columns: []string{"constellation_id", "constellation_name", "region_id"}}
+	for _, c := range ds.Constellations {
+		constellations.Rows = append(constellations.Rows, []interface{}{c.ConstellationID, c.ConstellationName, c.RegionID})
+	}
+	systems := dba.StaticTable{Name: "systems", Columns: []string{"system_id", "system_name", "security_status", "security_class", "x_pos", "y_pos", "z_pos", "constellation_id", "spectral_class"}}
+	for _, s := range ds.Systems {
+		systems.Rows = append(systems.Rows, []interface{}{s.SystemID, s.SystemName, s.SecurityStatus, s.SecurityClass, s.XPos, s.YPos, s.ZPos, s.ConstellationID, s.SpectralClass})
+	}
+	stargates := dba.StaticTable{Name: "stargates", Columns: []string{"stargate_id", "stargate_name", "system_id", "destination_stargate_id", "destination_system_id"}}
+	for _, g := range ds.Stargates {
+		stargates.Rows = append(stargates.Rows, []interface{}{g.StargateID, g.StargateName, g.SystemID, g.DestinationStargateID, g.DestinationSystemID})
+	}
+	planets := dba.StaticTable{Name: "planets", Columns: []string{"planet_id", "planet_name", "system_id", "type", "moon_count", "asteroid_belt_count"}}
+	for _, p := range ds.Planets {
+		planets.Rows = append(planets.Rows, []interface{}{p.PlanetID, p.PlanetName, p.SystemID, p.Type, p.MoonCount, p.AsteroidBeltCount})
+	}
+	stations := dba.StaticTable{Name: "stations", Columns: []string{"station_id", "station_name", "system_id"}}
+	for _, s := range ds.Stations {
+		stations.Rows = append(stations.Rows, []interface{}{s.StationID, s.StationName, s.SystemID})
+	}
+	return []dba.StaticTable{regions, constellations, systems, stargates, planets, stations}
+}
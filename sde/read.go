@@ -0,0 +1,104 @@
+package sde
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// localized is an SDE name, which is either a plain string or a map of language -> string.
+type localized string
+
+func (l *localized) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*l = localized(s)
+		return nil
+	}
+	var names map[string]string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return fmt.Errorf("name is neither a string nor a localized map: %w", err)
+	}
+	*l = localized(names["en"])
+	return nil
+}
+
+// readFile decodes every record of an SDE file into a value built by newRecord, calling add
+// with the record key. Both the JSONL layout (one object per line, key in "_key") and the YAML
+// layout (a mapping of key -> object) are read; base is the file name without extension.
+// Returns false if neither file exists.
+func readFile(dir string, base string, newRecord func() interface{}, add func(key int64, record interface{}) error) (bool, error) {
+	jsonlPath := filepath.Join(dir, base+".jsonl")
+	if f, err := os.Open(jsonlPath); err == nil {
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 1024*1024), 64*1024*1024)
+		line := 0
+		for scanner.Scan() {
+			line++
+			if len(scanner.Bytes()) == 0 {
+				continue
+			}
+			var key struct {
+				Key int64 `json:"_key"`
+			}
+			if err := json.Unmarshal(scanner.Bytes(), &key); err != nil {
+				return true, fmt.Errorf("%s line %d: %w", jsonlPath, line, err)
+			}
+			record := newRecord()
+			if err := json.Unmarshal(scanner.Bytes(), record); err != nil {
+				return true, fmt.Errorf("%s line %d: %w", jsonlPath, line, err)
+			}
+			if err := add(key.Key, record); err != nil {
+				return true, fmt.Errorf("%s line %d: %w", jsonlPath, line, err)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return true, fmt.Errorf("failed to read %s: %w", jsonlPath, err)
+		}
+		return true, nil
+	}
+	yamlPath := filepath.Join(dir, base+".yaml")
+	data, err := os.ReadFile(yamlPath)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", yamlPath, err)
+	}
+	var records map[int64]interface{}
+	if err := yaml.Unmarshal(data, &records); err != nil {
+		return true, fmt.Errorf("failed to decode %s: %w", yamlPath, err)
+	}
+	// Round-trip each record through JSON so both layouts share the same struct tags
+	for key, raw := range records {
+		data, err := json.Marshal(raw)
+		if err != nil {
+			return true, fmt.Errorf("%s key %d: %w", yamlPath, key, err)
+		}
+		record := newRecord()
+		if err := json.Unmarshal(data, record); err != nil {
+			return true, fmt.Errorf("%s key %d: %w", yamlPath, key, err)
+		}
+		if err := add(key, record); err != nil {
+			return true, fmt.Errorf("%s key %d: %w", yamlPath, key, err)
+		}
+	}
+	return true, nil
+}
+
+// requireFile is readFile for files the import cannot do without.
+func requireFile(dir string, base string, newRecord func() interface{}, add func(key int64, record interface{}) error) error {
+	found, err := readFile(dir, base, newRecord, add)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("missing %s.jsonl or %s.yaml in %s", base, base, dir)
+	}
+	return nil
+}
@@ -0,0 +1,274 @@
+// Package sde loads the map data of the EVE Online Static Data Export into the API's static tables.
+package sde
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/astrocartics-xyz/Astrocartics-API/models"
+)
+
+// SDE record layouts. Only the fields the API stores are decoded.
+type regionRecord struct {
+	Name localized `json:"name"`
+}
+
+type constellationRecord struct {
+	Name     localized `json:"name"`
+	RegionID int       `json:"regionID"`
+}
+
+type systemRecord struct {
+	Name            localized `json:"name"`
+	ConstellationID int       `json:"constellationID"`
+	SecurityStatus  float64   `json:"securityStatus"`
+	SecurityClass   *string   `json:"securityClass"`
+	StarID          int64     `json:"starID"`
+	Position        struct {
+		X float64 `json:"x"`
+		Y float64 `json:"y"`
+		Z float64 `json:"z"`
+	} `json:"position"`
+}
+
+type starRecord struct {
+	SolarSystemID int `json:"solarSystemID"`
+	Statistics    struct {
+		SpectralClass *string `json:"spectralClass"`
+	} `json:"statistics"`
+}
+
+type stargateRecord struct {
+	Name          localized `json:"name"`
+	SolarSystemID int       `json:"solarSystemID"`
+	Destination   struct {
+		SolarSystemID int `json:"solarSystemID"`
+		StargateID    int `json:"stargateID"`
+	} `json:"destination"`
+}
+
+type planetRecord struct {
+	Name            localized `json:"name"`
+	SolarSystemID   int       `json:"solarSystemID"`
+	TypeID          int64     `json:"typeID"`
+	CelestialIndex  int       `json:"celestialIndex"`
+	MoonIDs         []int64   `json:"moonIDs"`
+	AsteroidBeltIDs []int64   `json:"asteroidBeltIDs"`
+}
+
+type stationRecord struct {
+	Name          localized `json:"name"`
+	SolarSystemID int       `json:"solarSystemID"`
+}
+
+type typeRecord struct {
+	Name localized `json:"name"`
+}
+
+// Dataset is the static data in the shape of the API's tables.
+type Dataset struct {
+	Regions        []models.Region
+	Constellations []models.Constellation
+	Systems        []models.System
+	Stargates      []models.Stargate
+	Planets        []models.Planet
+	Stations       []models.Station
+}
+
+// Load reads an SDE export directory. Regions, constellations, solar systems, stargates and planets
+// are required; stars (spectral classes), types (planet types) and NPC stations are used when present.
+func Load(dir string) (*Dataset, error) {
+	ds := &Dataset{}
+
+	regions := map[int]*models.Region{}
+	err := requireFile(dir, "mapRegions", func() interface{} { return &regionRecord{} }, func(key int64, rec interface{}) error {
+		r := rec.(*regionRecord)
+		regions[int(key)] = &models.Region{RegionID: int(key), RegionName: string(r.Name)}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	constellations := map[int]*models.Constellation{}
+	err = requireFile(dir, "mapConstellations", func() interface{} { return &constellationRecord{} }, func(key int64, rec interface{}) error {
+		c := rec.(*constellationRecord)
+		if regions[c.RegionID] == nil {
+			return fmt.Errorf("constellation %d references unknown region %d", key, c.RegionID)
+		}
+		constellations[int(key)] = &models.Constellation{ConstellationID: int(key), ConstellationName: string(c.Name), RegionID: c.RegionID}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Spectral classes live on the star, not the system
+	spectral := map[int]*string{}
+	_, err = readFile(dir, "mapStars", func() interface{} { return &starRecord{} }, func(key int64, rec interface{}) error {
+		s := rec.(*starRecord)
+		spectral[s.SolarSystemID] = s.Statistics.SpectralClass
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	systems := map[int]*models.System{}
+	err = requireFile(dir, "mapSolarSystems", func() interface{} { return &systemRecord{} }, func(key int64, rec interface{}) error {
+		s := rec.(*systemRecord)
+		c := constellations[s.ConstellationID]
+		if c == nil {
+			return fmt.Errorf("system %d references unknown constellation %d", key, s.ConstellationID)
+		}
+		systems[int(key)] = &models.System{
+			SystemID:        int(key),
+			SystemName:      string(s.Name),
+			SecurityStatus:  s.SecurityStatus,
+			SecurityClass:   s.SecurityClass,
+			XPos:            s.Position.X,
+			YPos:            s.Position.Y,
+			ZPos:            s.Position.Z,
+			ConstellationID: s.ConstellationID,
+			RegionID:        c.RegionID,
+			SpectralClass:   spectral[int(key)],
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stargates := map[int]*models.Stargate{}
+	err = requireFile(dir, "mapStargates", func() interface{} { return &stargateRecord{} }, func(key int64, rec interface{}) error {
+		g := rec.(*stargateRecord)
+		if systems[g.SolarSystemID] == nil {
+			return fmt.Errorf("stargate %d references unknown system %d", key, g.SolarSystemID)
+		}
+		stargates[int(key)] = &models.Stargate{
+			StargateID:            int(key),
+			StargateName:          string(g.Name),
+			SystemID:              g.SolarSystemID,
+			DestinationStargateID: g.Destination.StargateID,
+			DestinationSystemID:   g.Destination.SolarSystemID,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Stargates are named after the system they lead to
+	for _, g := range stargates {
+		if g.StargateName == "" {
+			if dest := systems[g.DestinationSystemID]; dest != nil {
+				g.StargateName = fmt.Sprintf("Stargate (%s)", dest.SystemName)
+			}
+		}
+	}
+
+	typeNames := map[int64]string{}
+	_, err = readFile(dir, "types", func() interface{} { return &typeRecord{} }, func(key int64, rec interface{}) error {
+		typeNames[key] = string(rec.(*typeRecord).Name)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	planets := map[int]*models.Planet{}
+	err = requireFile(dir, "mapPlanets", func() interface{} { return &planetRecord{} }, func(key int64, rec interface{}) error {
+		p := rec.(*planetRecord)
+		sys := systems[p.SolarSystemID]
+		if sys == nil {
+			return fmt.Errorf("planet %d references unknown system %d", key, p.SolarSystemID)
+		}
+		// Planets are named after their system and orbit, e.g. "Jita IV"
+		name := string(p.Name)
+		if name == "" {
+			name = fmt.Sprintf("%s %s", sys.SystemName, roman(p.CelestialIndex))
+		}
+		var planetType *string
+		if t, ok := typeNames[p.TypeID]; ok {
+			planetType = &t
+		}
+		planets[int(key)] = &models.Planet{
+			PlanetID:          int(key),
+			PlanetName:        name,
+			SystemID:          p.SolarSystemID,
+			Type:              planetType,
+			MoonCount:         len(p.MoonIDs),
+			AsteroidBeltCount: len(p.AsteroidBeltIDs),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stations := map[int]*models.Station{}
+	_, err = readFile(dir, "npcStations", func() interface{} { return &stationRecord{} }, func(key int64, rec interface{}) error {
+		s := rec.(*stationRecord)
+		if systems[s.SolarSystemID] == nil {
+			return fmt.Errorf("station %d references unknown system %d", key, s.SolarSystemID)
+		}
+		name := string(s.Name)
+		if name == "" {
+			name = fmt.Sprintf("%s Station %d", systems[s.SolarSystemID].SystemName, key)
+		}
+		stations[int(key)] = &models.Station{StationID: int(key), StationName: name, SystemID: s.SolarSystemID}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Flatten in ID order so diffs and writes are deterministic
+	for _, id := range sortedKeys(regions) {
+		ds.Regions = append(ds.Regions, *regions[id])
+	}
+	for _, id := range sortedKeys(constellations) {
+		ds.Constellations = append(ds.Constellations, *constellations[id])
+	}
+	for _, id := range sortedKeys(systems) {
+		ds.Systems = append(ds.Systems, *systems[id])
+	}
+	for _, id := range sortedKeys(stargates) {
+		ds.Stargates = append(ds.Stargates, *stargates[id])
+	}
+	for _, id := range sortedKeys(planets) {
+		ds.Planets = append(ds.Planets, *planets[id])
+	}
+	for _, id := range sortedKeys(stations) {
+		ds.Stations = append(ds.Stations, *stations[id])
+	}
+	return ds, nil
+}
+
+func sortedKeys[T any](m map[int]T) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+// roman formats a celestial index as a roman numeral, as used in planet names.
+func roman(n int) string {
+	if n <= 0 {
+		return "0"
+	}
+	numerals := []struct {
+		value  int
+		symbol string
+	}{{1000, "M"}, {900, "CM"}, {500, "D"}, {400, "CD"}, {100, "C"}, {90, "XC"}, {50, "L"}, {40, "XL"}, {10, "X"}, {9, "IX"}, {5, "V"}, {4, "IV"}, {1, "I"}}
+	var b strings.Builder
+	for _, num := range numerals {
+		for n >= num.value {
+			b.WriteString(num.symbol)
+			n -= num.value
+		}
+	}
+	return b.String()
+}
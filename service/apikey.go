@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/astrocartics-xyz/Astrocartics-API/dba"
+	"github.com/astrocartics-xyz/Astrocartics-API/metrics"
+	"github.com/astrocartics-xyz/Astrocartics-API/models"
+	"github.com/astrocartics-xyz/Astrocartics-API/tracing"
+)
+
+// apiKeyPrefix starts every issued key, so leaked keys are easy to recognise.
+const apiKeyPrefix = "ak_"
+
+// apiKeyLookupTTL is how long a key lookup is reused, which is also how long a revoked key keeps working.
+const apiKeyLookupTTL = time.Minute
+
+var apiKeyLookups = struct {
+	mu      sync.Mutex
+	entries map[string]apiKeyLookup
+}{entries: map[string]apiKeyLookup{}}
+
+type apiKeyLookup struct {
+	key     *models.APIKey
+	fetched time.Time
+}
+
+// hashAPIKey returns the hash a key is stored under.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateAPIKey issues a new key for a tier. The returned key is not stored and cannot be shown again.
+func CreateAPIKey(ctx context.Context, name string, tier string) (models.NewAPIKey, error) {
+	ctx, span := tracing.Start(ctx, "service.CreateAPIKey")
+	defer span.End()
+	var created models.NewAPIKey
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return created, fmt.Errorf("invalid request: name is required")
+	}
+	if _, ok := Tiers[tier]; !ok || tier == TierAnonymous {
+		return created, fmt.Errorf("invalid request: unknown tier %s", tier)
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return created, fmt.Errorf("failed to generate API key: %w", err)
+	}
+	key := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	stored, err := dba.CreateAPIKey(ctx, hashAPIKey(key), key[:len(apiKeyPrefix)+6], name, tier)
+	if err != nil {
+		return created, err
+	}
+	return models.NewAPIKey{APIKey: stored, Key: key}, nil
+}
+
+// GetAllAPIKeys lists the issued keys, without the keys themselves.
+func GetAllAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	ctx, span := tracing.Start(ctx, "service.GetAllAPIKeys")
+	defer span.End()
+	return dba.GetAllAPIKeys(ctx)
+}
+
+// RevokeAPIKey revokes a key. Returns false if there was no active key with that ID.
+func RevokeAPIKey(ctx context.Context, id int) (bool, error) {
+	ctx, span := tracing.Start(ctx, "service.RevokeAPIKey")
+	defer span.End()
+	return dba.RevokeAPIKey(ctx, id)
+}
+
+// LookupAPIKey returns the active key matching key, or nil if it is unknown or revoked.
+// Lookups are cached for a minute, so a revoked key may keep working for that long.
+func LookupAPIKey(ctx context.Context, key string) (*models.APIKey, error) {
+	ctx, span := tracing.Start(ctx, "service.LookupAPIKey")
+	defer span.End()
+	hash := hashAPIKey(key)
+	apiKeyLookups.mu.Lock()
+	entry, ok := apiKeyLookups.entries[hash]
+	apiKeyLookups.mu.Unlock()
+	if ok && time.Since(entry.fetched) < apiKeyLookupTTL {
+		metrics.CacheLookups.WithLabelValues("api_key", metrics.CacheHit).Inc()
+		return entry.key, nil
+	}
+	metrics.CacheLookups.WithLabelValues("api_key", metrics.CacheMiss).Inc()
+	found, err := dba.GetAPIKeyByHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if found != nil && found.RevokedAt != nil {
+		found = nil
+	}
+	apiKeyLookups.mu.Lock()
+	defer apiKeyLookups.mu.Unlock()
+	// Unknown keys are cached too, so guessing keys cannot hammer the database; bound the map
+	if len(apiKeyLookups.entries) >= 10000 {
+		apiKeyLookups.entries = map[string]apiKeyLookup{}
+	}
+	apiKeyLookups.entries[hash] = apiKeyLookup{key: found, fetched: time.Now()}
+	return found, nil
+}
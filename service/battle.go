@@ -0,0 +1,320 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/astrocartics-xyz/Astrocartics-API/dba"
+	"github.com/astrocartics-xyz/Astrocartics-API/models"
+	"github.com/astrocartics-xyz/Astrocartics-API/tracing"
+)
+
+// Battles are built by clustering killmails: two kills belong to the same battle when they
+// happened in the same or stargate-adjacent systems and less than the gap apart.
+const (
+	DefaultBattleGap      = 15 * time.Minute
+	DefaultBattleMinKills = 5
+	// battleBlock is the span of UTC time clustered at once. Every kill of the universe in a block
+	// is clustered together, so listings and single battles always see the same input and agree,
+	// and fights across region borders stay whole; a fight running past midnight UTC is split.
+	battleBlock = 24 * time.Hour
+)
+
+// battleCluster is a battle under construction.
+type battleCluster struct {
+	kills   []models.TopKillmail
+	times   []time.Time
+	systems map[int]bool
+}
+
+func (b *battleCluster) last() time.Time {
+	return b.times[len(b.times)-1]
+}
+
+// touches reports whether a kill in systemID is in or next to one of the battle's systems.
+func (b *battleCluster) touches(systemID int, adjacency map[int]map[int]bool) bool {
+	if b.systems[systemID] {
+		return true
+	}
+	for neighbour := range adjacency[systemID] {
+		if b.systems[neighbour] {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *battleCluster) add(k models.TopKillmail, t time.Time) {
+	b.kills = append(b.kills, k)
+	b.times = append(b.times, t)
+	b.systems[k.SolarSystemID] = true
+}
+
+// merge folds other into b, keeping kills in time order.
+func (b *battleCluster) merge(other *battleCluster) {
+	for i := range other.kills {
+		b.add(other.kills[i], other.times[i])
+	}
+	sort.Sort(byKillTime{b})
+}
+
+// byKillTime sorts a cluster's kills by time, then killmail ID.
+type byKillTime struct{ b *battleCluster }
+
+func (s byKillTime) Len() int { return len(s.b.kills) }
+func (s byKillTime) Less(i, j int) bool {
+	if !s.b.times[i].Equal(s.b.times[j]) {
+		return s.b.times[i].Before(s.b.times[j])
+	}
+	return s.b.kills[i].KillmailID < s.b.kills[j].KillmailID
+}
+func (s byKillTime) Swap(i, j int) {
+	s.b.kills[i], s.b.kills[j] = s.b.kills[j], s.b.kills[i]
+	s.b.times[i], s.b.times[j] = s.b.times[j], s.b.times[i]
+}
+
+// clusterBattles groups kills (sorted oldest first) into battles.
+func clusterBattles(kills []models.TopKillmail, adjacency map[int]map[int]bool, gap time.Duration) ([]*battleCluster, error) {
+	var active, done []*battleCluster
+	for _, k := range kills {
+		t, err := time.Parse(time.RFC3339, k.KillmailTime)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse killmail time %q: %w", k.KillmailTime, err)
+		}
+		// Retire battles that have gone quiet and collect the ones this kill joins
+		var matched []*battleCluster
+		stillActive := active[:0]
+		for _, b := range active {
+			if t.Sub(b.last()) >= gap {
+				done = append(done, b)
+				continue
+			}
+			stillActive = append(stillActive, b)
+			if b.touches(k.SolarSystemID, adjacency) {
+				matched = append(matched, b)
+			}
+		}
+		active = stillActive
+		// Start a new battle, or join (and possibly bridge) existing ones
+		if len(matched) == 0 {
+			b := &battleCluster{systems: map[int]bool{}}
+			b.add(k, t)
+			active = append(active, b)
+			continue
+		}
+		target := matched[0]
+		for _, other := range matched[1:] {
+			target.merge(other)
+		}
+		target.add(k, t)
+		if len(matched) > 1 {
+			merged := active[:0]
+			for _, b := range active {
+				keep := true
+				for _, other := range matched[1:] {
+					if b == other {
+						keep = false
+						break
+					}
+				}
+				if keep {
+					merged = append(merged, b)
+				}
+			}
+			active = merged
+		}
+	}
+	return append(done, active...), nil
+}
+
+// clusterBlocks groups kills (sorted oldest first) into battles, clustering each battleBlock
+// of kills on its own.
+func clusterBlocks(kills []models.TopKillmail, adjacency map[int]map[int]bool, gap time.Duration) ([]*battleCluster, error) {
+	var clusters []*battleCluster
+	for start := 0; start < len(kills); {
+		t, err := time.Parse(time.RFC3339, kills[start].KillmailTime)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse killmail time %q: %w", kills[start].KillmailTime, err)
+		}
+		blockEnd := t.UTC().Truncate(battleBlock).Add(battleBlock)
+		end := start + 1
+		for end < len(kills) {
+			t, err := time.Parse(time.RFC3339, kills[end].KillmailTime)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse killmail time %q: %w", kills[end].KillmailTime, err)
+			}
+			if !t.Before(blockEnd) {
+				break
+			}
+			end++
+		}
+		block, err := clusterBattles(kills[start:end], adjacency, gap)
+		if err != nil {
+			return nil, err
+		}
+		clusters = append(clusters, block...)
+		start = end
+	}
+	return clusters, nil
+}
+
+// touchesRegion reports whether any kill of the battle happened in regionID.
+func (b *battleCluster) touchesRegion(regionID int) bool {
+	for _, k := range b.kills {
+		if k.RegionID == regionID {
+			return true
+		}
+	}
+	return false
+}
+
+// toBattle summarises a cluster. Killmails are only attached when withKillmails is set.
+func (b *battleCluster) toBattle(withKillmails bool) models.Battle {
+	first := b.kills[0]
+	battle := models.Battle{
+		BattleID:   first.KillmailID,
+		RegionID:   first.RegionID,
+		RegionName: first.RegionName,
+		StartTime:  b.times[0].UTC().Format(time.RFC3339),
+		EndTime:    b.last().UTC().Format(time.RFC3339),
+		KillCount:  len(b.kills),
+	}
+	// Per system breakdown, busiest system first
+	bySystem := map[int]*models.BattleSystem{}
+	for _, k := range b.kills {
+		battle.TotalValue += k.TotalValue
+		sys, ok := bySystem[k.SolarSystemID]
+		if !ok {
+			sys = &models.BattleSystem{SystemID: k.SolarSystemID, SystemName: k.SystemName}
+			bySystem[k.SolarSystemID] = sys
+		}
+		sys.Kills++
+		sys.TotalValue += k.TotalValue
+	}
+	battle.Systems = make([]models.BattleSystem, 0, len(bySystem))
+	for _, sys := range bySystem {
+		battle.Systems = append(battle.Systems, *sys)
+	}
+	sort.Slice(battle.Systems, func(i, j int) bool {
+		if battle.Systems[i].Kills != battle.Systems[j].Kills {
+			return battle.Systems[i].Kills > battle.Systems[j].Kills
+		}
+		return battle.Systems[i].SystemID < battle.Systems[j].SystemID
+	})
+	if withKillmails {
+		battle.Killmails = b.kills
+	}
+	return battle
+}
+
+// getAdjacency maps each system to the systems its stargates lead to.
+func getAdjacency(ctx context.Context) (map[int]map[int]bool, error) {
+	stargates, err := dba.GetAllStargates(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch stargates: %w", err)
+	}
+	adjacency := map[int]map[int]bool{}
+	link := func(a, b int) {
+		if adjacency[a] == nil {
+			adjacency[a] = map[int]bool{}
+		}
+		adjacency[a][b] = true
+	}
+	for _, sg := range stargates {
+		link(sg.SystemID, sg.DestinationSystemID)
+		link(sg.DestinationSystemID, sg.SystemID)
+	}
+	return adjacency, nil
+}
+
+// modeWindowStart returns the start of the sliding window of a kill mode ending at now.
+func modeWindowStart(mode string, now time.Time) time.Time {
+	switch mode {
+		case "hour":
+			return now.Add(-time.Hour)
+		case "day":
+			return now.AddDate(0, 0, -1)
+		case "week":
+			return now.AddDate(0, 0, -7)
+		default:
+			return now.AddDate(0, -1, 0)
+	}
+}
+
+// GetRecentBattlesByRegion lists battles with kills in a region that were active during the window
+// of the given mode, most recently active first. Battles with fewer than minKills kills are dropped.
+// A battle spilling over from a neighbouring region is listed in both, under the region of its first kill.
+func GetRecentBattlesByRegion(ctx context.Context, regionID int, mode string, gap time.Duration, minKills int) ([]models.Battle, error) {
+	ctx, span := tracing.Start(ctx, "service.GetRecentBattlesByRegion")
+	defer span.End()
+	// Validate mode
+	if !isValidKillMode(mode) {
+		return nil, fmt.Errorf("invalid mode: %s; supported: 'hour','day','week','month'", mode)
+	}
+	now := time.Now().UTC()
+	windowStart := modeWindowStart(mode, now)
+	// Load whole blocks, so battles already in progress at the window start are not cut short
+	kills, err := dba.GetKillmailsBetween(ctx, windowStart.Truncate(battleBlock), now.Add(time.Minute))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch killmails: %w", err)
+	}
+	adjacency, err := getAdjacency(ctx)
+	if err != nil {
+		return nil, err
+	}
+	clusters, err := clusterBlocks(kills, adjacency, gap)
+	if err != nil {
+		return nil, err
+	}
+	battles := make([]models.Battle, 0)
+	for _, c := range clusters {
+		if len(c.kills) < minKills || c.last().Before(windowStart) || !c.touchesRegion(regionID) {
+			continue
+		}
+		battles = append(battles, c.toBattle(false))
+	}
+	sort.Slice(battles, func(i, j int) bool {
+		if battles[i].EndTime != battles[j].EndTime {
+			return battles[i].EndTime > battles[j].EndTime
+		}
+		return battles[i].BattleID > battles[j].BattleID
+	})
+	return battles, nil
+}
+
+// GetBattle rebuilds the battle whose first killmail is battleID, including its killmails.
+// Returns nil if battleID does not start a battle under the given gap.
+func GetBattle(ctx context.Context, battleID int64, gap time.Duration) (*models.Battle, error) {
+	ctx, span := tracing.Start(ctx, "service.GetBattle")
+	defer span.End()
+	killTime, regionID, err := dba.GetKillmailLocation(ctx, battleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch killmail: %w", err)
+	}
+	if regionID == 0 {
+		return nil, nil
+	}
+	// The block of the first kill holds the whole battle, clustered as the listings cluster it
+	blockStart := killTime.UTC().Truncate(battleBlock)
+	kills, err := dba.GetKillmailsBetween(ctx, blockStart, blockStart.Add(battleBlock))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch killmails: %w", err)
+	}
+	adjacency, err := getAdjacency(ctx)
+	if err != nil {
+		return nil, err
+	}
+	clusters, err := clusterBattles(kills, adjacency, gap)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range clusters {
+		if c.kills[0].KillmailID == battleID {
+			battle := c.toBattle(true)
+			return &battle, nil
+		}
+	}
+	return nil, nil
+}
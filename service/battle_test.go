@@ -0,0 +1,136 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/astrocartics-xyz/Astrocartics-API/models"
+)
+
+// testKill is a kill in systemID at minute offset from 2026-10-18 12:00 UTC.
+func testKill(id int64, systemID int, minute int) models.TopKillmail {
+	t := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC).Add(time.Duration(minute) * time.Minute)
+	return killAt(id, systemID, t.Format(time.RFC3339))
+}
+
+// killAt is a kill in systemID at an RFC 3339 time.
+func killAt(id int64, systemID int, killTime string) models.TopKillmail {
+	return models.TopKillmail{Killmails: models.Killmails{KillmailID: id, SolarSystemID: systemID, KillmailTime: killTime}}
+}
+
+func TestClusterBattles(t *testing.T) {
+	// 1 - 2 - 3 form a pipe, 4 is unconnected
+	adjacency := map[int]map[int]bool{
+		1: {2: true},
+		2: {1: true, 3: true},
+		3: {2: true},
+	}
+	tests := []struct {
+		name  string
+		kills []models.TopKillmail
+		want  [][]int64 // Killmail IDs of each battle, by first kill
+	}{
+		{
+			name:  "same system within the gap",
+			kills: []models.TopKillmail{testKill(1, 1, 0), testKill(2, 1, 10), testKill(3, 1, 24)},
+			want:  [][]int64{{1, 2, 3}},
+		},
+		{
+			name:  "exactly the gap apart starts a new battle",
+			kills: []models.TopKillmail{testKill(1, 1, 0), testKill(2, 1, 15)},
+			want:  [][]int64{{1}, {2}},
+		},
+		{
+			name:  "just under the gap joins",
+			kills: []models.TopKillmail{testKill(1, 1, 0), killAt(2, 1, "2026-10-18T12:14:59Z")},
+			want:  [][]int64{{1, 2}},
+		},
+		{
+			name:  "adjacent systems join",
+			kills: []models.TopKillmail{testKill(1, 1, 0), testKill(2, 2, 5)},
+			want:  [][]int64{{1, 2}},
+		},
+		{
+			name:  "systems two jumps apart do not",
+			kills: []models.TopKillmail{testKill(1, 1, 0), testKill(2, 3, 5)},
+			want:  [][]int64{{1}, {2}},
+		},
+		{
+			name:  "unconnected system",
+			kills: []models.TopKillmail{testKill(1, 1, 0), testKill(2, 4, 1)},
+			want:  [][]int64{{1}, {2}},
+		},
+		{
+			name:  "a kill in between bridges two battles",
+			kills: []models.TopKillmail{testKill(1, 1, 0), testKill(2, 3, 1), testKill(3, 2, 2)},
+			want:  [][]int64{{1, 2, 3}},
+		},
+		{
+			name:  "a battle grows along the pipe",
+			kills: []models.TopKillmail{testKill(1, 1, 0), testKill(2, 2, 10), testKill(3, 3, 20)},
+			want:  [][]int64{{1, 2, 3}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clusters, err := clusterBattles(tt.kills, adjacency, DefaultBattleGap)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertBattles(t, clusters, tt.want)
+		})
+	}
+}
+
+func TestClusterBattlesInvalidTime(t *testing.T) {
+	kills := []models.TopKillmail{killAt(1, 1, "noon")}
+	if _, err := clusterBattles(kills, nil, DefaultBattleGap); err == nil {
+		t.Error("expected an error for an invalid killmail time")
+	}
+}
+
+func TestClusterBlocks(t *testing.T) {
+	kills := []models.TopKillmail{
+		killAt(1, 1, "2026-10-18T23:55:00Z"),
+		killAt(2, 1, "2026-10-18T23:59:59Z"),
+		// Midnight UTC starts a new block, even within the gap
+		killAt(3, 1, "2026-10-19T00:00:00Z"),
+		killAt(4, 1, "2026-10-19T00:05:00Z"),
+	}
+	clusters, err := clusterBlocks(kills, nil, DefaultBattleGap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertBattles(t, clusters, [][]int64{{1, 2}, {3, 4}})
+
+	// Clustering one block alone, as GetBattle does, gives the same battles
+	clusters, err = clusterBattles(kills[2:], nil, DefaultBattleGap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertBattles(t, clusters, [][]int64{{3, 4}})
+}
+
+func assertBattles(t *testing.T, clusters []*battleCluster, want [][]int64) {
+	t.Helper()
+	got := make(map[int64][]int64, len(clusters))
+	for _, c := range clusters {
+		for _, k := range c.kills {
+			got[c.kills[0].KillmailID] = append(got[c.kills[0].KillmailID], k.KillmailID)
+		}
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got battles %v, want %v", got, want)
+	}
+	for _, ids := range want {
+		battle := got[ids[0]]
+		if len(battle) != len(ids) {
+			t.Fatalf("got battles %v, want %v", got, want)
+		}
+		for i := range ids {
+			if battle[i] != ids[i] {
+				t.Fatalf("got battles %v, want %v", got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/astrocartics-xyz/Astrocartics-API/cache"
+	"github.com/astrocartics-xyz/Astrocartics-API/metrics"
+	"github.com/astrocartics-xyz/Astrocartics-API/models"
+	"golang.org/x/sync/singleflight"
+)
+
+// aggregateTTLs sets how long a kill aggregate of each mode is reused. Wider windows
+// change proportionally less with each new killmail, so they are kept longer.
+var aggregateTTLs = map[string]time.Duration{
+	"hour":  30 * time.Second,
+	"day":   2 * time.Minute,
+	"week":  5 * time.Minute,
+	"month": 10 * time.Minute,
+}
+
+// AggregateTimeout bounds a shared aggregate computation, whichever request started it. It should
+// be no shorter than the longest query timeout of the requests that wait on it.
+var AggregateTimeout = 30 * time.Second
+
+// aggregateCache holds the TTLs and the backend storing recent kill aggregates, keyed by endpoint and parameters.
+var aggregateCache = struct {
+	mu      sync.Mutex
+	backend cache.Cache
+	flights singleflight.Group
+}{backend: cache.NewMemory(cache.DefaultMemoryEntries)}
+
+// SetAggregateCache replaces the backend storing kill aggregates, e.g. with a Redis cache
+// shared by every replica.
+func SetAggregateCache(c cache.Cache) {
+	aggregateCache.mu.Lock()
+	defer aggregateCache.mu.Unlock()
+	aggregateCache.backend = c
+}
+
+// SetAggregateTTL overrides the cache lifetime of one mode, 0 disables caching for it.
+func SetAggregateTTL(mode string, ttl time.Duration) {
+	aggregateCache.mu.Lock()
+	defer aggregateCache.mu.Unlock()
+	aggregateTTLs[mode] = ttl
+}
+
+// AggregateTTLs returns a copy of the cache lifetime of each mode.
+func AggregateTTLs() map[string]time.Duration {
+	aggregateCache.mu.Lock()
+	defer aggregateCache.mu.Unlock()
+	ttls := make(map[string]time.Duration, len(aggregateTTLs))
+	for mode, ttl := range aggregateTTLs {
+		ttls[mode] = ttl
+	}
+	return ttls
+}
+
+// aggregateTTL returns the cache lifetime for a mode.
+func aggregateTTL(mode string) time.Duration {
+	aggregateCache.mu.Lock()
+	defer aggregateCache.mu.Unlock()
+	return aggregateTTLs[mode]
+}
+
+// cached returns the value stored under key, or calls fetch to produce it and keeps it for ttl.
+// Values are stored as JSON, so T must round-trip through encoding/json. Concurrent misses for
+// the same key in this process share a single fetch. Errors are never cached, and a failing
+// backend only costs the cache: fetch is still called. A shared fetch runs on the context of
+// the request that started it, detached from its cancellation and deadline so it completes for
+// the others, and bounded by AggregateTimeout instead.
+func cached[T any](ctx context.Context, key string, ttl time.Duration, fetch func(ctx context.Context) (T, error)) (T, error) {
+	if ttl <= 0 {
+		return fetch(ctx)
+	}
+	aggregateCache.mu.Lock()
+	backend := aggregateCache.backend
+	aggregateCache.mu.Unlock()
+	if data, ok, err := backend.Get(ctx, key); err != nil {
+		metrics.CacheLookups.WithLabelValues("aggregate", metrics.CacheError).Inc()
+		slog.WarnContext(ctx, "Error reading from the cache", "key", key, "err", err)
+	} else if ok {
+		var value T
+		if err := json.Unmarshal(data, &value); err == nil {
+			metrics.CacheLookups.WithLabelValues("aggregate", metrics.CacheHit).Inc()
+			return value, nil
+		}
+		metrics.CacheLookups.WithLabelValues("aggregate", metrics.CacheMiss).Inc()
+	} else {
+		metrics.CacheLookups.WithLabelValues("aggregate", metrics.CacheMiss).Inc()
+	}
+	results := aggregateCache.flights.DoChan(key, func() (interface{}, error) {
+		// Later requests may wait longer than the one that started the fetch
+		fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), AggregateTimeout)
+		defer cancel()
+		value, err := fetch(fetchCtx)
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s for the cache: %w", key, err)
+		}
+		if err := backend.Set(fetchCtx, key, data, ttl); err != nil {
+			slog.WarnContext(fetchCtx, "Error writing to the cache", "key", key, "err", err)
+		}
+		return value, nil
+	})
+	// Stop waiting when this request is cancelled or times out, the fetch carries on for the others
+	var zero T
+	select {
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		case res := <-results:
+			if res.Err != nil {
+				return zero, res.Err
+			}
+			return res.Val.(T), nil
+	}
+}
+
+// killSummary is the cached result of a dba kill summary query.
+type killSummary struct {
+	Name    string               `json:"name"`
+	Buckets []models.PeriodCount `json:"buckets"`
+}
+
+// cachedKillSummary runs one of the dba kill summary queries through the aggregate cache.
+func cachedKillSummary(ctx context.Context, scope string, id int, mode string, from time.Time, to time.Time, query func(context.Context, int, string, time.Time, time.Time) (string, []models.PeriodCount, error)) (killSummary, error) {
+	key := fmt.Sprintf("kills:%s:%d:%s:%d:%d", scope, id, mode, from.Unix(), to.Unix())
+	return cached(ctx, key, aggregateTTL(mode), func(ctx context.Context) (killSummary, error) {
+		name, buckets, err := query(ctx, id, mode, from, to)
+		return killSummary{Name: name, Buckets: buckets}, err
+	})
+}
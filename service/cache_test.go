@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/astrocartics-xyz/Astrocartics-API/cache"
+	"github.com/astrocartics-xyz/Astrocartics-API/models"
+)
+
+// useCache swaps the aggregate cache backend for the duration of a test.
+func useCache(t *testing.T, c cache.Cache) {
+	t.Helper()
+	aggregateCache.mu.Lock()
+	previous := aggregateCache.backend
+	aggregateCache.mu.Unlock()
+	SetAggregateCache(c)
+	t.Cleanup(func() { SetAggregateCache(previous) })
+}
+
+func TestCachedRoundTrip(t *testing.T) {
+	mr := miniredis.RunT(t)
+	redis, err := cache.NewRedis("redis://" + mr.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer redis.Close()
+	backends := map[string]cache.Cache{"memory": cache.NewMemory(10), "redis": redis}
+	for name, backend := range backends {
+		t.Run(name, func(t *testing.T) {
+			useCache(t, backend)
+			want := killSummary{Name: "Jita", Buckets: []models.PeriodCount{
+				{Period: "2026-10-18T12:00:00Z", Count: 42, DestroyedValue: 1.5e9, DroppedValue: 2.5e8},
+				{Period: "2026-10-18T11:00:00Z", Count: 7},
+			}}
+			calls := 0
+			fetch := func(ctx context.Context) (killSummary, error) {
+				calls++
+				return want, nil
+			}
+			ctx := context.Background()
+			for i := 0; i < 2; i++ {
+				got, err := cached(ctx, "test:roundtrip", time.Minute, fetch)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Fatalf("call %d returned %+v, want %+v", i+1, got, want)
+				}
+			}
+			if calls != 1 {
+				t.Errorf("fetch called %d times, want 1", calls)
+			}
+		})
+	}
+}
+
+func TestCachedSkipsErrorsAndDisabledTTL(t *testing.T) {
+	useCache(t, cache.NewMemory(10))
+	ctx := context.Background()
+	calls := 0
+	failing := func(ctx context.Context) (int, error) {
+		calls++
+		return 0, errors.New("database down")
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := cached(ctx, "test:error", time.Minute, failing); err == nil {
+			t.Fatal("expected the fetch error")
+		}
+	}
+	if calls != 2 {
+		t.Errorf("failing fetch called %d times, want 2 as errors are not cached", calls)
+	}
+
+	calls = 0
+	counting := func(ctx context.Context) (int, error) {
+		calls++
+		return calls, nil
+	}
+	for i := 0; i < 2; i++ {
+		cached(ctx, "test:disabled", 0, counting)
+	}
+	if calls != 2 {
+		t.Errorf("fetch called %d times with a zero TTL, want 2", calls)
+	}
+}
+
+func TestCachedSurvivesBackendFailure(t *testing.T) {
+	mr := miniredis.RunT(t)
+	redis, err := cache.NewRedis("redis://" + mr.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer redis.Close()
+	useCache(t, redis)
+	mr.Close()
+	got, err := cached(context.Background(), "test:down", time.Minute, func(ctx context.Context) (string, error) {
+		return "fresh", nil
+	})
+	if err != nil || got != "fresh" {
+		t.Errorf("cached = %q, %v with the backend down, want the fetched value", got, err)
+	}
+}
+
+func TestCachedOutlivesStartingDeadline(t *testing.T) {
+	useCache(t, cache.NewMemory(10))
+	started, release := make(chan struct{}), make(chan struct{})
+	fetch := func(ctx context.Context) (string, error) {
+		close(started)
+		<-release
+		return "shared", ctx.Err()
+	}
+	first, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	firstErr := make(chan error)
+	go func() {
+		_, err := cached(first, "test:deadline", time.Minute, fetch)
+		firstErr <- err
+	}()
+	<-started
+	second := make(chan string)
+	go func() {
+		got, _ := cached(context.Background(), "test:deadline", time.Minute, fetch)
+		second <- got
+	}()
+	if err := <-firstErr; !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("starting request returned %v, want its deadline", err)
+	}
+	// The fetch carries on past the deadline of the request that started it
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	if got := <-second; got != "shared" {
+		t.Errorf("waiting request got %q, want the shared value", got)
+	}
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/astrocartics-xyz/Astrocartics-API/dba"
+	"github.com/astrocartics-xyz/Astrocartics-API/models"
+	"github.com/astrocartics-xyz/Astrocartics-API/tracing"
+)
+
+// Gate camp heuristics. A camp shows up as several kills in one gate system, close together in time,
+// mostly landed by the same few ship types. Kills in the stargate-adjacent systems shortly before or
+// after mark the route through the system as the hunting ground, so pipe systems camped in a row
+// raise each other's score.
+const (
+	DefaultCampMinScore = 50.0
+	campMinKills        = 3
+	campSaturationKills = 10               // Kill count at which the volume component maxes out
+	campBurstGap        = 10 * time.Minute // Kills closer than this count as repeated
+)
+
+// scoreCamp rates the kills of one system (oldest first) on a 0 - 100 scale. neighbourTimes are the
+// times of the kills in the stargate-adjacent systems, oldest first.
+func scoreCamp(kills []models.TopKillmail, times []time.Time, neighbourTimes []time.Time, now time.Time, window time.Duration) models.CampAlert {
+	first := kills[0]
+	alert := models.CampAlert{
+		SystemID:   first.SolarSystemID,
+		SystemName: first.SystemName,
+		RegionID:   first.RegionID,
+		RegionName: first.RegionName,
+		Kills:      len(kills),
+		FirstKill:  times[0].Format(time.RFC3339),
+		LastKill:   times[len(times)-1].Format(time.RFC3339),
+	}
+	// Repetition of the final-blow ship type
+	shipCounts := map[int64]int{}
+	for _, k := range kills {
+		if k.KillShip == 0 {
+			continue
+		}
+		shipCounts[k.KillShip]++
+		if shipCounts[k.KillShip] > alert.TopKillShipKills || (shipCounts[k.KillShip] == alert.TopKillShipKills && k.KillShip < alert.TopKillShip) {
+			alert.TopKillShip = k.KillShip
+			alert.TopKillShipKills = shipCounts[k.KillShip]
+		}
+	}
+	alert.DistinctKillShips = len(shipCounts)
+	// Share of consecutive kills that followed each other quickly
+	bursts := 0
+	for i := 1; i < len(times); i++ {
+		if times[i].Sub(times[i-1]) < campBurstGap {
+			bursts++
+		}
+	}
+	// Share of the kills with a kill next door on the route less than campBurstGap before or after
+	linked := 0
+	for _, t := range times {
+		if nearAny(neighbourTimes, t, campBurstGap) {
+			linked++
+		}
+	}
+	for _, t := range neighbourTimes {
+		if nearAny(times, t, campBurstGap) {
+			alert.PathKills++
+		}
+	}
+	volume := math.Min(float64(len(kills))/campSaturationKills, 1)
+	repeat := float64(alert.TopKillShipKills) / float64(len(kills))
+	density := float64(bursts) / float64(len(kills)-1)
+	path := float64(linked) / float64(len(kills))
+	recency := math.Max(0, 1-now.Sub(times[len(times)-1]).Seconds()/window.Seconds())
+	score := 100 * (0.3*volume + 0.2*repeat + 0.15*density + 0.15*path + 0.2*recency)
+	alert.Score = math.Round(score*10) / 10
+	return alert
+}
+
+// nearAny reports whether one of times (sorted) lies less than d from t.
+func nearAny(times []time.Time, t time.Time, d time.Duration) bool {
+	i := sort.Search(len(times), func(i int) bool { return !times[i].Before(t) })
+	if i < len(times) && times[i].Sub(t) < d {
+		return true
+	}
+	return i > 0 && t.Sub(times[i-1]) < d
+}
+
+// GetGateCampAlerts flags gate systems that look camped during the window of the given mode,
+// highest score first. A regionID of 0 covers every region.
+func GetGateCampAlerts(ctx context.Context, mode string, regionID int, minScore float64) ([]models.CampAlert, error) {
+	ctx, span := tracing.Start(ctx, "service.GetGateCampAlerts")
+	defer span.End()
+	// Camps are short lived, so only short windows make sense
+	if mode != "hour" && mode != "day" {
+		return nil, fmt.Errorf("invalid mode: %s; supported: 'hour','day'", mode)
+	}
+	now := time.Now().UTC()
+	windowStart := modeWindowStart(mode, now)
+	// Kills of every region are loaded, as the route through a border system leaves the region
+	kills, err := dba.GetGateSystemKillmailsSince(ctx, windowStart, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch killmails: %w", err)
+	}
+	adjacency, err := getAdjacency(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Group kills by system, keeping time order
+	bySystem := map[int][]models.TopKillmail{}
+	timesBySystem := map[int][]time.Time{}
+	for _, k := range kills {
+		t, err := time.Parse(time.RFC3339, k.KillmailTime)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse killmail time %q: %w", k.KillmailTime, err)
+		}
+		bySystem[k.SolarSystemID] = append(bySystem[k.SolarSystemID], k)
+		timesBySystem[k.SolarSystemID] = append(timesBySystem[k.SolarSystemID], t.UTC())
+	}
+	alerts := make([]models.CampAlert, 0)
+	for systemID, systemKills := range bySystem {
+		if len(systemKills) < campMinKills || (regionID != 0 && systemKills[0].RegionID != regionID) {
+			continue
+		}
+		var neighbourTimes []time.Time
+		for neighbour := range adjacency[systemID] {
+			neighbourTimes = append(neighbourTimes, timesBySystem[neighbour]...)
+		}
+		sort.Slice(neighbourTimes, func(i, j int) bool { return neighbourTimes[i].Before(neighbourTimes[j]) })
+		alert := scoreCamp(systemKills, timesBySystem[systemID], neighbourTimes, now, now.Sub(windowStart))
+		if alert.Score >= minScore {
+			alerts = append(alerts, alert)
+		}
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		if alerts[i].Score != alerts[j].Score {
+			return alerts[i].Score > alerts[j].Score
+		}
+		return alerts[i].SystemID < alerts[j].SystemID
+	})
+	return alerts, nil
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/astrocartics-xyz/Astrocartics-API/models"
+)
+
+// campKills are kills in system 1 at the given minute offsets, landed by ships 100, 101, ...
+// unless sameShip is set.
+func campKills(sameShip bool, minutes ...int) ([]models.TopKillmail, []time.Time) {
+	kills := make([]models.TopKillmail, len(minutes))
+	times := make([]time.Time, len(minutes))
+	for i, m := range minutes {
+		kills[i] = testKill(int64(i+1), 1, m)
+		kills[i].KillShip = 100
+		if !sameShip {
+			kills[i].KillShip += int64(i)
+		}
+		times[i], _ = time.Parse(time.RFC3339, kills[i].KillmailTime)
+	}
+	return kills, times
+}
+
+// campTimes are the times at the given minute offsets.
+func campTimes(minutes ...int) []time.Time {
+	times := make([]time.Time, len(minutes))
+	for i, m := range minutes {
+		times[i] = time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC).Add(time.Duration(m) * time.Minute)
+	}
+	return times
+}
+
+func TestScoreCamp(t *testing.T) {
+	tests := []struct {
+		name       string
+		minutes    []int
+		sameShip   bool
+		neighbours []int
+		now        int // Minute offset of the time of scoring
+		wantScore  float64
+		wantPath   int
+	}{
+		{
+			name:      "saturated camp on an isolated gate",
+			minutes:   []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+			sameShip:  true,
+			now:       9,
+			wantScore: 85,
+		},
+		{
+			name:       "saturated camp with kills next door",
+			minutes:    []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+			sameShip:   true,
+			neighbours: []int{0, 5},
+			now:        9,
+			wantScore:  100,
+			wantPath:   2,
+		},
+		{
+			name:      "spread out kills by different ships",
+			minutes:   []int{0, 30, 60},
+			now:       60,
+			wantScore: 35.7,
+		},
+		{
+			name:       "kills next door raise the score",
+			minutes:    []int{0, 30, 60},
+			neighbours: []int{5, 31, 200},
+			now:        60,
+			wantScore:  45.7,
+			wantPath:   2,
+		},
+		{
+			name:       "kills next door exactly the burst gap apart are not linked",
+			minutes:    []int{0, 30, 60},
+			neighbours: []int{-10, 40, 70},
+			now:        60,
+			wantScore:  35.7,
+		},
+		{
+			name:      "repeated ship in quick succession",
+			minutes:   []int{0, 5, 9},
+			sameShip:  true,
+			now:       9,
+			wantScore: 64,
+		},
+		{
+			name:      "old kills lose recency",
+			minutes:   []int{0, 5, 9},
+			sameShip:  true,
+			now:       39,
+			wantScore: 54,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kills, times := campKills(tt.sameShip, tt.minutes...)
+			now := campTimes(tt.now)[0]
+			alert := scoreCamp(kills, times, campTimes(tt.neighbours...), now, time.Hour)
+			if alert.Score != tt.wantScore {
+				t.Errorf("Score = %v, want %v", alert.Score, tt.wantScore)
+			}
+			if alert.PathKills != tt.wantPath {
+				t.Errorf("PathKills = %d, want %d", alert.PathKills, tt.wantPath)
+			}
+			if alert.Kills != len(tt.minutes) {
+				t.Errorf("Kills = %d, want %d", alert.Kills, len(tt.minutes))
+			}
+		})
+	}
+}
+
+func TestScoreCampTopKillShip(t *testing.T) {
+	kills, times := campKills(false, 0, 1, 2, 3, 4)
+	// Ties go to the lowest type ID
+	for i, ship := range []int64{101, 100, 101, 100} {
+		kills[i].KillShip = ship
+	}
+	kills[4].KillShip = 0 // Unknown ships are not counted
+	alert := scoreCamp(kills, times, nil, times[4], time.Hour)
+	if alert.TopKillShip != 100 || alert.TopKillShipKills != 2 {
+		t.Errorf("top kill ship = %d x%d, want 100 x2", alert.TopKillShip, alert.TopKillShipKills)
+	}
+	if alert.DistinctKillShips != 2 {
+		t.Errorf("DistinctKillShips = %d, want 2", alert.DistinctKillShips)
+	}
+	if alert.FirstKill != "2026-10-18T12:00:00Z" || alert.LastKill != "2026-10-18T12:04:00Z" {
+		t.Errorf("kills from %s to %s", alert.FirstKill, alert.LastKill)
+	}
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/astrocartics-xyz/Astrocartics-API/dba"
+	"github.com/astrocartics-xyz/Astrocartics-API/models"
+	"github.com/astrocartics-xyz/Astrocartics-API/tracing"
+)
+
+// Readiness statuses.
+const (
+	StatusOK          = "ok"
+	StatusFail        = "fail"
+	StatusUnavailable = "unavailable"
+)
+
+// readinessCheckTimeout bounds each readiness check, so a stuck database fails the probe
+// instead of hanging it.
+const readinessCheckTimeout = 2 * time.Second
+
+// MaxIngestionLag is how long ago a killmail may last have been ingested before the API reports
+// itself unready. Zero or less, the default, only reports the lag: a stalled ingester does not
+// stop the API from serving what it has.
+var MaxIngestionLag time.Duration
+
+var shuttingDown atomic.Bool
+
+// BeginShutdown makes readiness fail from now on, so load balancers stop sending requests
+// while the server drains the ones in flight.
+func BeginShutdown() {
+	shuttingDown.Store(true)
+}
+
+// CheckReadiness checks that the database is reachable, the static data has been imported
+// and killmails are being ingested. The API is ready only when every check passes.
+func CheckReadiness(ctx context.Context) models.Readiness {
+	ctx, span := tracing.Start(ctx, "service.CheckReadiness")
+	defer span.End()
+	checks := []struct {
+		name  string
+		check func(context.Context) (string, error)
+	}{
+		{"database", checkDatabase},
+		{"static_data", checkStaticData},
+		{"ingestion", checkIngestion},
+	}
+	readiness := models.Readiness{Status: StatusOK, Components: map[string]models.ComponentStatus{}}
+	if shuttingDown.Load() {
+		readiness.Status = StatusUnavailable
+		readiness.Components["server"] = models.ComponentStatus{Status: StatusFail, Detail: "shutting down"}
+	}
+	for _, c := range checks {
+		start := time.Now()
+		checkCtx, cancel := context.WithTimeout(ctx, readinessCheckTimeout)
+		detail, err := c.check(checkCtx)
+		cancel()
+		component := models.ComponentStatus{Status: StatusOK, Detail: detail, LatencyMS: float64(time.Since(start).Microseconds()) / 1000}
+		if err != nil {
+			component.Status = StatusFail
+			component.Detail = err.Error()
+			readiness.Status = StatusUnavailable
+		}
+		readiness.Components[c.name] = component
+	}
+	return readiness
+}
+
+func checkDatabase(ctx context.Context) (string, error) {
+	return "", dba.PingDB(ctx)
+}
+
+func checkStaticData(ctx context.Context) (string, error) {
+	loaded, err := dba.HasStaticData(ctx)
+	if err != nil {
+		return "", err
+	}
+	if !loaded {
+		return "", fmt.Errorf("static data not imported")
+	}
+	return "", nil
+}
+
+func checkIngestion(ctx context.Context) (string, error) {
+	latest, err := dba.GetLatestIngestTime(ctx)
+	if err != nil {
+		return "", err
+	}
+	if latest.IsZero() {
+		if MaxIngestionLag > 0 {
+			return "", fmt.Errorf("no killmails ingested")
+		}
+		return "no killmails ingested", nil
+	}
+	lag := time.Since(latest).Round(time.Second)
+	if MaxIngestionLag > 0 && lag > MaxIngestionLag {
+		return "", fmt.Errorf("last killmail ingested %s ago, more than %s", lag, MaxIngestionLag)
+	}
+	return fmt.Sprintf("last killmail ingested %s ago", lag), nil
+}
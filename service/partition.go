@@ -0,0 +1,176 @@
+package service
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/astrocartics-xyz/Astrocartics-API/dba"
+	"github.com/astrocartics-xyz/Astrocartics-API/models"
+)
+
+// Retention actions for killmail partitions past the retention period.
+const (
+	RetentionDetach  = "detach"  // Detach the partition, leaving it as a standalone table
+	RetentionDrop    = "drop"    // Drop the partition and its rows
+	RetentionArchive = "archive" // Write the rows to <ArchiveDir>/<partition>.jsonl.gz, then drop the partition
+)
+
+// PartitionPolicy configures killmail partition maintenance.
+type PartitionPolicy struct {
+	Ahead           int    // Months of partitions to create ahead of the current one
+	RetentionMonths int    // Months of killmails to keep before the current one, 0 keeps everything
+	Action          string // What to do with partitions past retention
+	ArchiveDir      string // Directory for archived partitions
+}
+
+// Validate checks the policy is complete.
+func (p PartitionPolicy) Validate() error {
+	if p.Ahead < 0 {
+		return fmt.Errorf("partitions ahead must not be negative")
+	}
+	if p.RetentionMonths < 0 {
+		return fmt.Errorf("retention months must not be negative")
+	}
+	if p.RetentionMonths == 0 {
+		return nil
+	}
+	switch p.Action {
+		case RetentionDetach, RetentionDrop:
+			return nil
+		case RetentionArchive:
+			if p.ArchiveDir == "" {
+				return fmt.Errorf("retention action archive needs an archive directory")
+			}
+			return nil
+		default:
+			return fmt.Errorf("invalid retention action: %s; supported: 'detach', 'drop', 'archive'", p.Action)
+	}
+}
+
+// retentionCutoff is the start of the oldest month kept by the policy.
+func (p PartitionPolicy) retentionCutoff(now time.Time) time.Time {
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -p.RetentionMonths, 0)
+}
+
+// MaintainKillmailPartitions applies the retention policy, then creates upcoming partitions and
+// moves rows out of the default partition. It does nothing while killmails is not partitioned.
+func MaintainKillmailPartitions(ctx context.Context, policy PartitionPolicy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	partitioned, err := dba.IsKillmailsPartitioned(ctx)
+	if err != nil {
+		return err
+	}
+	if !partitioned {
+		return nil
+	}
+	// Retention first, so late rows for expired months are purged rather than given a partition
+	if policy.RetentionMonths > 0 {
+		if err := applyRetention(ctx, policy); err != nil {
+			return err
+		}
+	}
+	created, err := dba.EnsureKillmailPartitions(ctx, policy.Ahead)
+	for _, name := range created {
+		slog.Info("Created killmail partition", "partition", name)
+	}
+	return err
+}
+
+// applyRetention handles every partition that ends before the retention cutoff.
+func applyRetention(ctx context.Context, policy PartitionPolicy) error {
+	cutoff := policy.retentionCutoff(time.Now())
+	partitions, err := dba.GetKillmailPartitions(ctx)
+	if err != nil {
+		return err
+	}
+	for _, p := range partitions {
+		if p.To.After(cutoff) {
+			break
+		}
+		switch policy.Action {
+			case RetentionDetach:
+				err = dba.DetachKillmailPartition(ctx, p.Name)
+			case RetentionDrop:
+				err = dba.DropKillmailPartition(ctx, p.Name)
+			case RetentionArchive:
+				err = archivePartition(ctx, p.Name, policy.ArchiveDir)
+		}
+		if err != nil {
+			return err
+		}
+		slog.Info("Applied retention action to killmail partition", "action", policy.Action, "partition", p.Name)
+	}
+	purged, err := dba.DeleteUnpartitionedKillmailsBefore(ctx, cutoff)
+	if err != nil {
+		return err
+	}
+	if purged > 0 {
+		slog.Info("Purged killmails past retention from the default partition", "killmails", purged, "before", cutoff.Format("2006-01"))
+	}
+	return nil
+}
+
+// archivePartition writes a partition to a gzipped JSONL file and drops it once the file is safely on disk.
+// The file is written under a temporary name and renamed, so a crash never leaves a truncated archive.
+func archivePartition(ctx context.Context, name string, dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	path := filepath.Join(dir, name+".jsonl.gz")
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("failed to create archive %s: %w", tmp, err)
+	}
+	defer os.Remove(tmp)
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	enc := json.NewEncoder(gz)
+	n, err := dba.ExportKillmailPartition(ctx, name, func(k models.Killmails) error {
+		return enc.Encode(k)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to archive partition %s: %w", name, err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to compress archive %s: %w", path, err)
+	}
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("failed to sync archive %s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close archive %s: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to finalise archive %s: %w", path, err)
+	}
+	slog.Info("Archived killmail partition", "partition", name, "killmails", n, "path", path)
+	return dba.DropKillmailPartition(ctx, name)
+}
+
+// StartPartitionMaintenance runs MaintainKillmailPartitions every interval until ctx is cancelled.
+func StartPartitionMaintenance(ctx context.Context, interval time.Duration, policy PartitionPolicy) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := MaintainKillmailPartitions(ctx, policy); err != nil {
+				slog.Error("Error maintaining killmail partitions", "err", err)
+			}
+			select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+			}
+		}
+	}()
+}
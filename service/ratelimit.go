@@ -0,0 +1,98 @@
+package service
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Tier is a usage tier: a token bucket refilled at Rate tokens per second up to Burst,
+// and the heaviest request cost it may spend at once.
+type Tier struct {
+	Rate    float64
+	Burst   float64
+	MaxCost float64
+}
+
+// TierAnonymous applies per IP address to requests without an API key.
+const TierAnonymous = "anonymous"
+
+// Tiers lists the usage tiers by name. Keys can be issued for any tier but anonymous.
+var Tiers = map[string]Tier{
+	TierAnonymous: {Rate: 2, Burst: 30, MaxCost: 5},
+	"free":        {Rate: 5, Burst: 60, MaxCost: 5},
+	"pro":         {Rate: 25, Burst: 300, MaxCost: 20},
+	"internal":    {Rate: 200, Burst: 2000, MaxCost: 100},
+}
+
+// KeyCheckTier limits, per IP address, the API keys that fail their check: guessing keys buys
+// a handful of database lookups, then one every ten seconds.
+var KeyCheckTier = Tier{Rate: 0.1, Burst: 10, MaxCost: 1}
+
+// RateLimitResult is the outcome of taking tokens from a bucket.
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int           // Bucket size
+	Remaining  int           // Tokens left after this request
+	Reset      time.Duration // Until the bucket is full again
+	RetryAfter time.Duration // Until enough tokens for this request, when not allowed
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// limiterIdle is how long an untouched bucket is kept; by then it has refilled anyway.
+const limiterIdle = 10 * time.Minute
+
+var limiter = struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time
+}{buckets: map[string]*bucket{}}
+
+// TakeTokens takes cost tokens from the bucket named key, which refills according to tier.
+// Buckets live in process memory, so each replica enforces its own share of the limits.
+func TakeTokens(key string, tier Tier, cost float64) RateLimitResult {
+	return useTokens(key, tier, cost, true)
+}
+
+// CheckTokens reports whether the bucket named key holds cost tokens, without taking them.
+func CheckTokens(key string, tier Tier, cost float64) RateLimitResult {
+	return useTokens(key, tier, cost, false)
+}
+
+func useTokens(key string, tier Tier, cost float64, take bool) RateLimitResult {
+	now := time.Now()
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	// Forget idle buckets now and then, they would be full again
+	if now.Sub(limiter.swept) > limiterIdle {
+		for k, b := range limiter.buckets {
+			if now.Sub(b.last) > limiterIdle {
+				delete(limiter.buckets, k)
+			}
+		}
+		limiter.swept = now
+	}
+	b, ok := limiter.buckets[key]
+	if !ok {
+		b = &bucket{tokens: tier.Burst, last: now}
+		limiter.buckets[key] = b
+	}
+	b.tokens = math.Min(tier.Burst, b.tokens+now.Sub(b.last).Seconds()*tier.Rate)
+	b.last = now
+	result := RateLimitResult{Limit: int(tier.Burst)}
+	if b.tokens >= cost {
+		if take {
+			b.tokens -= cost
+		}
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((cost - b.tokens) / tier.Rate * float64(time.Second))
+	}
+	result.Remaining = int(b.tokens)
+	result.Reset = time.Duration((tier.Burst - b.tokens) / tier.Rate * float64(time.Second))
+	return result
+}
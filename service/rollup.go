@@ -0,0 +1,35 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/astrocartics-xyz/Astrocartics-API/dba"
+)
+
+// StartKillRollupWorker refreshes the kill rollup tables every interval until ctx is cancelled.
+// Kill summaries and rankings switch to the rollups after the first successful refresh.
+func StartKillRollupWorker(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			start := time.Now()
+			rebuilt, err := dba.RefreshKillRollups(ctx)
+			if err != nil {
+				slog.Error("Error refreshing kill rollups", "err", err)
+			} else {
+				dba.SetKillRollups(true)
+				if rebuilt > 0 {
+					slog.Info("Refreshed kill rollups", "buckets", rebuilt, "duration", time.Since(start).Round(time.Millisecond))
+				}
+			}
+			select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+			}
+		}
+	}()
+}
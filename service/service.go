@@ -1,108 +1,172 @@
 package service
 
 import (
+	"context"
 	"fmt"
+	"math"
+	"strings"
+	"time"
 
 	"github.com/astrocartics-xyz/Astrocartics-API/dba"
 	"github.com/astrocartics-xyz/Astrocartics-API/models"
+	"github.com/astrocartics-xyz/Astrocartics-API/tracing"
 )
 
-func GetAllRegions() ([]models.Region, error) {
-	return dba.GetAllRegions()
+func GetAllRegions(ctx context.Context) ([]models.Region, error) {
+	ctx, span := tracing.Start(ctx, "service.GetAllRegions")
+	defer span.End()
+	return dba.GetAllRegions(ctx)
 }
 
-func GetRegionByID(id int) (*models.Region, error) {
-	return dba.GetRegionByID(id)
+func GetRegionByID(ctx context.Context, id int) (*models.Region, error) {
+	ctx, span := tracing.Start(ctx, "service.GetRegionByID")
+	defer span.End()
+	return dba.GetRegionByID(ctx, id)
 }
 
-func GetRegionByName(name string) (*models.Region, error) {
-	return dba.GetRegionByName(name)
+func GetRegionByName(ctx context.Context, name string) (*models.Region, error) {
+	ctx, span := tracing.Start(ctx, "service.GetRegionByName")
+	defer span.End()
+	return dba.GetRegionByName(ctx, name)
 }
 
-func GetAllConstellations() ([]models.Constellation, error) {
-	return dba.GetAllConstellations()
+func GetAllConstellations(ctx context.Context) ([]models.Constellation, error) {
+	ctx, span := tracing.Start(ctx, "service.GetAllConstellations")
+	defer span.End()
+	return dba.GetAllConstellations(ctx)
 }
 
-func GetConstellationByIDOrRegionID(id int) ([]models.Constellation, error) {
-	return dba.GetConstellationByIDOrRegionID(id)
+func GetConstellationByIDOrRegionID(ctx context.Context, id int) ([]models.Constellation, error) {
+	ctx, span := tracing.Start(ctx, "service.GetConstellationByIDOrRegionID")
+	defer span.End()
+	return dba.GetConstellationByIDOrRegionID(ctx, id)
 }
 
-func GetConstellationByName(name string) (*models.Constellation, error) {
-	return dba.GetConstellationByName(name)
+func GetConstellationByName(ctx context.Context, name string) (*models.Constellation, error) {
+	ctx, span := tracing.Start(ctx, "service.GetConstellationByName")
+	defer span.End()
+	return dba.GetConstellationByName(ctx, name)
 }
 
-func GetAllSystems() ([]models.System, error) {
-	return dba.GetAllSystems()
+func GetSystemByIDOrConstellationID(ctx context.Context, id int) ([]models.System, error) {
+	ctx, span := tracing.Start(ctx, "service.GetSystemByIDOrConstellationID")
+	defer span.End()
+	return dba.GetSystemByIDOrConstellationID(ctx, id)
 }
 
-func GetSystemByIDOrConstellationID(id int) ([]models.System, error) {
-	return dba.GetSystemByIDOrConstellationID(id)
+// GetSystems returns one page of the systems matching the filter, the number of matches and the
+// cursor of the next page.
+func GetSystems(ctx context.Context, filter models.SystemFilter, page models.PageRequest) ([]models.System, int, *models.PageCursor, error) {
+	ctx, span := tracing.Start(ctx, "service.GetSystems")
+	defer span.End()
+	if filter.MinSecurity != nil && filter.MaxSecurity != nil && *filter.MinSecurity > *filter.MaxSecurity {
+		return nil, 0, nil, fmt.Errorf("invalid filter: min_security is greater than max_security")
+	}
+	if filter.MinPlanets != nil && *filter.MinPlanets < 0 {
+		return nil, 0, nil, fmt.Errorf("invalid filter: min_planets must not be negative")
+	}
+	if b := filter.BoundingBox; b != nil && (b.MinX > b.MaxX || b.MinY > b.MaxY || b.MinZ > b.MaxZ) {
+		return nil, 0, nil, fmt.Errorf("invalid filter: bounding box minimum exceeds its maximum")
+	}
+	return dba.GetSystems(ctx, filter, page)
 }
 
-func GetSystemByName(name string) (*models.System, error) {
-	return dba.GetSystemByName(name)
+func GetSystemByName(ctx context.Context, name string) (*models.System, error) {
+	ctx, span := tracing.Start(ctx, "service.GetSystemByName")
+	defer span.End()
+	return dba.GetSystemByName(ctx, name)
 }
 
-func GetSystemNameByID(systemID int) (string, error) {
-	return dba.GetSystemNameByID(systemID)
+func GetSystemNameByID(ctx context.Context, systemID int) (string, error) {
+	ctx, span := tracing.Start(ctx, "service.GetSystemNameByID")
+	defer span.End()
+	return dba.GetSystemNameByID(ctx, systemID)
 }
 
-func GetSystemsByRegionID(id int) ([]models.System, error) {
-	return dba.GetSystemsByRegionID(id)
+func GetSystemsByRegionID(ctx context.Context, id int) ([]models.System, error) {
+	ctx, span := tracing.Start(ctx, "service.GetSystemsByRegionID")
+	defer span.End()
+	return dba.GetSystemsByRegionID(ctx, id)
 }
 
-func GetAllStargates() ([]models.Stargate, error) {
-	return dba.GetAllStargates()
+func GetStargates(ctx context.Context, page models.PageRequest) ([]models.Stargate, int, *models.PageCursor, error) {
+	ctx, span := tracing.Start(ctx, "service.GetStargates")
+	defer span.End()
+	return dba.GetStargates(ctx, page)
 }
 
-func GetStargateBySystemID(id int) ([]models.Stargate, error) {
-	return dba.GetStargateBySystemID(id)
+func GetStargateBySystemID(ctx context.Context, id int) ([]models.Stargate, error) {
+	ctx, span := tracing.Start(ctx, "service.GetStargateBySystemID")
+	defer span.End()
+	return dba.GetStargateBySystemID(ctx, id)
 }
 
-func GetStargateByConstellationID(id int) ([]models.Stargate, error) {
-        return dba.GetStargateByConstellationID(id)
+func GetStargateByConstellationID(ctx context.Context, id int) ([]models.Stargate, error) {
+	ctx, span := tracing.Start(ctx, "service.GetStargateByConstellationID")
+	defer span.End()
+        return dba.GetStargateByConstellationID(ctx, id)
 }
 
-func GetStargateByRegionID(id int) ([]models.Stargate, error) {
-        return dba.GetStargateByRegionID(id)
+func GetStargateByRegionID(ctx context.Context, id int) ([]models.Stargate, error) {
+	ctx, span := tracing.Start(ctx, "service.GetStargateByRegionID")
+	defer span.End()
+        return dba.GetStargateByRegionID(ctx, id)
 }
 
-func GetSpectralClassCounts() ([]models.SpectralClassCount, error) {
-	return dba.GetSpectralClassCounts()
+func GetSpectralClassCounts(ctx context.Context) ([]models.SpectralClassCount, error) {
+	ctx, span := tracing.Start(ctx, "service.GetSpectralClassCounts")
+	defer span.End()
+	return dba.GetSpectralClassCounts(ctx)
 }
 
 // Planet service functions
-func GetAllPlanets() ([]models.Planet, error) {
-	return dba.GetAllPlanets()
+func GetPlanets(ctx context.Context, page models.PageRequest) ([]models.Planet, int, *models.PageCursor, error) {
+	ctx, span := tracing.Start(ctx, "service.GetPlanets")
+	defer span.End()
+	return dba.GetPlanets(ctx, page)
 }
 
-func GetPlanetByID(id int) (*models.Planet, error) {
-	return dba.GetPlanetByID(id)
+func GetPlanetByID(ctx context.Context, id int) (*models.Planet, error) {
+	ctx, span := tracing.Start(ctx, "service.GetPlanetByID")
+	defer span.End()
+	return dba.GetPlanetByID(ctx, id)
 }
 
-func GetPlanetByName(name string) (*models.Planet, error) {
-	return dba.GetPlanetByName(name)
+func GetPlanetByName(ctx context.Context, name string) (*models.Planet, error) {
+	ctx, span := tracing.Start(ctx, "service.GetPlanetByName")
+	defer span.End()
+	return dba.GetPlanetByName(ctx, name)
 }
 
-func GetPlanetsBySystemID(id int) ([]models.Planet, error) {
-	return dba.GetPlanetsBySystemID(id)
+func GetPlanetsBySystemID(ctx context.Context, id int) ([]models.Planet, error) {
+	ctx, span := tracing.Start(ctx, "service.GetPlanetsBySystemID")
+	defer span.End()
+	return dba.GetPlanetsBySystemID(ctx, id)
 }
 
 // Station service functions
-func GetAllStations() ([]models.Station, error) {
-	return dba.GetAllStations()
+func GetStations(ctx context.Context, page models.PageRequest) ([]models.Station, int, *models.PageCursor, error) {
+	ctx, span := tracing.Start(ctx, "service.GetStations")
+	defer span.End()
+	return dba.GetStations(ctx, page)
 }
 
-func GetStationByID(id int) (*models.Station, error) {
-	return dba.GetStationByID(id)
+func GetStationByID(ctx context.Context, id int) (*models.Station, error) {
+	ctx, span := tracing.Start(ctx, "service.GetStationByID")
+	defer span.End()
+	return dba.GetStationByID(ctx, id)
 }
 
-func GetStationByName(name string) (*models.Station, error) {
-	return dba.GetStationByName(name)
+func GetStationByName(ctx context.Context, name string) (*models.Station, error) {
+	ctx, span := tracing.Start(ctx, "service.GetStationByName")
+	defer span.End()
+	return dba.GetStationByName(ctx, name)
 }
 
-func GetStationsBySystemID(id int) ([]models.Station, error) {
-	return dba.GetStationsBySystemID(id)
+func GetStationsBySystemID(ctx context.Context, id int) ([]models.Station, error) {
+	ctx, span := tracing.Start(ctx, "service.GetStationsBySystemID")
+	defer span.End()
+	return dba.GetStationsBySystemID(ctx, id)
 }
 
 // isValidKillMode validates if the mode is one of the supported modes
@@ -117,52 +181,65 @@ func isValidKillMode(mode string) bool {
 }
 
 // Used for heat map display by regionID
-func GetSystemHeatmapReportByRegionMode(regionID int, mode string) (models.HeatmapReport, error) {
+func GetSystemHeatmapReportByRegionMode(ctx context.Context, regionID int, mode string) (models.HeatmapReport, error) {
+	ctx, span := tracing.Start(ctx, "service.GetSystemHeatmapReportByRegionMode")
+	defer span.End()
 	var empty models.HeatmapReport
 	// validate mode
 	if !isValidKillMode(mode) {
 		return empty, fmt.Errorf("invalid mode: %s; supported: 'hour','day','week','month'", mode)
 	}
-	// dba returns: regionName, points, windowStart, windowEnd, error
-	regionName, points, windowStart, windowEnd, err := dba.GetSystemHeatmapByRegionMode(regionID, mode)
-	if err != nil {
-		return empty, fmt.Errorf("failed to fetch system heatmap: %w", err)
-	}
-	// Compute total kills
-	total := 0
-	for _, p := range points {
-		total += p.Kills
-	}
-	// Generate report
-	report := models.HeatmapReport{
-		Mode:        mode,
-		RegionID:    regionID,
-		RegionName:  regionName,
-		WindowStart: windowStart,
-		WindowEnd:   windowEnd,
-		TotalKills:  total,
-		Buckets:     points,
-	}
-	return report, nil
+	key := fmt.Sprintf("heatmap:%d:%s", regionID, mode)
+	return cached(ctx, key, aggregateTTL(mode), func(ctx context.Context) (models.HeatmapReport, error) {
+		// dba returns: regionName, points, windowStart, windowEnd, error
+		regionName, points, windowStart, windowEnd, err := dba.GetSystemHeatmapByRegionMode(ctx, regionID, mode)
+		if err != nil {
+			return empty, fmt.Errorf("failed to fetch system heatmap: %w", err)
+		}
+		// Compute total kills
+		total := 0
+		for _, p := range points {
+			total += p.Kills
+		}
+		// Generate report
+		report := models.HeatmapReport{
+			Mode:        mode,
+			RegionID:    regionID,
+			RegionName:  regionName,
+			WindowStart: windowStart,
+			WindowEnd:   windowEnd,
+			TotalKills:  total,
+			Buckets:     points,
+		}
+		return report, nil
+	})
 }
 // GetLast15KillmailsBySystemID returns the last 15 killmails for a system.
-func GetRecentKillmailsBySystemID(systemID int) ([]models.Killmails, error) {
-	return dba.GetRecentKillmailsBySystemID(systemID)
+func GetRecentKillmailsBySystemID(ctx context.Context, systemID int) ([]models.Killmails, error) {
+	ctx, span := tracing.Start(ctx, "service.GetRecentKillmailsBySystemID")
+	defer span.End()
+	return dba.GetRecentKillmailsBySystemID(ctx, systemID)
 }
 
-// GetKillCountBySystemID retrieves kill counts by system ID and calculates the total
-func GetKillCountBySystemID(systemID int, mode string) (string, int, []models.PeriodCount, error) {
+// GetKillCountBySystemID retrieves kill counts by system ID and calculates the total.
+// A zero from or to leaves that end of the time range open.
+func GetKillCountBySystemID(ctx context.Context, systemID int, mode string, from time.Time, to time.Time) (string, int, []models.PeriodCount, error) {
+	ctx, span := tracing.Start(ctx, "service.GetKillCountBySystemID")
+	defer span.End()
 	// Validate mode
 	if !isValidKillMode(mode) {
 		return "", 0, nil, fmt.Errorf("invalid mode: %s; supported: 'day', 'week', 'month'", mode)
 	}
+	// Validate the optional time range
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return "", 0, nil, fmt.Errorf("invalid range: from must be before to")
+	}
 	// Fetch data from the dba layer
-	systemName, buckets, err := dba.GetKillsBySystemID(systemID, mode)
+	summary, err := cachedKillSummary(ctx, "system", systemID, mode, from, to, dba.GetKillsBySystemID)
+	systemName, buckets := summary.Name, summary.Buckets
 	if err != nil {
 		return "", 0, nil, fmt.Errorf("failed to fetch kills: %w", err)
 	}
-	// Use `systemName` if needed, or discard it with `_` if it’s not needed
-	fmt.Printf("System Name: %s\n", systemName)
 	// Calculate total kills
 	total := 0
 	for _, bucket := range buckets {
@@ -172,18 +249,23 @@ func GetKillCountBySystemID(systemID int, mode string) (string, int, []models.Pe
 }
 
 // GetKillCountByConstellationID retrieves kill counts by constellation ID and calculates the total
-func GetKillCountByConstellationID(constellationID int, mode string) (string, int, []models.PeriodCount, error) {
+func GetKillCountByConstellationID(ctx context.Context, constellationID int, mode string, from time.Time, to time.Time) (string, int, []models.PeriodCount, error) {
+	ctx, span := tracing.Start(ctx, "service.GetKillCountByConstellationID")
+	defer span.End()
 	// Validate mode
 	if !isValidKillMode(mode) {
 		return "", 0, nil, fmt.Errorf("invalid mode: %s; supported: 'day', 'week', 'month'", mode)
 	}
+	// Validate the optional time range
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return "", 0, nil, fmt.Errorf("invalid range: from must be before to")
+	}
 	// Fetch data from the dba layer
-	constellationName, buckets, err := dba.GetKillsByConstellationID(constellationID, mode)
+	summary, err := cachedKillSummary(ctx, "constellation", constellationID, mode, from, to, dba.GetKillsByConstellationID)
+	constellationName, buckets := summary.Name, summary.Buckets
 	if err != nil {
 		return "", 0, nil, fmt.Errorf("failed to fetch kills: %w", err)
 	}
-	// Use `constellationName` if needed, or discard it with `_` if it’s not needed
-	fmt.Printf("System Name: %s\n", constellationName)
 	// Calculate total kills
 	total := 0
 	for _, bucket := range buckets {
@@ -193,18 +275,23 @@ func GetKillCountByConstellationID(constellationID int, mode string) (string, in
 }
 
 // GetKillCountByRegionID retrieves kill counts by region ID and calculates the total
-func GetKillCountByRegionID(regionID int, mode string) (string, int, []models.PeriodCount, error) {
+func GetKillCountByRegionID(ctx context.Context, regionID int, mode string, from time.Time, to time.Time) (string, int, []models.PeriodCount, error) {
+	ctx, span := tracing.Start(ctx, "service.GetKillCountByRegionID")
+	defer span.End()
 	// Validate mode
 	if !isValidKillMode(mode) {
 		return "", 0, nil, fmt.Errorf("invalid mode: %s; supported: 'day', 'week', 'month'", mode)
 	}
+	// Validate the optional time range
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return "", 0, nil, fmt.Errorf("invalid range: from must be before to")
+	}
 	// Fetch data from the dba layer
-	regionName, buckets, err := dba.GetKillsByRegionID(regionID, mode)
+	summary, err := cachedKillSummary(ctx, "region", regionID, mode, from, to, dba.GetKillsByRegionID)
+	regionName, buckets := summary.Name, summary.Buckets
 	if err != nil {
 		return "", 0, nil, fmt.Errorf("failed to fetch kills: %w", err)
 	}
-	// Use `systemName` if needed, or discard it with `_` if it’s not needed
-	fmt.Printf("System Name: %s\n", regionName)
 	// Calculate total kills
 	total := 0
 	for _, bucket := range buckets {
@@ -214,16 +301,277 @@ func GetKillCountByRegionID(regionID int, mode string) (string, int, []models.Pe
 }
 
 // Get top regions by fetching top regions by kill count for a given time window
-func GetTopRegionsByKills(mode string) ([]models.RegionKillCount, error) {
-	return dba.GetTopRegionsByKills(mode)
+func GetTopRegionsByKills(ctx context.Context, mode string) ([]models.RegionKillCount, error) {
+	ctx, span := tracing.Start(ctx, "service.GetTopRegionsByKills")
+	defer span.End()
+	return cached(ctx, "GetTopRegionsByKills:"+mode, aggregateTTL(mode), func(ctx context.Context) ([]models.RegionKillCount, error) {
+		return dba.GetTopRegionsByKills(ctx, mode)
+	})
 }
 
 // Get top constellations by fetching top constellations by kill count for a given time window
-func GetTopConstellationsByKills(mode string) ([]models.ConstellationKillCount, error) {
-	return dba.GetTopConstellationsByKills(mode)
+func GetTopConstellationsByKills(ctx context.Context, mode string) ([]models.ConstellationKillCount, error) {
+	ctx, span := tracing.Start(ctx, "service.GetTopConstellationsByKills")
+	defer span.End()
+	return cached(ctx, "GetTopConstellationsByKills:"+mode, aggregateTTL(mode), func(ctx context.Context) ([]models.ConstellationKillCount, error) {
+		return dba.GetTopConstellationsByKills(ctx, mode)
+	})
 }
 
 // Get top systems by fetching top systems by kill count for a given time window
-func GetTopSystemsByKills(mode string) ([]models.SystemKillCount, error) {
-	return dba.GetTopSystemsByKills(mode)
+func GetTopSystemsByKills(ctx context.Context, mode string) ([]models.SystemKillCount, error) {
+	ctx, span := tracing.Start(ctx, "service.GetTopSystemsByKills")
+	defer span.End()
+	return cached(ctx, "GetTopSystemsByKills:"+mode, aggregateTTL(mode), func(ctx context.Context) ([]models.SystemKillCount, error) {
+		return dba.GetTopSystemsByKills(ctx, mode)
+	})
+}
+
+// activityDays labels the rows of an activity profile, matching ISO day-of-week order.
+var activityDays = []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday", "Sunday"}
+
+// GetActivityProfile builds a 7x24 day-of-week by hour-of-day matrix of kills and ISK
+// for a system, constellation or region over the window of the given mode.
+func GetActivityProfile(ctx context.Context, scope string, id int, mode string, tz string) (models.ActivityProfile, error) {
+	ctx, span := tracing.Start(ctx, "service.GetActivityProfile")
+	defer span.End()
+	var empty models.ActivityProfile
+	// Validate mode
+	if !isValidKillMode(mode) {
+		return empty, fmt.Errorf("invalid mode: %s; supported: 'hour','day','week','month'", mode)
+	}
+	// Validate timezone, "Local" would depend on the server so only accept explicit zones
+	loc, err := time.LoadLocation(tz)
+	if err != nil || tz == "Local" {
+		return empty, fmt.Errorf("invalid timezone: %s", tz)
+	}
+	name, err := dba.GetScopeName(ctx, scope, id)
+	if err != nil {
+		return empty, err
+	}
+	type profileRows struct {
+		Cells       []models.ActivityCell `json:"cells"`
+		WindowStart string                `json:"window_start"`
+		WindowEnd   string                `json:"window_end"`
+	}
+	key := fmt.Sprintf("activity:%s:%d:%s:%s", scope, id, mode, loc.String())
+	rows, err := cached(ctx, key, aggregateTTL(mode), func(ctx context.Context) (profileRows, error) {
+		cells, windowStart, windowEnd, err := dba.GetActivityProfile(ctx, scope, id, mode, loc.String())
+		return profileRows{cells, windowStart, windowEnd}, err
+	})
+	cells, windowStart, windowEnd := rows.Cells, rows.WindowStart, rows.WindowEnd
+	if err != nil {
+		return empty, fmt.Errorf("failed to fetch activity profile: %w", err)
+	}
+	// Fill the matrix, cells without kills stay at zero
+	kills := make([][]int, 7)
+	value := make([][]float64, 7)
+	for d := range kills {
+		kills[d] = make([]int, 24)
+		value[d] = make([]float64, 24)
+	}
+	totalKills := 0
+	totalValue := 0.0
+	for _, c := range cells {
+		if c.DayOfWeek < 0 || c.DayOfWeek > 6 || c.Hour < 0 || c.Hour > 23 {
+			continue
+		}
+		kills[c.DayOfWeek][c.Hour] = c.Kills
+		value[c.DayOfWeek][c.Hour] = c.Value
+		totalKills += c.Kills
+		totalValue += c.Value
+	}
+	// Generate report
+	profile := models.ActivityProfile{
+		Scope:       scope,
+		ID:          id,
+		Name:        name,
+		Mode:        mode,
+		Timezone:    loc.String(),
+		WindowStart: windowStart,
+		WindowEnd:   windowEnd,
+		TotalKills:  totalKills,
+		TotalValue:  totalValue,
+		Days:        activityDays,
+		Kills:       kills,
+		Value:       value,
+	}
+	return profile, nil
+}
+
+// isValidScope validates if the scope is one of the supported aggregation scopes
+func isValidScope(scope string) bool {
+	validScopes := map[string]bool{
+		"system": true,
+		"constellation": true,
+		"region": true,
+	}
+	return validScopes[scope]
+}
+
+// GetTopKillmails returns the most valuable killmails for a scope and window.
+// An empty scope returns the most valuable killmails universe-wide.
+func GetTopKillmails(ctx context.Context, scope string, id int, mode string, limit int) ([]models.TopKillmail, error) {
+	ctx, span := tracing.Start(ctx, "service.GetTopKillmails")
+	defer span.End()
+	// Validate mode and scope
+	if !isValidKillMode(mode) {
+		return nil, fmt.Errorf("invalid mode: %s; supported: 'hour','day','week','month'", mode)
+	}
+	if scope != "" && !isValidScope(scope) {
+		return nil, fmt.Errorf("invalid scope: %s; supported: 'system','constellation','region'", scope)
+	}
+	key := fmt.Sprintf("top-killmails:%s:%d:%s:%d", scope, id, mode, limit)
+	return cached(ctx, key, aggregateTTL(mode), func(ctx context.Context) ([]models.TopKillmail, error) {
+		return dba.GetTopKillmails(ctx, scope, id, mode, limit)
+	})
+}
+
+// getValueDistribution computes the stats and logarithmic histogram of one killmail value column.
+func getValueDistribution(ctx context.Context, scope string, id int, mode string, column string, bins int) (models.ValueDistribution, error) {
+	dist, err := dba.GetKillmailValueStats(ctx, scope, id, mode, column)
+	if err != nil {
+		return dist, err
+	}
+	dist.Bins = []models.ValueBin{}
+	if dist.Count == 0 {
+		return dist, nil
+	}
+	// Log bins need a positive lower bound, zero-value kills land in the first bin
+	lo := math.Max(dist.Min, 1)
+	hi := dist.Max
+	if hi <= lo {
+		dist.Bins = []models.ValueBin{{Min: dist.Min, Max: dist.Max, Count: dist.Count}}
+		return dist, nil
+	}
+	dist.Bins, err = dba.GetKillmailValueHistogram(ctx, scope, id, mode, column, lo, hi, bins)
+	if err != nil {
+		return dist, err
+	}
+	return dist, nil
+}
+
+// GetKillmailValueDistribution returns the distribution of total, destroyed and fitted value
+// for a scope and window. An empty scope covers the whole universe.
+func GetKillmailValueDistribution(ctx context.Context, scope string, id int, mode string, bins int) (models.KillmailValueDistribution, error) {
+	ctx, span := tracing.Start(ctx, "service.GetKillmailValueDistribution")
+	defer span.End()
+	var empty models.KillmailValueDistribution
+	// Validate mode and scope
+	if !isValidKillMode(mode) {
+		return empty, fmt.Errorf("invalid mode: %s; supported: 'hour','day','week','month'", mode)
+	}
+	if scope != "" && !isValidScope(scope) {
+		return empty, fmt.Errorf("invalid scope: %s; supported: 'system','constellation','region'", scope)
+	}
+	key := fmt.Sprintf("distribution:%s:%d:%s:%d", scope, id, mode, bins)
+	return cached(ctx, key, aggregateTTL(mode), func(ctx context.Context) (models.KillmailValueDistribution, error) {
+		return getKillmailValueDistribution(ctx, scope, id, mode, bins)
+	})
+}
+
+// getKillmailValueDistribution computes the uncached value distribution report.
+func getKillmailValueDistribution(ctx context.Context, scope string, id int, mode string, bins int) (models.KillmailValueDistribution, error) {
+	var empty models.KillmailValueDistribution
+	now := time.Now().UTC()
+	report := models.KillmailValueDistribution{
+		Scope:       scope,
+		ID:          id,
+		Mode:        mode,
+		WindowStart: modeWindowStart(mode, now).Format(time.RFC3339),
+		WindowEnd:   now.Format(time.RFC3339),
+	}
+	var err error
+	if report.TotalValue, err = getValueDistribution(ctx, scope, id, mode, "total_value", bins); err != nil {
+		return empty, fmt.Errorf("failed to fetch value distribution: %w", err)
+	}
+	if report.DestroyedValue, err = getValueDistribution(ctx, scope, id, mode, "destroyed_value", bins); err != nil {
+		return empty, fmt.Errorf("failed to fetch value distribution: %w", err)
+	}
+	if report.FittedValue, err = getValueDistribution(ctx, scope, id, mode, "fitted_value", bins); err != nil {
+		return empty, fmt.Errorf("failed to fetch value distribution: %w", err)
+	}
+	return report, nil
+}
+
+// minSearchLength is the shortest query worth searching for.
+const minSearchLength = 2
+
+// Search matches a name query across the requested entity types, all of them when types is empty.
+func Search(ctx context.Context, q string, types []string, limit int) ([]models.SearchResult, error) {
+	ctx, span := tracing.Start(ctx, "service.Search")
+	defer span.End()
+	q = strings.TrimSpace(q)
+	if len([]rune(q)) < minSearchLength {
+		return nil, fmt.Errorf("invalid query: must be at least %d characters", minSearchLength)
+	}
+	if len(types) == 0 {
+		types = dba.SearchTypes
+	}
+	seen := map[string]bool{}
+	var unique []string
+	for _, t := range types {
+		if !isValidSearchType(t) {
+			return nil, fmt.Errorf("invalid type: %s; supported: %s", t, strings.Join(dba.SearchTypes, ", "))
+		}
+		if !seen[t] {
+			seen[t] = true
+			unique = append(unique, t)
+		}
+	}
+	return dba.SearchNames(ctx, q, unique, limit)
+}
+
+func isValidSearchType(t string) bool {
+	for _, valid := range dba.SearchTypes {
+		if t == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// maxUniverseLookup caps the IDs or names resolved by one universe lookup.
+const maxUniverseLookup = 1000
+
+// GetUniverseNames resolves up to maxUniverseLookup IDs to their category and name.
+func GetUniverseNames(ctx context.Context, ids []int) ([]models.UniverseName, error) {
+	ctx, span := tracing.Start(ctx, "service.GetUniverseNames")
+	defer span.End()
+	if len(ids) == 0 || len(ids) > maxUniverseLookup {
+		return nil, fmt.Errorf("invalid request: between 1 and %d IDs are required", maxUniverseLookup)
+	}
+	return dba.GetUniverseNames(ctx, ids)
+}
+
+// GetUniverseIDs resolves up to maxUniverseLookup exact names to IDs, grouped by category.
+func GetUniverseIDs(ctx context.Context, names []string) (models.UniverseIDs, error) {
+	ctx, span := tracing.Start(ctx, "service.GetUniverseIDs")
+	defer span.End()
+	var ids models.UniverseIDs
+	if len(names) == 0 || len(names) > maxUniverseLookup {
+		return ids, fmt.Errorf("invalid request: between 1 and %d names are required", maxUniverseLookup)
+	}
+	matches, err := dba.GetUniverseIDs(ctx, names)
+	if err != nil {
+		return ids, err
+	}
+	for _, m := range matches {
+		entity := models.UniverseEntity{ID: m.ID, Name: m.Name}
+		switch m.Category {
+			case "region":
+				ids.Regions = append(ids.Regions, entity)
+			case "constellation":
+				ids.Constellations = append(ids.Constellations, entity)
+			case "system":
+				ids.Systems = append(ids.Systems, entity)
+			case "planet":
+				ids.Planets = append(ids.Planets, entity)
+			case "station":
+				ids.Stations = append(ids.Stations, entity)
+			case "stargate":
+				ids.Stargates = append(ids.Stargates, entity)
+		}
+	}
+	return ids, nil
 }
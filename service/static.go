@@ -0,0 +1,39 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/astrocartics-xyz/Astrocartics-API/dba"
+	"github.com/astrocartics-xyz/Astrocartics-API/tracing"
+)
+
+// staticUpdatedAtTTL is how long the static data update time is reused before it is read again.
+const staticUpdatedAtTTL = time.Minute
+
+var staticUpdatedAt struct {
+	mu      sync.Mutex
+	value   time.Time
+	fetched time.Time
+}
+
+// GetStaticDataUpdatedAt returns when the static data last changed, re-reading it at most once a minute.
+// Returns the zero time if it is unknown.
+func GetStaticDataUpdatedAt(ctx context.Context) time.Time {
+	ctx, span := tracing.Start(ctx, "service.GetStaticDataUpdatedAt")
+	defer span.End()
+	staticUpdatedAt.mu.Lock()
+	defer staticUpdatedAt.mu.Unlock()
+	if time.Since(staticUpdatedAt.fetched) < staticUpdatedAtTTL {
+		return staticUpdatedAt.value
+	}
+	updatedAt, err := dba.GetStaticDataUpdatedAt(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error fetching static data update time", "err", err)
+	}
+	staticUpdatedAt.value = updatedAt
+	staticUpdatedAt.fetched = time.Now()
+	return updatedAt
+}